@@ -2,21 +2,19 @@ package main
 
 import (
 	"net/http"
-	"os"
-	"path"
 )
 
 func (cfg apiConfig) resetDatabase(w http.ResponseWriter, r *http.Request) {
 
-	dirs, err := os.ReadDir(cfg.assetsRoot)
+	keys, err := cfg.db.GetMediaBlobKeys(r.Context())
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
 		return
 	}
 
-	for _, dir := range dirs {
-		if err := os.Remove(path.Join(cfg.assetsRoot, dir.Name())); err != nil {
+	for _, key := range keys {
+		if err := cfg.deleteBlobFiles(r.Context(), key); err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Failed to delete user assets", err, false)
 			return
 		}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+)
+
+var (
+	errAlbumMediaNotViewable = errors.New("album media must be viewable by the album owner")
+)
+
+// AlbumParams describes an album. On update the media list replaces the
+// album's current media, in the order given.
+type AlbumParams struct {
+	Title       string      `json:"title" validate:"required,max=200"`
+	Description string      `json:"description" validate:"max=2000"`
+	MediaIDs    []uuid.UUID `json:"mediaIds" validate:"max=500,unique"`
+}
+
+type AlbumResponse struct {
+	ID            uuid.UUID       `json:"id"`
+	Title         string          `json:"title"`
+	Description   string          `json:"description,omitempty"`
+	MediaCount    int32           `json:"mediaCount"`
+	CoverPhotoURL sql.NullString  `json:"coverPhotoURL"`
+	Media         []MediaResponse `json:"media,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+}
+
+// checkAlbumMedia makes sure albums only collect media the user can see,
+// from their own trips or trips shared with them.
+func (cfg apiConfig) checkAlbumMedia(ctx context.Context, mediaIDs []uuid.UUID, userID uuid.UUID) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	count, err := cfg.db.CountViewableMedia(ctx, database.CountViewableMediaParams{
+		MediaIds: mediaIDs,
+		UserID:   userID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if count != int64(len(mediaIDs)) {
+		return errAlbumMediaNotViewable
+	}
+
+	return nil
+}
+
+func (cfg apiConfig) handlerGetAlbums(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	albums, err := cfg.db.GetAlbums(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get your albums", err, false)
+		return
+	}
+
+	albumsResponse := make([]AlbumResponse, 0, len(albums))
+
+	for _, album := range albums {
+		albumsResponse = append(albumsResponse, AlbumResponse{
+			ID:            album.ID,
+			Title:         album.Title,
+			Description:   album.Description.String,
+			MediaCount:    album.MediaCount,
+			CoverPhotoURL: cfg.signMediaNullURL(album.CoverPhotoUrl),
+			CreatedAt:     album.CreatedAt,
+			UpdatedAt:     album.UpdatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   albumsResponse,
+	})
+}
+
+func (cfg apiConfig) handlerGetAlbum(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	albumUUID, err := uuid.Parse(chi.URLParam(r, "albumID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	album, err := cfg.db.GetAlbum(r.Context(), database.GetAlbumParams{
+		ID:     albumUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find album possibly deleted", err, false)
+		return
+	}
+
+	media, err := cfg.db.GetAlbumMedia(r.Context(), album.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get this album's media", err, false)
+		return
+	}
+
+	mediaResponse, err := cfg.transformMediaList(r.Context(), media)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get this album's photo sizes", err, false)
+		return
+	}
+
+	albumResponse := AlbumResponse{
+		ID:          album.ID,
+		Title:       album.Title,
+		Description: album.Description.String,
+		MediaCount:  int32(len(mediaResponse)),
+		Media:       mediaResponse,
+		CreatedAt:   album.CreatedAt,
+		UpdatedAt:   album.UpdatedAt,
+	}
+
+	for _, medium := range mediaResponse {
+		if medium.PhotoURL != "" {
+			albumResponse.CoverPhotoURL = sql.NullString{String: medium.PhotoURL, Valid: true}
+			break
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   albumResponse,
+	})
+}
+
+func (cfg apiConfig) handlerCreateAlbum(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	params := &AlbumParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read album", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	err = cfg.checkAlbumMedia(r.Context(), params.MediaIDs, user.ID)
+
+	if errors.Is(err, errAlbumMediaNotViewable) {
+		respondWithError(w, http.StatusBadRequest, "Albums can only collect media from trips you can view", err, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check album media", err, false)
+		return
+	}
+
+	var albumID uuid.UUID
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		albumID, err = q.CreateAlbum(r.Context(), database.CreateAlbumParams{
+			UserID:      user.ID,
+			Title:       params.Title,
+			Description: sql.NullString{String: params.Description, Valid: params.Description != ""},
+		})
+
+		if err != nil || len(params.MediaIDs) == 0 {
+			return err
+		}
+
+		return q.AddAlbumMedia(r.Context(), database.AddAlbumMediaParams{
+			AlbumID:  albumID,
+			MediaIds: params.MediaIDs,
+		})
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create album", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data: struct {
+			AlbumID uuid.UUID `json:"albumID"`
+		}{
+			AlbumID: albumID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerUpdateAlbum(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	albumUUID, err := uuid.Parse(chi.URLParam(r, "albumID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	params := &AlbumParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read album", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	err = cfg.checkAlbumMedia(r.Context(), params.MediaIDs, user.ID)
+
+	if errors.Is(err, errAlbumMediaNotViewable) {
+		respondWithError(w, http.StatusBadRequest, "Albums can only collect media from trips you can view", err, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check album media", err, false)
+		return
+	}
+
+	var albumID uuid.UUID
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		albumID, err = q.UpdateAlbum(r.Context(), database.UpdateAlbumParams{
+			Title:       params.Title,
+			Description: sql.NullString{String: params.Description, Valid: params.Description != ""},
+			ID:          albumUUID,
+			UserID:      user.ID,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteAlbumMedia(r.Context(), albumID); err != nil || len(params.MediaIDs) == 0 {
+			return err
+		}
+
+		return q.AddAlbumMedia(r.Context(), database.AddAlbumMediaParams{
+			AlbumID:  albumID,
+			MediaIds: params.MediaIDs,
+		})
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Unable to find album possibly deleted", err, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update album", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: struct {
+			AlbumID uuid.UUID `json:"albumID"`
+		}{
+			AlbumID: albumID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerDeleteAlbum(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	albumUUID, err := uuid.Parse(chi.URLParam(r, "albumID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteAlbum(r.Context(), database.DeleteAlbumParams{
+		ID:     albumUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete album", err, false)
+		return
+	}
+
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Unable to find album possibly deleted", sql.ErrNoRows, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mambo-dev/adventrak-backend/internal/storage"
+)
+
+// mediaURLLifetime is how long a signed media URL stays valid. Expiry is
+// rounded to whole windows so a photo keeps the same URL, and stays in the
+// browser cache, for a while instead of changing on every response.
+const mediaURLLifetime = time.Hour
+
+var (
+	errMediaURLExpired   = errors.New("media url has expired")
+	errMediaURLSignature = errors.New("media url signature does not match")
+)
+
+// mediaURLExpiry is when media URLs signed now expire, as a Unix time.
+func mediaURLExpiry() int64 {
+	return time.Now().Truncate(mediaURLLifetime).Add(2 * mediaURLLifetime).Unix()
+}
+
+func (cfg apiConfig) mediaSignature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, cfg.mediaURLSecret)
+	fmt.Fprintf(mac, "%v\n%v", key, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signMediaURL turns a stored media URL into one the assets handler will
+// serve until it expires. URLs that do not point at stored media are
+// returned as they are.
+func (cfg apiConfig) signMediaURL(mediaURL string) string {
+	key, err := cfg.mediaKey(mediaURL)
+
+	if err != nil {
+		return mediaURL
+	}
+
+	expires := mediaURLExpiry()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", cfg.mediaSignature(key, expires))
+
+	return cfg.mediaURL(key) + "?" + query.Encode()
+}
+
+func (cfg apiConfig) signMediaNullURL(mediaURL sql.NullString) sql.NullString {
+	if !mediaURL.Valid {
+		return mediaURL
+	}
+
+	return sql.NullString{String: cfg.signMediaURL(mediaURL.String), Valid: true}
+}
+
+// verifyMediaURL checks the signature on a request for key and returns when
+// the link expires.
+func (cfg apiConfig) verifyMediaURL(key string, query url.Values) (time.Time, error) {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+
+	if err != nil {
+		return time.Time{}, errMediaURLSignature
+	}
+
+	want := cfg.mediaSignature(key, expires)
+
+	if !hmac.Equal([]byte(want), []byte(query.Get("signature"))) {
+		return time.Time{}, errMediaURLSignature
+	}
+
+	expiresAt := time.Unix(expires, 0)
+
+	if time.Now().After(expiresAt) {
+		return time.Time{}, errMediaURLExpired
+	}
+
+	return expiresAt, nil
+}
+
+// handlerServeMedia serves a stored file to anyone holding a valid signed
+// URL for it. Files in an object store are handed off to a presigned URL
+// that expires with the signed one; local files are served here with
+// conditional and range request support.
+func (cfg apiConfig) handlerServeMedia(w http.ResponseWriter, r *http.Request) {
+	key, err := storage.CleanKey(chi.URLParam(r, "*"))
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Media not found", err, false)
+		return
+	}
+
+	expiresAt, err := cfg.verifyMediaURL(key, r.URL.Query())
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "This media link is invalid or has expired.", err, false)
+		return
+	}
+
+	if cfg.redirectMedia {
+		presigned, err := cfg.storage.Presign(r.Context(), key, time.Until(expiresAt))
+
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+			return
+		}
+
+		http.Redirect(w, r, presigned, http.StatusFound)
+		return
+	}
+
+	body, object, err := cfg.storage.Get(r.Context(), key)
+
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Media not found", err, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	defer body.Close()
+
+	contentType := object.ContentType
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(time.Until(expiresAt).Seconds())))
+
+	if object.ETag != "" {
+		w.Header().Set("ETag", object.ETag)
+	}
+
+	if content, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", object.ModTime, content)
+		return
+	}
+
+	if object.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(object.Size, 10))
+	}
+
+	if r.Method != http.MethodHead {
+		io.Copy(w, body)
+	}
+}
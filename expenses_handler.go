@@ -0,0 +1,789 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/currency"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+)
+
+const expenseDateLayout = "2006-01-02"
+
+// expenseCategories lists the categories in the order summaries report them.
+var expenseCategories = []database.ExpenseCategory{
+	database.ExpenseCategoryAccommodation,
+	database.ExpenseCategoryTransport,
+	database.ExpenseCategoryFood,
+	database.ExpenseCategoryActivities,
+	database.ExpenseCategoryShopping,
+	database.ExpenseCategoryFees,
+	database.ExpenseCategoryOther,
+}
+
+var (
+	amountPattern           = regexp.MustCompile(`^\d{1,10}(\.\d{1,4})?$`)
+	errInvalidAmount        = errors.New("amount must be a positive decimal with at most 4 decimal places")
+	errInvalidCurrency      = errors.New("currency must be an ISO 4217 code")
+	errExpenseStopNotInTrip = errors.New("stop does not belong to the trip")
+)
+
+type ExpenseParams struct {
+	TripStopID  *uuid.UUID `json:"tripStopId,omitempty"`
+	Amount      string     `json:"amount" validate:"required"`
+	Currency    string     `json:"currency" validate:"required,len=3"`
+	Category    string     `json:"category,omitempty"`
+	SpentOn     string     `json:"spentOn" validate:"required,datetime=2006-01-02"`
+	Payer       string     `json:"payer,omitempty" validate:"max=100"`
+	Description string     `json:"description,omitempty" validate:"max=255"`
+}
+
+type BudgetParams struct {
+	Amount   string `json:"amount,omitempty"`
+	Currency string `json:"currency,omitempty"`
+}
+
+type ExpenseResponse struct {
+	ID          uuid.UUID      `json:"id"`
+	TripID      uuid.UUID      `json:"tripId"`
+	TripStopID  uuid.NullUUID  `json:"tripStopId"`
+	Amount      float64        `json:"amount"`
+	Currency    string         `json:"currency"`
+	Category    string         `json:"category"`
+	SpentOn     string         `json:"spentOn"`
+	Payer       sql.NullString `json:"payer"`
+	Description sql.NullString `json:"description"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+}
+
+type ExpenseTotalResponse struct {
+	Category string  `json:"category,omitempty"`
+	Payer    string  `json:"payer,omitempty"`
+	Currency string  `json:"currency,omitempty"`
+	Total    float64 `json:"total"`
+	Expenses int64   `json:"expenses"`
+}
+
+// ExpenseSummaryResponse reports spending converted into the user's home
+// currency. Amounts in currencies missing from the rate table are left out
+// of the totals and listed under unconverted.
+type ExpenseSummaryResponse struct {
+	Currency       string                 `json:"currency"`
+	Spent          float64                `json:"spent"`
+	BudgetAmount   sql.NullFloat64        `json:"budgetAmount"`
+	BudgetCurrency sql.NullString         `json:"budgetCurrency"`
+	Budget         sql.NullFloat64        `json:"budget"`
+	Remaining      sql.NullFloat64        `json:"remaining"`
+	Categories     []ExpenseTotalResponse `json:"categories"`
+	Payers         []ExpenseTotalResponse `json:"payers"`
+	Unconverted    []ExpenseTotalResponse `json:"unconverted"`
+}
+
+type TripExpensesResponse struct {
+	Expenses []ExpenseResponse      `json:"expenses"`
+	Summary  ExpenseSummaryResponse `json:"summary"`
+}
+
+func parseExpenseCategory(category string) (database.ExpenseCategory, error) {
+	if category == "" {
+		return database.ExpenseCategoryOther, nil
+	}
+
+	for _, known := range expenseCategories {
+		if string(known) == category {
+			return known, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown expense category %q", category)
+}
+
+func parseAmount(amount string) (string, error) {
+	if !amountPattern.MatchString(amount) || strings.Trim(amount, "0.") == "" {
+		return "", errInvalidAmount
+	}
+
+	return amount, nil
+}
+
+func parseCurrencyCode(code string) (string, error) {
+	code = strings.ToUpper(code)
+
+	if !currency.ValidCode(code) {
+		return "", errInvalidCurrency
+	}
+
+	return code, nil
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func convertToExpenseResponse(expense *database.GetExpenseRow, expenses *database.GetTripExpensesRow) ExpenseResponse {
+	if expense != nil {
+		return ExpenseResponse{
+			ID:          expense.ID,
+			TripID:      expense.TripID,
+			TripStopID:  expense.TripStopID,
+			Amount:      expense.Amount,
+			Currency:    expense.Currency,
+			Category:    string(expense.Category),
+			SpentOn:     expense.SpentOn.Format(expenseDateLayout),
+			Payer:       expense.Payer,
+			Description: expense.Description,
+			CreatedAt:   expense.CreatedAt,
+			UpdatedAt:   expense.UpdatedAt,
+		}
+	}
+
+	return ExpenseResponse{
+		ID:          expenses.ID,
+		TripID:      expenses.TripID,
+		TripStopID:  expenses.TripStopID,
+		Amount:      expenses.Amount,
+		Currency:    expenses.Currency,
+		Category:    string(expenses.Category),
+		SpentOn:     expenses.SpentOn.Format(expenseDateLayout),
+		Payer:       expenses.Payer,
+		Description: expenses.Description,
+		CreatedAt:   expenses.CreatedAt,
+		UpdatedAt:   expenses.UpdatedAt,
+	}
+}
+
+// summariseExpenses totals a trip's expenses per category and payer in the
+// user's home currency and compares them with the trip budget.
+func (cfg apiConfig) summariseExpenses(ctx context.Context, tripID uuid.UUID, userID uuid.UUID, homeCurrency string) (ExpenseSummaryResponse, error) {
+	totals, err := cfg.db.GetTripExpenseTotals(ctx, database.GetTripExpenseTotalsParams{
+		TripID: tripID,
+		UserID: userID,
+	})
+
+	if err != nil {
+		return ExpenseSummaryResponse{}, err
+	}
+
+	budget, err := cfg.db.GetTripBudget(ctx, database.GetTripBudgetParams{
+		ID:     tripID,
+		UserID: userID,
+	})
+
+	if err != nil {
+		return ExpenseSummaryResponse{}, err
+	}
+
+	summary := ExpenseSummaryResponse{
+		Currency:       homeCurrency,
+		BudgetAmount:   budget.BudgetAmount,
+		BudgetCurrency: budget.BudgetCurrency,
+		Categories:     make([]ExpenseTotalResponse, 0),
+		Payers:         make([]ExpenseTotalResponse, 0),
+		Unconverted:    make([]ExpenseTotalResponse, 0),
+	}
+
+	categories := make(map[database.ExpenseCategory]*ExpenseTotalResponse)
+	payers := make(map[string]*ExpenseTotalResponse)
+	unconverted := make(map[string]*ExpenseTotalResponse)
+
+	for _, total := range totals {
+		converted, err := cfg.exchangeRates.Convert(total.Total, total.Currency, homeCurrency)
+
+		if errors.Is(err, currency.ErrUnknownCurrency) {
+			if unconverted[total.Currency] == nil {
+				unconverted[total.Currency] = &ExpenseTotalResponse{Currency: total.Currency}
+			}
+
+			unconverted[total.Currency].Total += total.Total
+			unconverted[total.Currency].Expenses += total.Expenses
+			continue
+		}
+
+		if err != nil {
+			return ExpenseSummaryResponse{}, err
+		}
+
+		summary.Spent += converted
+
+		if categories[total.Category] == nil {
+			categories[total.Category] = &ExpenseTotalResponse{Category: string(total.Category)}
+		}
+
+		categories[total.Category].Total += converted
+		categories[total.Category].Expenses += total.Expenses
+
+		if total.Payer.Valid {
+			if payers[total.Payer.String] == nil {
+				payers[total.Payer.String] = &ExpenseTotalResponse{Payer: total.Payer.String}
+			}
+
+			payers[total.Payer.String].Total += converted
+			payers[total.Payer.String].Expenses += total.Expenses
+		}
+	}
+
+	for _, category := range expenseCategories {
+		if total, ok := categories[category]; ok {
+			total.Total = roundMoney(total.Total)
+			summary.Categories = append(summary.Categories, *total)
+		}
+	}
+
+	for _, total := range payers {
+		total.Total = roundMoney(total.Total)
+		summary.Payers = append(summary.Payers, *total)
+	}
+
+	sort.Slice(summary.Payers, func(i, j int) bool {
+		return summary.Payers[i].Payer < summary.Payers[j].Payer
+	})
+
+	for _, total := range unconverted {
+		total.Total = roundMoney(total.Total)
+		summary.Unconverted = append(summary.Unconverted, *total)
+	}
+
+	sort.Slice(summary.Unconverted, func(i, j int) bool {
+		return summary.Unconverted[i].Currency < summary.Unconverted[j].Currency
+	})
+
+	if budget.BudgetAmount.Valid && budget.BudgetCurrency.Valid {
+		converted, err := cfg.exchangeRates.Convert(budget.BudgetAmount.Float64, budget.BudgetCurrency.String, homeCurrency)
+
+		if err != nil && !errors.Is(err, currency.ErrUnknownCurrency) {
+			return ExpenseSummaryResponse{}, err
+		}
+
+		if err == nil {
+			summary.Budget = sql.NullFloat64{Float64: roundMoney(converted), Valid: true}
+			summary.Remaining = sql.NullFloat64{Float64: roundMoney(converted - summary.Spent), Valid: true}
+		}
+	}
+
+	summary.Spent = roundMoney(summary.Spent)
+
+	return summary, nil
+}
+
+func (cfg apiConfig) handlerGetTripExpenses(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	expenses, err := cfg.db.GetTripExpenses(r.Context(), database.GetTripExpensesParams{
+		TripID: trip.ID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trip expenses", err, false)
+		return
+	}
+
+	summary, err := cfg.summariseExpenses(r.Context(), trip.ID, user.ID, user.HomeCurrency)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to summarise trip expenses", err, false)
+		return
+	}
+
+	expensesResponse := make([]ExpenseResponse, 0, len(expenses))
+
+	for _, expense := range expenses {
+		expensesResponse = append(expensesResponse, convertToExpenseResponse(nil, &expense))
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: TripExpensesResponse{
+			Expenses: expensesResponse,
+			Summary:  summary,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerGetExpense(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	expenseUUID, err := uuid.Parse(chi.URLParam(r, "expenseID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	expense, err := cfg.db.GetExpense(r.Context(), database.GetExpenseParams{
+		ID:     expenseUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find expense possibly deleted", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   convertToExpenseResponse(&expense, nil),
+	})
+}
+
+type expenseFields struct {
+	amount   string
+	currency string
+	category database.ExpenseCategory
+	spentOn  time.Time
+}
+
+// readExpenseFields checks the parts of an expense the validator cannot.
+func readExpenseFields(params *ExpenseParams) (expenseFields, error) {
+	amount, err := parseAmount(params.Amount)
+
+	if err != nil {
+		return expenseFields{}, err
+	}
+
+	code, err := parseCurrencyCode(params.Currency)
+
+	if err != nil {
+		return expenseFields{}, err
+	}
+
+	category, err := parseExpenseCategory(params.Category)
+
+	if err != nil {
+		return expenseFields{}, err
+	}
+
+	spentOn, err := time.Parse(expenseDateLayout, params.SpentOn)
+
+	if err != nil {
+		return expenseFields{}, err
+	}
+
+	return expenseFields{
+		amount:   amount,
+		currency: code,
+		category: category,
+		spentOn:  spentOn,
+	}, nil
+}
+
+func (cfg apiConfig) handlerCreateExpense(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	params := &ExpenseParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read expense details", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	fields, err := readExpenseFields(params)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err, false)
+		return
+	}
+
+	var tripStopID uuid.NullUUID
+	if params.TripStopID != nil {
+		tripStopID = uuid.NullUUID{UUID: *params.TripStopID, Valid: true}
+	}
+
+	expenseID, err := cfg.db.CreateExpense(r.Context(), database.CreateExpenseParams{
+		TripID:      trip.ID,
+		TripStopID:  tripStopID,
+		UserID:      user.ID,
+		Amount:      fields.amount,
+		Currency:    fields.currency,
+		Category:    fields.category,
+		SpentOn:     fields.spentOn,
+		Payer:       nullString(strings.TrimSpace(params.Payer)),
+		Description: nullString(strings.TrimSpace(params.Description)),
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Expenses can only be attached to a stop of the same trip", errExpenseStopNotInTrip, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create expense", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data: struct {
+			ExpenseID uuid.UUID `json:"expenseID"`
+		}{
+			ExpenseID: expenseID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerUpdateExpense(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	expenseUUID, err := uuid.Parse(chi.URLParam(r, "expenseID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	expense, err := cfg.db.GetExpense(r.Context(), database.GetExpenseParams{
+		ID:     expenseUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find expense possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(expense.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	params := &ExpenseParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read expense details", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	fields, err := readExpenseFields(params)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err, false)
+		return
+	}
+
+	var tripStopID uuid.NullUUID
+	if params.TripStopID != nil {
+		tripStopID = uuid.NullUUID{UUID: *params.TripStopID, Valid: true}
+	}
+
+	expenseID, err := cfg.db.UpdateExpense(r.Context(), database.UpdateExpenseParams{
+		TripStopID:  tripStopID,
+		Amount:      fields.amount,
+		Currency:    fields.currency,
+		Category:    fields.category,
+		SpentOn:     fields.spentOn,
+		Payer:       nullString(strings.TrimSpace(params.Payer)),
+		Description: nullString(strings.TrimSpace(params.Description)),
+		ID:          expense.ID,
+		UserID:      user.ID,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Expenses can only be attached to a stop of the same trip", errExpenseStopNotInTrip, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update expense", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: struct {
+			ExpenseID uuid.UUID `json:"expenseID"`
+		}{
+			ExpenseID: expenseID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerDeleteExpense(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	expenseUUID, err := uuid.Parse(chi.URLParam(r, "expenseID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	expense, err := cfg.db.GetExpense(r.Context(), database.GetExpenseParams{
+		ID:     expenseUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find expense possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(expense.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	err = cfg.db.DeleteExpense(r.Context(), database.DeleteExpenseParams{
+		ID:     expense.ID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete expense", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
+
+func (cfg apiConfig) handlerSetTripBudget(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	params := &BudgetParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read budget details", err, false)
+		return
+	}
+
+	// An empty amount clears the budget.
+	var budgetAmount, budgetCurrency sql.NullString
+
+	if params.Amount != "" {
+		amount, err := parseAmount(params.Amount)
+
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err, false)
+			return
+		}
+
+		code := user.HomeCurrency
+
+		if params.Currency != "" {
+			code, err = parseCurrencyCode(params.Currency)
+
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error(), err, false)
+				return
+			}
+		}
+
+		budgetAmount = sql.NullString{String: amount, Valid: true}
+		budgetCurrency = sql.NullString{String: code, Valid: true}
+	}
+
+	tripID, err := cfg.db.SetTripBudget(r.Context(), database.SetTripBudgetParams{
+		BudgetAmount:   budgetAmount,
+		BudgetCurrency: budgetCurrency,
+		ID:             trip.ID,
+		UserID:         user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to set trip budget", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: struct {
+			TripID uuid.UUID `json:"tripID"`
+		}{
+			TripID: tripID,
+		},
+	})
+}
+
+func loadExchangeRates(path string) (*currency.Rates, error) {
+	if path == "" {
+		return currency.New(), nil
+	}
+
+	return currency.Load(path)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/emissions"
+)
+
+type ModeFootprintResponse struct {
+	Mode     string  `json:"mode"`
+	Distance float64 `json:"distance"`
+	CO2Kg    float64 `json:"co2Kg"`
+}
+
+// FootprintResponse holds the estimated CO2 in kilograms. Distances are in
+// meters, untracked distance covers legs without a transport mode.
+type FootprintResponse struct {
+	CO2Kg             float64                 `json:"co2Kg"`
+	UntrackedDistance float64                 `json:"untrackedDistance"`
+	Modes             []ModeFootprintResponse `json:"modes"`
+}
+
+// parseTransportMode turns an optional transport mode from a request body
+// into its database value. An empty mode clears it.
+func parseTransportMode(mode string) (database.NullTransportMode, error) {
+	if mode == "" {
+		return database.NullTransportMode{}, nil
+	}
+
+	for _, known := range emissions.Modes {
+		if known == mode {
+			return database.NullTransportMode{TransportMode: database.TransportMode(mode), Valid: true}, nil
+		}
+	}
+
+	return database.NullTransportMode{}, fmt.Errorf("unknown transport mode %q", mode)
+}
+
+func nullTransportModeString(mode database.NullTransportMode) sql.NullString {
+	return sql.NullString{String: string(mode.TransportMode), Valid: mode.Valid}
+}
+
+func roundCO2Kg(grams float64) float64 {
+	return math.Round(grams/100) / 10
+}
+
+func (cfg apiConfig) convertToFootprintResponse(footprint emissions.Footprint) FootprintResponse {
+	response := FootprintResponse{
+		CO2Kg:             roundCO2Kg(footprint.CO2),
+		UntrackedDistance: math.Round(footprint.Untracked),
+		Modes:             make([]ModeFootprintResponse, 0, len(footprint.Distance)),
+	}
+
+	for _, mode := range emissions.Modes {
+		distance, ok := footprint.Distance[mode]
+
+		if !ok {
+			continue
+		}
+
+		co2, _ := cfg.emissionFactors.Estimate(mode, distance)
+
+		response.Modes = append(response.Modes, ModeFootprintResponse{
+			Mode:     mode,
+			Distance: math.Round(distance),
+			CO2Kg:    roundCO2Kg(co2),
+		})
+	}
+
+	return response
+}
+
+// getFootprints estimates the footprint of a user's trips, grouped by the
+// key picked for each leg. A leg without its own mode uses the trip's mode.
+func getFootprints[K comparable](
+	cfg apiConfig,
+	ctx context.Context,
+	params database.GetTripLegsParams,
+	key func(database.GetTripLegsRow) K,
+) (map[K]emissions.Footprint, error) {
+	legs, err := cfg.db.GetTripLegs(ctx, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	footprints := make(map[K]emissions.Footprint)
+
+	for _, leg := range legs {
+		footprint := footprints[key(leg)]
+		cfg.emissionFactors.Add(&footprint, string(leg.TransportMode.TransportMode), leg.Distance)
+		footprints[key(leg)] = footprint
+	}
+
+	return footprints, nil
+}
+
+func footprintByTrip(leg database.GetTripLegsRow) uuid.UUID {
+	return leg.TripID
+}
+
+func footprintByYear(leg database.GetTripLegsRow) int32 {
+	return leg.Year
+}
+
+func loadEmissionFactors(path string) (emissions.Factors, error) {
+	if path == "" {
+		return emissions.Default(), nil
+	}
+
+	return emissions.Load(path)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/mambo-dev/adventrak-backend/internal/geocode"
+	"github.com/mambo-dev/adventrak-backend/internal/utils"
+)
+
+var errLocationNameRequired = errors.New("location name is required when it cannot be resolved from coordinates")
+
+type ResolvedLocation struct {
+	utils.Location
+	CountryCode sql.NullString
+	Region      sql.NullString
+	Timezone    string
+}
+
+type PlaceResponse struct {
+	Name        string  `json:"name"`
+	Region      string  `json:"region"`
+	CountryCode string  `json:"countryCode"`
+	Lat         float64 `json:"lat"`
+	Lng         float64 `json:"lng"`
+}
+
+// resolveLocation fills in a missing location name and attaches the country
+// and region of the nearest gazetteer place along with the local timezone.
+// Names sent by the client are kept.
+func (cfg apiConfig) resolveLocation(loc utils.Location) (ResolvedLocation, error) {
+	resolved := ResolvedLocation{
+		Location: loc,
+		Timezone: cfg.timezones.Lookup(loc.Lat, loc.Lng),
+	}
+
+	place, err := cfg.geocoder.Reverse(loc.Lat, loc.Lng)
+
+	if err == nil {
+		if resolved.Name == "" {
+			resolved.Name = place.Name
+		}
+
+		resolved.CountryCode = sql.NullString{String: place.CountryCode, Valid: place.CountryCode != ""}
+		resolved.Region = sql.NullString{String: place.Region, Valid: place.Region != ""}
+	}
+
+	if resolved.Name == "" {
+		return resolved, errLocationNameRequired
+	}
+
+	return resolved, nil
+}
+
+func (cfg apiConfig) handlerReverseGeocode(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	lat, err := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+
+	if err != nil || lat < -90 || lat > 90 {
+		respondWithError(w, http.StatusBadRequest, "Invalid lat sent through query params", err, false)
+		return
+	}
+
+	lng, err := strconv.ParseFloat(r.URL.Query().Get("lng"), 64)
+
+	if err != nil || lng < -180 || lng > 180 {
+		respondWithError(w, http.StatusBadRequest, "Invalid lng sent through query params", err, false)
+		return
+	}
+
+	place, err := cfg.geocoder.Reverse(lat, lng)
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "No place found near these coordinates", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: PlaceResponse{
+			Name:        place.Name,
+			Region:      place.Region,
+			CountryCode: place.CountryCode,
+			Lat:         place.Lat,
+			Lng:         place.Lng,
+		},
+	})
+}
+
+func loadGeocoder(path string, admin1Path string) (*geocode.Gazetteer, error) {
+	if path == "" {
+		return geocode.New(), nil
+	}
+
+	return geocode.Load(path, admin1Path)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/exif"
+	"github.com/mambo-dev/adventrak-backend/internal/utils"
+)
+
+// stopAttachRadius is how close, in metres, a geotagged photo has to be to
+// one of the trip's stops to be filed under it.
+const stopAttachRadius = 500
+
+type SuggestedStopResponse struct {
+	LocationName string     `json:"locationName,omitempty"`
+	Region       string     `json:"region,omitempty"`
+	CountryCode  string     `json:"countryCode,omitempty"`
+	Lat          float64    `json:"lat"`
+	Lng          float64    `json:"lng"`
+	ArrivedAt    *time.Time `json:"arrivedAt,omitempty"`
+}
+
+// readPhotoMetadata reads a photo's EXIF and rewinds it so the image can
+// be copied afterwards. Photos without readable EXIF get empty metadata.
+func readPhotoMetadata(photo io.ReadSeeker) (exif.Metadata, error) {
+	metadata, err := exif.Read(photo)
+
+	if errors.Is(err, exif.ErrMalformed) {
+		log.Printf("Ignoring malformed EXIF: %v", err)
+	}
+
+	if err != nil {
+		metadata = exif.Metadata{Orientation: 1}
+	}
+
+	if _, err := photo.Seek(0, io.SeekStart); err != nil {
+		return exif.Metadata{}, err
+	}
+
+	return metadata, nil
+}
+
+// geotagPhoto copies the capture time and, when the user keeps locations,
+// the GPS position from a photo's EXIF into the media params. A photo
+// uploaded to a trip is moved to the nearest stop within stopAttachRadius;
+// when there is none, a new stop at the photo's position is suggested
+// instead. The position is still used for both when it is not kept.
+func (cfg apiConfig) geotagPhoto(ctx context.Context, params *database.CreateTripMediaParams, metadata exif.Metadata, keepLocation bool) *SuggestedStopResponse {
+	takenIn := time.UTC
+
+	point := utils.FormatPoint(utils.Location{Lat: metadata.Lat, Lng: metadata.Lng})
+
+	if metadata.HasLocation {
+		if keepLocation {
+			params.Location = point
+		}
+
+		if location, err := cfg.timezones.Location(metadata.Lat, metadata.Lng); err == nil {
+			takenIn = location
+		}
+	}
+
+	takenAt := metadata.TakenIn(takenIn)
+	params.TakenAt = sql.NullTime{Time: takenAt, Valid: !takenAt.IsZero()}
+
+	if !metadata.HasLocation || !params.TripID.Valid {
+		return nil
+	}
+
+	stop, err := cfg.db.FindNearestStop(ctx, database.FindNearestStopParams{
+		Point:  point,
+		TripID: params.TripID.UUID,
+		Radius: stopAttachRadius,
+	})
+
+	if err == nil {
+		params.TripID = uuid.NullUUID{}
+		params.TripStopID = uuid.NullUUID{UUID: stop.ID, Valid: true}
+		return nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Failed to look up the nearest stop: %v", err)
+		return nil
+	}
+
+	suggestion := &SuggestedStopResponse{
+		Lat: metadata.Lat,
+		Lng: metadata.Lng,
+	}
+
+	if place, err := cfg.geocoder.Reverse(metadata.Lat, metadata.Lng); err == nil {
+		suggestion.LocationName = place.Name
+		suggestion.Region = place.Region
+		suggestion.CountryCode = place.CountryCode
+	}
+
+	if params.TakenAt.Valid {
+		arrivedAt := params.TakenAt.Time.UTC()
+		suggestion.ArrivedAt = &arrivedAt
+	}
+
+	return suggestion
+}
@@ -0,0 +1,117 @@
+package currency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+var ErrUnknownCurrency = errors.New("no exchange rate for currency")
+
+var codePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Rates converts amounts between currencies using a table of rates quoted
+// against a single base currency.
+type Rates struct {
+	base  string
+	rates map[string]float64
+}
+
+type rateTable struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// New returns an empty table that can only convert a currency to itself.
+func New() *Rates {
+	return &Rates{rates: make(map[string]float64)}
+}
+
+func Load(path string) (*Rates, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// Parse reads a JSON rate table such as
+//
+//	{"base": "USD", "rates": {"EUR": 0.92, "KES": 129.5}}
+//
+// where each rate is the amount of that currency one unit of base buys.
+func Parse(r io.Reader) (*Rates, error) {
+	table := rateTable{}
+
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return nil, err
+	}
+
+	if !ValidCode(table.Base) {
+		return nil, fmt.Errorf("invalid base currency %q", table.Base)
+	}
+
+	rates := &Rates{
+		base:  table.Base,
+		rates: map[string]float64{table.Base: 1},
+	}
+
+	for code, rate := range table.Rates {
+		if !ValidCode(code) {
+			return nil, fmt.Errorf("invalid currency code %q", code)
+		}
+
+		if rate <= 0 {
+			return nil, fmt.Errorf("exchange rate for %v must be positive", code)
+		}
+
+		if code == table.Base && rate != 1 {
+			return nil, fmt.Errorf("base currency %v must have a rate of 1", code)
+		}
+
+		rates.rates[code] = rate
+	}
+
+	return rates, nil
+}
+
+// ValidCode reports whether code looks like an ISO 4217 alphabetic code.
+func ValidCode(code string) bool {
+	return codePattern.MatchString(code)
+}
+
+// Has reports whether the table can convert the currency.
+func (r *Rates) Has(code string) bool {
+	_, ok := r.rates[code]
+
+	return ok
+}
+
+// Convert converts amount from one currency to another through the base
+// currency. Converting a currency to itself never needs a rate.
+func (r *Rates) Convert(amount float64, from string, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+
+	fromRate, ok := r.rates[from]
+
+	if !ok {
+		return 0, fmt.Errorf("%w: %v", ErrUnknownCurrency, from)
+	}
+
+	toRate, ok := r.rates[to]
+
+	if !ok {
+		return 0, fmt.Errorf("%w: %v", ErrUnknownCurrency, to)
+	}
+
+	return amount / fromRate * toRate, nil
+}
@@ -0,0 +1,110 @@
+package currency
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+const ratesFixture = `{"base": "USD", "rates": {"EUR": 0.8, "KES": 130}}`
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		wantErr bool
+	}{
+		"Valid table": {
+			input: ratesFixture,
+		},
+		"Base listed with a rate of one": {
+			input: `{"base": "USD", "rates": {"USD": 1}}`,
+		},
+		"Invalid base": {
+			input:   `{"base": "usd", "rates": {}}`,
+			wantErr: true,
+		},
+		"Invalid code": {
+			input:   `{"base": "USD", "rates": {"EURO": 0.9}}`,
+			wantErr: true,
+		},
+		"Non positive rate": {
+			input:   `{"base": "USD", "rates": {"EUR": 0}}`,
+			wantErr: true,
+		},
+		"Base with another rate": {
+			input:   `{"base": "USD", "rates": {"USD": 2}}`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.input))
+
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	rates, err := Parse(strings.NewReader(ratesFixture))
+	if err != nil {
+		t.Fatalf("Error parsing rates: %v", err)
+	}
+
+	tests := map[string]struct {
+		amount  float64
+		from    string
+		to      string
+		want    float64
+		wantErr error
+	}{
+		"Same currency": {
+			amount: 12.5,
+			from:   "JPY",
+			to:     "JPY",
+			want:   12.5,
+		},
+		"From base": {
+			amount: 10,
+			from:   "USD",
+			to:     "EUR",
+			want:   8,
+		},
+		"To base": {
+			amount: 260,
+			from:   "KES",
+			to:     "USD",
+			want:   2,
+		},
+		"Cross rate": {
+			amount: 8,
+			from:   "EUR",
+			to:     "KES",
+			want:   1300,
+		},
+		"Unknown currency": {
+			amount:  1,
+			from:    "GBP",
+			to:      "USD",
+			wantErr: ErrUnknownCurrency,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := rates.Convert(tc.amount, tc.from, tc.to)
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Convert() error = %v, want %v", err, tc.wantErr)
+			}
+
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("Convert() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
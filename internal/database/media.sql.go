@@ -8,28 +8,54 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const clearMediaLocations = `-- name: ClearMediaLocations :execrows
+UPDATE trip_media
+SET location = NULL, updated_at = NOW()
+WHERE user_id = $1 AND location IS NOT NULL
+`
+
+func (q *Queries) ClearMediaLocations(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, clearMediaLocations, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createTripMedia = `-- name: CreateTripMedia :one
-INSERT INTO trip_media(trip_id, trip_stop_id, photo_url, video_url, user_id)
+INSERT INTO trip_media(trip_id, trip_stop_id, photo_url, video_url, user_id, duration_seconds, width, height, taken_at, location)
 VALUES(
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
 )
-RETURNING id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id
+RETURNING id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id, deleted_at, duration_seconds, width, height, taken_at, location, latitude, longitude, caption, alt_text, sort_order, favourite
 `
 
 type CreateTripMediaParams struct {
-	TripID     uuid.NullUUID
-	TripStopID uuid.NullUUID
-	PhotoUrl   sql.NullString
-	VideoUrl   sql.NullString
-	UserID     uuid.UUID
+	TripID          uuid.NullUUID
+	TripStopID      uuid.NullUUID
+	PhotoUrl        sql.NullString
+	VideoUrl        sql.NullString
+	UserID          uuid.UUID
+	DurationSeconds sql.NullFloat64
+	Width           sql.NullInt32
+	Height          sql.NullInt32
+	TakenAt         sql.NullTime
+	Location        interface{}
 }
 
 func (q *Queries) CreateTripMedia(ctx context.Context, arg CreateTripMediaParams) (TripMedium, error) {
@@ -39,6 +65,11 @@ func (q *Queries) CreateTripMedia(ctx context.Context, arg CreateTripMediaParams
 		arg.PhotoUrl,
 		arg.VideoUrl,
 		arg.UserID,
+		arg.DurationSeconds,
+		arg.Width,
+		arg.Height,
+		arg.TakenAt,
+		arg.Location,
 	)
 	var i TripMedium
 	err := row.Scan(
@@ -50,28 +81,111 @@ func (q *Queries) CreateTripMedia(ctx context.Context, arg CreateTripMediaParams
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
+		&i.DeletedAt,
+		&i.DurationSeconds,
+		&i.Width,
+		&i.Height,
+		&i.TakenAt,
+		&i.Location,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Caption,
+		&i.AltText,
+		&i.SortOrder,
+		&i.Favourite,
 	)
 	return i, err
 }
 
-const deleteTripMedia = `-- name: DeleteTripMedia :exec
-DELETE FROM trip_media
-WHERE id = $1 AND user_id = $2
+const findNearestStop = `-- name: FindNearestStop :one
+SELECT s.id, s.location_name, ST_Distance(s.location_tag, $1::geography)::FLOAT8 AS distance
+FROM trip_stop s
+WHERE s.trip_id = $2 AND s.deleted_at IS NULL
+    AND ST_DWithin(s.location_tag, $1::geography, $3::FLOAT8)
+ORDER BY distance, s.sequence
+LIMIT 1
 `
 
-type DeleteTripMediaParams struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
+type FindNearestStopParams struct {
+	Point  interface{}
+	TripID uuid.UUID
+	Radius float64
+}
+
+type FindNearestStopRow struct {
+	ID           uuid.UUID
+	LocationName string
+	Distance     float64
+}
+
+func (q *Queries) FindNearestStop(ctx context.Context, arg FindNearestStopParams) (FindNearestStopRow, error) {
+	row := q.db.QueryRowContext(ctx, findNearestStop, arg.Point, arg.TripID, arg.Radius)
+	var i FindNearestStopRow
+	err := row.Scan(
+		&i.ID,
+		&i.LocationName,
+		&i.Distance,
+	)
+	return i, err
+}
+
+const getTrashedMedia = `-- name: GetTrashedMedia :many
+SELECT id, trip_id, trip_stop_id, photo_url, video_url, deleted_at::TIMESTAMPTZ AS deleted_at
+FROM trip_media
+WHERE deleted_at IS NOT NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $1 AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
+ORDER BY deleted_at DESC
+`
+
+type GetTrashedMediaRow struct {
+	ID         uuid.UUID
+	TripID     uuid.NullUUID
+	TripStopID uuid.NullUUID
+	PhotoUrl   sql.NullString
+	VideoUrl   sql.NullString
+	DeletedAt  time.Time
 }
 
-func (q *Queries) DeleteTripMedia(ctx context.Context, arg DeleteTripMediaParams) error {
-	_, err := q.db.ExecContext(ctx, deleteTripMedia, arg.ID, arg.UserID)
-	return err
+func (q *Queries) GetTrashedMedia(ctx context.Context, userID uuid.UUID) ([]GetTrashedMediaRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrashedMedia, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrashedMediaRow
+	for rows.Next() {
+		var i GetTrashedMediaRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TripID,
+			&i.TripStopID,
+			&i.PhotoUrl,
+			&i.VideoUrl,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getTripMediaById = `-- name: GetTripMediaById :one
-SELECT id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id FROM trip_media
-WHERE id = $1 AND user_id = $2
+SELECT id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id, deleted_at, duration_seconds, width, height, taken_at, location, latitude, longitude, caption, alt_text, sort_order, favourite FROM trip_media
+WHERE id = $1 AND deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $2
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
 `
 
 type GetTripMediaByIdParams struct {
@@ -91,13 +205,30 @@ func (q *Queries) GetTripMediaById(ctx context.Context, arg GetTripMediaByIdPara
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
+		&i.DeletedAt,
+		&i.DurationSeconds,
+		&i.Width,
+		&i.Height,
+		&i.TakenAt,
+		&i.Location,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Caption,
+		&i.AltText,
+		&i.SortOrder,
+		&i.Favourite,
 	)
 	return i, err
 }
 
 const getTripMediaByTripOrStopID = `-- name: GetTripMediaByTripOrStopID :many
-SELECT id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id FROM trip_media
-WHERE trip_id = $1 OR trip_stop_id = $2 AND user_id = $3
+SELECT id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id, deleted_at, duration_seconds, width, height, taken_at, location, latitude, longitude, caption, alt_text, sort_order, favourite FROM trip_media
+WHERE (trip_id = $1 OR trip_stop_id = $2) AND deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $3
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
+ORDER BY sort_order NULLS LAST, created_at
 `
 
 type GetTripMediaByTripOrStopIDParams struct {
@@ -124,6 +255,18 @@ func (q *Queries) GetTripMediaByTripOrStopID(ctx context.Context, arg GetTripMed
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.UserID,
+			&i.DeletedAt,
+			&i.DurationSeconds,
+			&i.Width,
+			&i.Height,
+			&i.TakenAt,
+			&i.Location,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Caption,
+			&i.AltText,
+			&i.SortOrder,
+			&i.Favourite,
 		); err != nil {
 			return nil, err
 		}
@@ -138,28 +281,125 @@ func (q *Queries) GetTripMediaByTripOrStopID(ctx context.Context, arg GetTripMed
 	return items, nil
 }
 
-const updateTripMedia = `-- name: UpdateTripMedia :one
+const reorderMedia = `-- name: ReorderMedia :execrows
 UPDATE trip_media
-SET photo_url = $1, video_url = $2, updated_at = NOW()
-WHERE id = $3 AND user_id = $4
-RETURNING id
+SET sort_order = ordered.position, updated_at = NOW()
+FROM unnest($1::uuid[]) WITH ORDINALITY AS ordered(id, position)
+WHERE trip_media.id = ordered.id AND trip_media.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $2 AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
 `
 
-type UpdateTripMediaParams struct {
-	PhotoUrl sql.NullString
-	VideoUrl sql.NullString
-	ID       uuid.UUID
+type ReorderMediaParams struct {
+	MediaIds []uuid.UUID
 	UserID   uuid.UUID
 }
 
-func (q *Queries) UpdateTripMedia(ctx context.Context, arg UpdateTripMediaParams) (uuid.UUID, error) {
-	row := q.db.QueryRowContext(ctx, updateTripMedia,
-		arg.PhotoUrl,
-		arg.VideoUrl,
-		arg.ID,
-		arg.UserID,
-	)
+func (q *Queries) ReorderMedia(ctx context.Context, arg ReorderMediaParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reorderMedia, pq.Array(arg.MediaIds), arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreTripMedia = `-- name: RestoreTripMedia :one
+UPDATE trip_media
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $2 AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
+RETURNING id
+`
+
+type RestoreTripMediaParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RestoreTripMedia(ctx context.Context, arg RestoreTripMediaParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, restoreTripMedia, arg.ID, arg.UserID)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
 }
+
+const trashTripMedia = `-- name: TrashTripMedia :execrows
+UPDATE trip_media
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $2 AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
+`
+
+type TrashTripMediaParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) TrashTripMedia(ctx context.Context, arg TrashTripMediaParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, trashTripMedia, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateMediaDetails = `-- name: UpdateMediaDetails :one
+UPDATE trip_media
+SET caption = $1, alt_text = $2, favourite = $3, updated_at = NOW()
+WHERE id = $4 AND deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $5 AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
+RETURNING id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id, deleted_at, duration_seconds, width, height, taken_at, location, latitude, longitude, caption, alt_text, sort_order, favourite
+`
+
+type UpdateMediaDetailsParams struct {
+	Caption   sql.NullString
+	AltText   sql.NullString
+	Favourite bool
+	ID        uuid.UUID
+	UserID    uuid.UUID
+}
+
+func (q *Queries) UpdateMediaDetails(ctx context.Context, arg UpdateMediaDetailsParams) (TripMedium, error) {
+	row := q.db.QueryRowContext(ctx, updateMediaDetails,
+		arg.Caption,
+		arg.AltText,
+		arg.Favourite,
+		arg.ID,
+		arg.UserID,
+	)
+	var i TripMedium
+	err := row.Scan(
+		&i.ID,
+		&i.TripID,
+		&i.TripStopID,
+		&i.PhotoUrl,
+		&i.VideoUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.DeletedAt,
+		&i.DurationSeconds,
+		&i.Width,
+		&i.Height,
+		&i.TakenAt,
+		&i.Location,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Caption,
+		&i.AltText,
+		&i.SortOrder,
+		&i.Favourite,
+	)
+	return i, err
+}
@@ -6,11 +6,208 @@ package database
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ExpenseCategory string
+
+const (
+	ExpenseCategoryAccommodation ExpenseCategory = "accommodation"
+	ExpenseCategoryTransport     ExpenseCategory = "transport"
+	ExpenseCategoryFood          ExpenseCategory = "food"
+	ExpenseCategoryActivities    ExpenseCategory = "activities"
+	ExpenseCategoryShopping      ExpenseCategory = "shopping"
+	ExpenseCategoryFees          ExpenseCategory = "fees"
+	ExpenseCategoryOther         ExpenseCategory = "other"
+)
+
+func (e *ExpenseCategory) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ExpenseCategory(s)
+	case string:
+		*e = ExpenseCategory(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ExpenseCategory: %T", src)
+	}
+	return nil
+}
+
+type NullExpenseCategory struct {
+	ExpenseCategory ExpenseCategory
+	Valid           bool // Valid is true if ExpenseCategory is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullExpenseCategory) Scan(value interface{}) error {
+	if value == nil {
+		ns.ExpenseCategory, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ExpenseCategory.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullExpenseCategory) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ExpenseCategory), nil
+}
+
+type TransportMode string
+
+const (
+	TransportModeWalk   TransportMode = "walk"
+	TransportModeBike   TransportMode = "bike"
+	TransportModeCar    TransportMode = "car"
+	TransportModeBus    TransportMode = "bus"
+	TransportModeTrain  TransportMode = "train"
+	TransportModeFerry  TransportMode = "ferry"
+	TransportModeFlight TransportMode = "flight"
+)
+
+func (e *TransportMode) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TransportMode(s)
+	case string:
+		*e = TransportMode(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TransportMode: %T", src)
+	}
+	return nil
+}
+
+type NullTransportMode struct {
+	TransportMode TransportMode
+	Valid         bool // Valid is true if TransportMode is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTransportMode) Scan(value interface{}) error {
+	if value == nil {
+		ns.TransportMode, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TransportMode.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTransportMode) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TransportMode), nil
+}
+
+type TripRole string
+
+const (
+	TripRoleViewer TripRole = "viewer"
+	TripRoleEditor TripRole = "editor"
+)
+
+func (e *TripRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TripRole(s)
+	case string:
+		*e = TripRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TripRole: %T", src)
+	}
+	return nil
+}
+
+type NullTripRole struct {
+	TripRole TripRole
+	Valid    bool // Valid is true if TripRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTripRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.TripRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TripRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTripRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TripRole), nil
+}
+
+type TripStatus string
+
+const (
+	TripStatusPlanned   TripStatus = "planned"
+	TripStatusActive    TripStatus = "active"
+	TripStatusCompleted TripStatus = "completed"
+	TripStatusArchived  TripStatus = "archived"
+)
+
+func (e *TripStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TripStatus(s)
+	case string:
+		*e = TripStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TripStatus: %T", src)
+	}
+	return nil
+}
+
+type NullTripStatus struct {
+	TripStatus TripStatus
+	Valid      bool // Valid is true if TripStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTripStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.TripStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TripStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTripStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TripStatus), nil
+}
+
+type Album struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Title       string
+	Description sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type AlbumMedium struct {
+	AlbumID     uuid.UUID
+	TripMediaID uuid.UUID
+	Position    int32
+}
+
 type Account struct {
 	ID                    uuid.UUID
 	CreatedAt             time.Time
@@ -24,6 +221,62 @@ type Account struct {
 	ResetCodeExpiresAt    sql.NullTime
 }
 
+type JournalEntry struct {
+	ID           uuid.UUID
+	TripID       uuid.UUID
+	TripStopID   uuid.NullUUID
+	UserID       uuid.UUID
+	Title        sql.NullString
+	Body         string
+	BodyHtml     string
+	EntryDate    time.Time
+	LocationName sql.NullString
+	LocationTag  interface{}
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type JournalEntryMedium struct {
+	JournalEntryID uuid.UUID
+	TripMediaID    uuid.UUID
+	Position       int32
+}
+
+type MediaBlob struct {
+	StorageKey string
+	UserID     uuid.UUID
+	Sha256     sql.NullString
+	Size       sql.NullInt64
+	RefCount   int32
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	StrippedAt sql.NullTime
+}
+
+type MediaVariant struct {
+	ID          uuid.UUID
+	TripMediaID uuid.UUID
+	Name        string
+	Format      string
+	Url         string
+	Width       int32
+	Height      int32
+	CreatedAt   time.Time
+}
+
+type PlannedStop struct {
+	ID            uuid.UUID
+	TripID        uuid.UUID
+	UserID        uuid.UUID
+	LocationName  string
+	LocationTag   interface{}
+	TargetDate    sql.NullTime
+	Position      int32
+	MatchedStopID uuid.NullUUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
 type RefreshToken struct {
 	ID        uuid.UUID
 	Token     string
@@ -34,6 +287,20 @@ type RefreshToken struct {
 	UserID    uuid.NullUUID
 }
 
+type StopTag struct {
+	TripStopID uuid.UUID
+	TagID      uuid.UUID
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	Color     sql.NullString
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type Trip struct {
 	ID                uuid.UUID
 	TripTitle         string
@@ -47,34 +314,146 @@ type Trip struct {
 	UserID            uuid.UUID
 	StartLocationName string
 	EndLocationName   sql.NullString
+	StartCountryCode  sql.NullString
+	EndCountryCode    sql.NullString
+	StartTimezone     string
+	EndTimezone       sql.NullString
+	TimezonesResolved bool
+	Status            TripStatus
+	TransportMode     NullTransportMode
+	BudgetAmount      sql.NullString
+	BudgetCurrency    sql.NullString
+	DeletedAt         sql.NullTime
+	CoverMediaID      uuid.NullUUID
+}
+
+type TripAccess struct {
+	TripID uuid.UUID
+	UserID uuid.UUID
+	Role   string
+}
+
+type TripExpense struct {
+	ID          uuid.UUID
+	TripID      uuid.UUID
+	TripStopID  uuid.NullUUID
+	UserID      uuid.UUID
+	Amount      string
+	Currency    string
+	Category    ExpenseCategory
+	SpentOn     time.Time
+	Payer       sql.NullString
+	Description sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
 type TripMedium struct {
+	ID              uuid.UUID
+	TripID          uuid.NullUUID
+	TripStopID      uuid.NullUUID
+	PhotoUrl        sql.NullString
+	VideoUrl        sql.NullString
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	UserID          uuid.UUID
+	DeletedAt       sql.NullTime
+	DurationSeconds sql.NullFloat64
+	Width           sql.NullInt32
+	Height          sql.NullInt32
+	TakenAt         sql.NullTime
+	Location        interface{}
+	Latitude        sql.NullFloat64
+	Longitude       sql.NullFloat64
+	Caption         sql.NullString
+	AltText         sql.NullString
+	SortOrder       sql.NullInt32
+	Favourite       bool
+}
+
+type TripMember struct {
 	ID         uuid.UUID
-	TripID     uuid.NullUUID
-	TripStopID uuid.NullUUID
-	PhotoUrl   sql.NullString
-	VideoUrl   sql.NullString
+	TripID     uuid.UUID
+	UserID     uuid.UUID
+	InvitedBy  uuid.UUID
+	Role       TripRole
+	AcceptedAt sql.NullTime
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
-	UserID     uuid.UUID
+}
+
+type TripShare struct {
+	ID              uuid.UUID
+	TripID          uuid.UUID
+	UserID          uuid.UUID
+	Token           string
+	HideCoordinates bool
+	ExpiresAt       sql.NullTime
+	RevokedAt       sql.NullTime
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 type TripStop struct {
+	ID               uuid.UUID
+	TripID           uuid.UUID
+	LocationName     string
+	LocationTag      interface{}
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	UserID           uuid.UUID
+	CountryCode      sql.NullString
+	Region           sql.NullString
+	Timezone         string
+	TimezoneResolved bool
+	Sequence         int32
+	ArrivedAt        sql.NullTime
+	DepartedAt       sql.NullTime
+	TransportMode    NullTransportMode
+	DeletedAt        sql.NullTime
+	CoverMediaID     uuid.NullUUID
+}
+
+type TripSummary struct {
+	TripID     uuid.UUID
+	UserID     uuid.UUID
+	TripTitle  string
+	StartDate  time.Time
+	EndDate    sql.NullTime
+	Distance   float64
+	Days       int64
+	StopCount  int64
+	PhotoCount int64
+}
+
+type TripTag struct {
+	TripID uuid.UUID
+	TagID  uuid.UUID
+}
+
+type Upload struct {
 	ID           uuid.UUID
-	TripID       uuid.UUID
-	LocationName string
-	LocationTag  interface{}
+	UserID       uuid.UUID
+	TripID       uuid.NullUUID
+	TripStopID   uuid.NullUUID
+	MediaType    string
+	FileName     sql.NullString
+	UploadLength int64
+	UploadOffset int64
+	TripMediaID  uuid.NullUUID
+	ExpiresAt    time.Time
+	CompletedAt  sql.NullTime
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
-	UserID       uuid.UUID
 }
 
 type User struct {
-	ID           uuid.UUID
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	Username     string
-	PasswordHash string
-	Email        string
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Username          string
+	PasswordHash      string
+	Email             string
+	HomeCurrency      string
+	KeepPhotoLocation bool
 }
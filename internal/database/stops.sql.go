@@ -7,9 +7,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createStop = `-- name: CreateStop :one
@@ -17,22 +19,42 @@ INSERT INTO trip_stop (
     location_name,
     location_tag,
     trip_id,
-    user_id
+    user_id,
+    country_code,
+    region,
+    timezone,
+    arrived_at,
+    departed_at,
+    transport_mode,
+    sequence
 )
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    COALESCE((SELECT MAX(sequence) + 1 FROM trip_stop WHERE trip_id = $3), 0)
 )
 RETURNING id
 `
 
 type CreateStopParams struct {
-	LocationName string
-	LocationTag  interface{}
-	TripID       uuid.UUID
-	UserID       uuid.UUID
+	LocationName  string
+	LocationTag   interface{}
+	TripID        uuid.UUID
+	UserID        uuid.UUID
+	CountryCode   sql.NullString
+	Region        sql.NullString
+	Timezone      string
+	ArrivedAt     sql.NullTime
+	DepartedAt    sql.NullTime
+	TransportMode NullTransportMode
 }
 
 func (q *Queries) CreateStop(ctx context.Context, arg CreateStopParams) (uuid.UUID, error) {
@@ -41,36 +63,40 @@ func (q *Queries) CreateStop(ctx context.Context, arg CreateStopParams) (uuid.UU
 		arg.LocationTag,
 		arg.TripID,
 		arg.UserID,
+		arg.CountryCode,
+		arg.Region,
+		arg.Timezone,
+		arg.ArrivedAt,
+		arg.DepartedAt,
+		arg.TransportMode,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
 }
 
-const deleteStop = `-- name: DeleteStop :exec
-DELETE FROM trip_stop 
-WHERE id = $1 AND user_id = $2
-`
-
-type DeleteStopParams struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
-}
-
-func (q *Queries) DeleteStop(ctx context.Context, arg DeleteStopParams) error {
-	_, err := q.db.ExecContext(ctx, deleteStop, arg.ID, arg.UserID)
-	return err
-}
-
 const getStop = `-- name: GetStop :one
 SELECT
     id,
     location_name,
     created_at,
+    country_code,
+    region,
+    timezone,
+    sequence,
+    arrived_at,
+    departed_at,
+    transport_mode,
+    EXTRACT(EPOCH FROM (departed_at - arrived_at))::FLOAT8 AS stop_duration,
     ST_Y(location_tag::geometry) AS end_lat,
-    ST_X(location_tag::geometry) AS end_lng
+    ST_X(location_tag::geometry) AS end_lng,
+    cover_media_id,
+    (SELECT c.photo_url FROM trip_media c WHERE c.id = trip_stop.cover_media_id AND c.deleted_at IS NULL) AS cover_photo_url,
+    trip_stop.trip_id,
+    a.role
 FROM trip_stop
-WHERE user_id = $1 AND id = $2
+JOIN trip_access a ON a.trip_id = trip_stop.trip_id AND a.user_id = $1
+WHERE id = $2 AND deleted_at IS NULL
 `
 
 type GetStopParams struct {
@@ -79,11 +105,23 @@ type GetStopParams struct {
 }
 
 type GetStopRow struct {
-	ID           uuid.UUID
-	LocationName string
-	CreatedAt    time.Time
-	EndLat       interface{}
-	EndLng       interface{}
+	ID            uuid.UUID
+	LocationName  string
+	CreatedAt     time.Time
+	CountryCode   sql.NullString
+	Region        sql.NullString
+	Timezone      string
+	Sequence      int32
+	ArrivedAt     sql.NullTime
+	DepartedAt    sql.NullTime
+	TransportMode NullTransportMode
+	StopDuration  sql.NullFloat64
+	EndLat        interface{}
+	EndLng        interface{}
+	CoverMediaID  uuid.NullUUID
+	CoverPhotoUrl sql.NullString
+	TripID        uuid.UUID
+	Role          string
 }
 
 func (q *Queries) GetStop(ctx context.Context, arg GetStopParams) (GetStopRow, error) {
@@ -93,38 +131,104 @@ func (q *Queries) GetStop(ctx context.Context, arg GetStopParams) (GetStopRow, e
 		&i.ID,
 		&i.LocationName,
 		&i.CreatedAt,
+		&i.CountryCode,
+		&i.Region,
+		&i.Timezone,
+		&i.Sequence,
+		&i.ArrivedAt,
+		&i.DepartedAt,
+		&i.TransportMode,
+		&i.StopDuration,
 		&i.EndLat,
 		&i.EndLng,
+		&i.CoverMediaID,
+		&i.CoverPhotoUrl,
+		&i.TripID,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getStops = `-- name: GetStops :many
 SELECT
-    id,
-    location_name,
-    created_at,
-    ST_Y(location_tag::geometry) AS end_lat,
-    ST_X(location_tag::geometry) AS end_lng
-FROM trip_stop
-WHERE trip_id = $1 AND user_id = $2
+    id, location_name, created_at, country_code, region, timezone, sequence, arrived_at, departed_at,
+    transport_mode, stop_duration, leg_distance, leg_travel_time, end_lat, end_lng, cover_media_id, cover_photo_url
+FROM (
+SELECT
+    s.id,
+    s.location_name,
+    s.created_at,
+    s.country_code,
+    s.region,
+    s.timezone,
+    s.sequence,
+    s.arrived_at,
+    s.departed_at,
+    s.transport_mode,
+    EXTRACT(EPOCH FROM (s.departed_at - s.arrived_at))::FLOAT8 AS stop_duration,
+    ST_Distance(
+        COALESCE(LAG(s.location_tag) OVER legs, t.start_location),
+        s.location_tag
+    )::FLOAT8 AS leg_distance,
+    EXTRACT(EPOCH FROM (
+        s.arrived_at - CASE
+            WHEN LAG(s.id) OVER legs IS NULL THEN t.start_date
+            ELSE LAG(s.departed_at) OVER legs
+        END
+    ))::FLOAT8 AS leg_travel_time,
+    ST_Y(s.location_tag::geometry) AS end_lat,
+    ST_X(s.location_tag::geometry) AS end_lng,
+    s.cover_media_id,
+    (SELECT c.photo_url FROM trip_media c WHERE c.id = s.cover_media_id AND c.deleted_at IS NULL) AS cover_photo_url
+FROM trip_stop s
+JOIN trips t ON t.id = s.trip_id
+JOIN trip_access a ON a.trip_id = t.id AND a.user_id = $1
+WHERE s.trip_id = $2 AND s.deleted_at IS NULL
+WINDOW legs AS (ORDER BY s.sequence, s.created_at)
+) trip_stops
+WHERE $3::text[] IS NULL OR (
+    SELECT COUNT(*)
+    FROM stop_tags st
+    JOIN tags g ON g.id = st.tag_id
+    WHERE st.trip_stop_id = trip_stops.id AND LOWER(g.name) = ANY($3::text[])
+) >= CASE WHEN $4::bool THEN cardinality($3::text[]) ELSE 1 END
+ORDER BY trip_stops.sequence, trip_stops.created_at
 `
 
 type GetStopsParams struct {
-	TripID uuid.UUID
-	UserID uuid.UUID
+	TripID       uuid.UUID
+	UserID       uuid.UUID
+	Tags         []string
+	MatchAllTags bool
 }
 
 type GetStopsRow struct {
-	ID           uuid.UUID
-	LocationName string
-	CreatedAt    time.Time
-	EndLat       interface{}
-	EndLng       interface{}
+	ID            uuid.UUID
+	LocationName  string
+	CreatedAt     time.Time
+	CountryCode   sql.NullString
+	Region        sql.NullString
+	Timezone      string
+	Sequence      int32
+	ArrivedAt     sql.NullTime
+	DepartedAt    sql.NullTime
+	TransportMode NullTransportMode
+	StopDuration  sql.NullFloat64
+	LegDistance   sql.NullFloat64
+	LegTravelTime sql.NullFloat64
+	EndLat        interface{}
+	EndLng        interface{}
+	CoverMediaID  uuid.NullUUID
+	CoverPhotoUrl sql.NullString
 }
 
 func (q *Queries) GetStops(ctx context.Context, arg GetStopsParams) ([]GetStopsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getStops, arg.TripID, arg.UserID)
+	rows, err := q.db.QueryContext(ctx, getStops,
+		arg.TripID,
+		arg.UserID,
+		pq.Array(arg.Tags),
+		arg.MatchAllTags,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -136,8 +240,71 @@ func (q *Queries) GetStops(ctx context.Context, arg GetStopsParams) ([]GetStopsR
 			&i.ID,
 			&i.LocationName,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.Region,
+			&i.Timezone,
+			&i.Sequence,
+			&i.ArrivedAt,
+			&i.DepartedAt,
+			&i.TransportMode,
+			&i.StopDuration,
+			&i.LegDistance,
+			&i.LegTravelTime,
 			&i.EndLat,
 			&i.EndLng,
+			&i.CoverMediaID,
+			&i.CoverPhotoUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrashedStops = `-- name: GetTrashedStops :many
+SELECT
+    s.id,
+    s.trip_id,
+    t.trip_title,
+    s.location_name,
+    s.deleted_at::TIMESTAMPTZ AS deleted_at
+FROM trip_stop s
+JOIN trips t ON t.id = s.trip_id
+JOIN trip_access a ON a.trip_id = s.trip_id AND a.user_id = $1 AND a.role IN ('owner', 'editor')
+WHERE s.deleted_at IS NOT NULL
+ORDER BY s.deleted_at DESC
+`
+
+type GetTrashedStopsRow struct {
+	ID           uuid.UUID
+	TripID       uuid.UUID
+	TripTitle    string
+	LocationName string
+	DeletedAt    time.Time
+}
+
+func (q *Queries) GetTrashedStops(ctx context.Context, userID uuid.UUID) ([]GetTrashedStopsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrashedStops, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrashedStopsRow
+	for rows.Next() {
+		var i GetTrashedStopsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TripID,
+			&i.TripTitle,
+			&i.LocationName,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -152,18 +319,187 @@ func (q *Queries) GetStops(ctx context.Context, arg GetStopsParams) ([]GetStopsR
 	return items, nil
 }
 
+const getUnresolvedStopTimezones = `-- name: GetUnresolvedStopTimezones :many
+SELECT
+    id,
+    ST_Y(location_tag::geometry)::FLOAT8 AS lat,
+    ST_X(location_tag::geometry)::FLOAT8 AS lng
+FROM trip_stop
+WHERE NOT timezone_resolved
+`
+
+type GetUnresolvedStopTimezonesRow struct {
+	ID  uuid.UUID
+	Lat float64
+	Lng float64
+}
+
+func (q *Queries) GetUnresolvedStopTimezones(ctx context.Context) ([]GetUnresolvedStopTimezonesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnresolvedStopTimezones)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnresolvedStopTimezonesRow
+	for rows.Next() {
+		var i GetUnresolvedStopTimezonesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Lat,
+			&i.Lng,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reorderStops = `-- name: ReorderStops :execrows
+UPDATE trip_stop
+SET sequence = ordered.position, updated_at = NOW()
+FROM unnest($1::uuid[]) WITH ORDINALITY AS ordered(id, position)
+WHERE trip_stop.id = ordered.id AND trip_stop.trip_id = $2 AND trip_stop.deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = $3 AND a.role IN ('owner', 'editor')
+    )
+`
+
+type ReorderStopsParams struct {
+	StopIds []uuid.UUID
+	TripID  uuid.UUID
+	UserID  uuid.UUID
+}
+
+func (q *Queries) ReorderStops(ctx context.Context, arg ReorderStopsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reorderStops, pq.Array(arg.StopIds), arg.TripID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreStop = `-- name: RestoreStop :one
+UPDATE trip_stop
+-- The stop goes back at the end of the itinerary, since the live stops may
+-- have been reordered while it was in the trash.
+SET deleted_at = NULL, updated_at = NOW(), sequence = COALESCE((
+        SELECT MAX(s.sequence) + 1 FROM trip_stop s
+        WHERE s.trip_id = trip_stop.trip_id AND s.deleted_at IS NULL
+    ), 0)
+WHERE id = $1 AND deleted_at IS NOT NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = $2 AND a.role IN ('owner', 'editor')
+    )
+RETURNING id
+`
+
+type RestoreStopParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RestoreStop(ctx context.Context, arg RestoreStopParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, restoreStop, arg.ID, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setStopCover = `-- name: SetStopCover :execrows
+UPDATE trip_stop
+SET cover_media_id = $1, updated_at = NOW()
+WHERE id = $2 AND deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = $3 AND a.role IN ('owner', 'editor')
+    )
+    AND ($1::uuid IS NULL OR EXISTS (
+        SELECT 1 FROM trip_media m
+        WHERE m.id = $1::uuid AND m.photo_url IS NOT NULL
+            AND m.trip_stop_id = trip_stop.id AND m.deleted_at IS NULL
+    ))
+`
+
+type SetStopCoverParams struct {
+	MediaID uuid.NullUUID
+	StopID  uuid.UUID
+	UserID  uuid.UUID
+}
+
+func (q *Queries) SetStopCover(ctx context.Context, arg SetStopCoverParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setStopCover, arg.MediaID, arg.StopID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setStopTimezone = `-- name: SetStopTimezone :exec
+UPDATE trip_stop
+SET timezone = $1, timezone_resolved = TRUE
+WHERE id = $2
+`
+
+type SetStopTimezoneParams struct {
+	Timezone string
+	ID       uuid.UUID
+}
+
+func (q *Queries) SetStopTimezone(ctx context.Context, arg SetStopTimezoneParams) error {
+	_, err := q.db.ExecContext(ctx, setStopTimezone, arg.Timezone, arg.ID)
+	return err
+}
+
+const trashStop = `-- name: TrashStop :execrows
+UPDATE trip_stop
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = $2 AND a.role IN ('owner', 'editor')
+    )
+`
+
+type TrashStopParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) TrashStop(ctx context.Context, arg TrashStopParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, trashStop, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateStop = `-- name: UpdateStop :one
 UPDATE trip_stop
-SET location_name = $1, location_tag= $2
-WHERE id = $3 AND user_id = $4
+SET location_name = $1, location_tag= $2, country_code = $5, region = $6, timezone = $7,
+    arrived_at = $8, departed_at = $9, transport_mode = $10, updated_at = NOW()
+WHERE id = $3 AND deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = $4 AND a.role IN ('owner', 'editor')
+    )
 RETURNING id
 `
 
 type UpdateStopParams struct {
-	LocationName string
-	LocationTag  interface{}
-	ID           uuid.UUID
-	UserID       uuid.UUID
+	LocationName  string
+	LocationTag   interface{}
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	CountryCode   sql.NullString
+	Region        sql.NullString
+	Timezone      string
+	ArrivedAt     sql.NullTime
+	DepartedAt    sql.NullTime
+	TransportMode NullTransportMode
 }
 
 func (q *Queries) UpdateStop(ctx context.Context, arg UpdateStopParams) (uuid.UUID, error) {
@@ -172,6 +508,12 @@ func (q *Queries) UpdateStop(ctx context.Context, arg UpdateStopParams) (uuid.UU
 		arg.LocationTag,
 		arg.ID,
 		arg.UserID,
+		arg.CountryCode,
+		arg.Region,
+		arg.Timezone,
+		arg.ArrivedAt,
+		arg.DepartedAt,
+		arg.TransportMode,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
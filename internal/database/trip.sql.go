@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createTrip = `-- name: CreateTrip :one
@@ -22,7 +23,11 @@ INSERT INTO trips (
     end_location,
     end_date,
     distance_travelled,
-    user_id
+    user_id,
+    start_country_code,
+    start_timezone,
+    status,
+    transport_mode
 ) VALUES (
 $1,
 $2,
@@ -31,7 +36,11 @@ $4,
 $5,
 $6,
 $7,
-$8
+$8,
+$9,
+$10,
+$11,
+$12
 )
 RETURNING  id
 `
@@ -45,6 +54,10 @@ type CreateTripParams struct {
 	EndDate           sql.NullTime
 	DistanceTravelled sql.NullFloat64
 	UserID            uuid.UUID
+	StartCountryCode  sql.NullString
+	StartTimezone     string
+	Status            TripStatus
+	TransportMode     NullTransportMode
 }
 
 func (q *Queries) CreateTrip(ctx context.Context, arg CreateTripParams) (uuid.UUID, error) {
@@ -57,30 +70,164 @@ func (q *Queries) CreateTrip(ctx context.Context, arg CreateTripParams) (uuid.UU
 		arg.EndDate,
 		arg.DistanceTravelled,
 		arg.UserID,
+		arg.StartCountryCode,
+		arg.StartTimezone,
+		arg.Status,
+		arg.TransportMode,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
 }
 
-const deleteTrip = `-- name: DeleteTrip :exec
-DELETE FROM trips
-WHERE   id = $1 AND user_id = $2
+const duplicateTrip = `-- name: DuplicateTrip :one
+WITH new_trip AS (
+    INSERT INTO trips (
+        trip_title,
+        start_location_name,
+        start_location,
+        start_date,
+        start_country_code,
+        start_timezone,
+        timezones_resolved,
+        transport_mode,
+        status,
+        user_id
+    )
+    SELECT
+        COALESCE($1::VARCHAR, t.trip_title),
+        t.start_location_name,
+        t.start_location,
+        COALESCE($2::TIMESTAMPTZ, t.start_date),
+        t.start_country_code,
+        t.start_timezone,
+        t.timezones_resolved,
+        t.transport_mode,
+        'planned',
+        $3
+    FROM trips t
+    WHERE t.id = $4 AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = t.id AND a.user_id = $3
+    )
+    RETURNING id
+),
+copied_stops AS (
+    -- Stop times only carry over when the copy is shifted to a new start
+    -- date; without one they would all sit in the past.
+    INSERT INTO trip_stop (
+        location_name,
+        location_tag,
+        trip_id,
+        user_id,
+        country_code,
+        region,
+        timezone,
+        timezone_resolved,
+        arrived_at,
+        departed_at,
+        transport_mode,
+        sequence
+    )
+    SELECT
+        s.location_name,
+        s.location_tag,
+        new_trip.id,
+        $3,
+        s.country_code,
+        s.region,
+        s.timezone,
+        s.timezone_resolved,
+        s.arrived_at + ($2::TIMESTAMPTZ - t.start_date),
+        s.departed_at + ($2::TIMESTAMPTZ - t.start_date),
+        s.transport_mode,
+        ROW_NUMBER() OVER (ORDER BY s.sequence, s.created_at) - 1
+    FROM trip_stop s
+    JOIN trips t ON t.id = s.trip_id, new_trip
+    WHERE s.trip_id = $4 AND s.deleted_at IS NULL
+    RETURNING id
+)
+SELECT new_trip.id, (SELECT COUNT(*) FROM copied_stops) AS stop_count
+FROM new_trip
 `
 
-type DeleteTripParams struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
+type DuplicateTripParams struct {
+	TripTitle sql.NullString
+	StartDate sql.NullTime
+	UserID    uuid.UUID
+	ID        uuid.UUID
 }
 
-func (q *Queries) DeleteTrip(ctx context.Context, arg DeleteTripParams) error {
-	_, err := q.db.ExecContext(ctx, deleteTrip, arg.ID, arg.UserID)
-	return err
+type DuplicateTripRow struct {
+	ID        uuid.UUID
+	StopCount int64
+}
+
+func (q *Queries) DuplicateTrip(ctx context.Context, arg DuplicateTripParams) (DuplicateTripRow, error) {
+	row := q.db.QueryRowContext(ctx, duplicateTrip,
+		arg.TripTitle,
+		arg.StartDate,
+		arg.UserID,
+		arg.ID,
+	)
+	var i DuplicateTripRow
+	err := row.Scan(
+		&i.ID,
+		&i.StopCount,
+	)
+	return i, err
+}
+
+const getTrashedTrips = `-- name: GetTrashedTrips :many
+SELECT id, trip_title, start_location_name, start_date, status, deleted_at::TIMESTAMPTZ AS deleted_at
+FROM trips
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+type GetTrashedTripsRow struct {
+	ID                uuid.UUID
+	TripTitle         string
+	StartLocationName string
+	StartDate         time.Time
+	Status            TripStatus
+	DeletedAt         time.Time
+}
+
+func (q *Queries) GetTrashedTrips(ctx context.Context, userID uuid.UUID) ([]GetTrashedTripsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrashedTrips, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrashedTripsRow
+	for rows.Next() {
+		var i GetTrashedTripsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TripTitle,
+			&i.StartLocationName,
+			&i.StartDate,
+			&i.Status,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getTrip = `-- name: GetTrip :one
 SELECT 
   id,
+  trip_title,
   start_location_name,
   end_location_name,
   start_date,
@@ -88,13 +235,23 @@ SELECT
   distance_travelled,
   created_at,
   updated_at,
-  user_id,
+  trips.user_id,
+  start_country_code,
+  end_country_code,
+  start_timezone,
+  end_timezone,
+  status,
+  transport_mode,
   ST_Y(start_location::geometry) AS start_lat,
   ST_X(start_location::geometry) AS start_lng,
   ST_Y(end_location::geometry) AS end_lat,
-  ST_X(end_location::geometry) AS end_lng
+  ST_X(end_location::geometry) AS end_lng,
+  cover_media_id,
+  (SELECT c.photo_url FROM trip_media c WHERE c.id = trips.cover_media_id AND c.deleted_at IS NULL) AS cover_photo_url,
+  a.role
 FROM trips
-WHERE user_id = $1 AND id = $2
+JOIN trip_access a ON a.trip_id = trips.id AND a.user_id = $1
+WHERE id = $2
 `
 
 type GetTripParams struct {
@@ -104,6 +261,7 @@ type GetTripParams struct {
 
 type GetTripRow struct {
 	ID                uuid.UUID
+	TripTitle         string
 	StartLocationName string
 	EndLocationName   sql.NullString
 	StartDate         time.Time
@@ -112,10 +270,19 @@ type GetTripRow struct {
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
 	UserID            uuid.UUID
+	StartCountryCode  sql.NullString
+	EndCountryCode    sql.NullString
+	StartTimezone     string
+	EndTimezone       sql.NullString
+	Status            TripStatus
+	TransportMode     NullTransportMode
 	StartLat          interface{}
 	StartLng          interface{}
 	EndLat            interface{}
 	EndLng            interface{}
+	CoverMediaID      uuid.NullUUID
+	CoverPhotoUrl     sql.NullString
+	Role              string
 }
 
 func (q *Queries) GetTrip(ctx context.Context, arg GetTripParams) (GetTripRow, error) {
@@ -123,6 +290,7 @@ func (q *Queries) GetTrip(ctx context.Context, arg GetTripParams) (GetTripRow, e
 	var i GetTripRow
 	err := row.Scan(
 		&i.ID,
+		&i.TripTitle,
 		&i.StartLocationName,
 		&i.EndLocationName,
 		&i.StartDate,
@@ -131,10 +299,19 @@ func (q *Queries) GetTrip(ctx context.Context, arg GetTripParams) (GetTripRow, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
+		&i.StartCountryCode,
+		&i.EndCountryCode,
+		&i.StartTimezone,
+		&i.EndTimezone,
+		&i.Status,
+		&i.TransportMode,
 		&i.StartLat,
 		&i.StartLng,
 		&i.EndLat,
 		&i.EndLng,
+		&i.CoverMediaID,
+		&i.CoverPhotoUrl,
+		&i.Role,
 	)
 	return i, err
 }
@@ -154,6 +331,7 @@ func (q *Queries) GetTripDistance(ctx context.Context, id uuid.UUID) (float64, e
 const getTrips = `-- name: GetTrips :many
 SELECT   
   id,
+  trip_title,
   start_location_name,
   end_location_name,
   start_date,
@@ -161,17 +339,42 @@ SELECT
   distance_travelled,
   created_at,
   updated_at,
-  user_id,
+  trips.user_id,
+  start_country_code,
+  end_country_code,
+  start_timezone,
+  end_timezone,
+  status,
+  transport_mode,
   ST_Y(start_location::geometry) AS start_lat,
   ST_X(start_location::geometry) AS start_lng,
   ST_Y(end_location::geometry) AS end_lat,
-  ST_X(end_location::geometry) AS end_lng
-FROM trips 
-WHERE user_id = $1
+  ST_X(end_location::geometry) AS end_lng,
+  cover_media_id,
+  (SELECT c.photo_url FROM trip_media c WHERE c.id = trips.cover_media_id AND c.deleted_at IS NULL) AS cover_photo_url,
+  a.role
+FROM trips
+JOIN trip_access a ON a.trip_id = trips.id AND a.user_id = $1
+WHERE ($2::trip_status IS NULL OR status = $2::trip_status)
+  AND ($3::text[] IS NULL OR (
+    SELECT COUNT(*)
+    FROM trip_tags tt
+    JOIN tags g ON g.id = tt.tag_id
+    WHERE tt.trip_id = trips.id AND LOWER(g.name) = ANY($3::text[])
+  ) >= CASE WHEN $4::bool THEN cardinality($3::text[]) ELSE 1 END)
+ORDER BY start_date DESC
 `
 
+type GetTripsParams struct {
+	UserID       uuid.UUID
+	Status       NullTripStatus
+	Tags         []string
+	MatchAllTags bool
+}
+
 type GetTripsRow struct {
 	ID                uuid.UUID
+	TripTitle         string
 	StartLocationName string
 	EndLocationName   sql.NullString
 	StartDate         time.Time
@@ -180,14 +383,28 @@ type GetTripsRow struct {
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
 	UserID            uuid.UUID
+	StartCountryCode  sql.NullString
+	EndCountryCode    sql.NullString
+	StartTimezone     string
+	EndTimezone       sql.NullString
+	Status            TripStatus
+	TransportMode     NullTransportMode
 	StartLat          interface{}
 	StartLng          interface{}
 	EndLat            interface{}
 	EndLng            interface{}
+	CoverMediaID      uuid.NullUUID
+	CoverPhotoUrl     sql.NullString
+	Role              string
 }
 
-func (q *Queries) GetTrips(ctx context.Context, userID uuid.UUID) ([]GetTripsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTrips, userID)
+func (q *Queries) GetTrips(ctx context.Context, arg GetTripsParams) ([]GetTripsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrips,
+		arg.UserID,
+		arg.Status,
+		pq.Array(arg.Tags),
+		arg.MatchAllTags,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -197,6 +414,7 @@ func (q *Queries) GetTrips(ctx context.Context, userID uuid.UUID) ([]GetTripsRow
 		var i GetTripsRow
 		if err := rows.Scan(
 			&i.ID,
+			&i.TripTitle,
 			&i.StartLocationName,
 			&i.EndLocationName,
 			&i.StartDate,
@@ -205,10 +423,19 @@ func (q *Queries) GetTrips(ctx context.Context, userID uuid.UUID) ([]GetTripsRow
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.UserID,
+			&i.StartCountryCode,
+			&i.EndCountryCode,
+			&i.StartTimezone,
+			&i.EndTimezone,
+			&i.Status,
+			&i.TransportMode,
 			&i.StartLat,
 			&i.StartLng,
 			&i.EndLat,
 			&i.EndLng,
+			&i.CoverMediaID,
+			&i.CoverPhotoUrl,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -223,15 +450,69 @@ func (q *Queries) GetTrips(ctx context.Context, userID uuid.UUID) ([]GetTripsRow
 	return items, nil
 }
 
-const markTripEnd = `-- name: MarkTripEnd :one
+const getUnresolvedTripTimezones = `-- name: GetUnresolvedTripTimezones :many
+SELECT
+    id,
+    ST_Y(start_location::geometry)::FLOAT8 AS start_lat,
+    ST_X(start_location::geometry)::FLOAT8 AS start_lng,
+    ST_Y(end_location::geometry)::FLOAT8 AS end_lat,
+    ST_X(end_location::geometry)::FLOAT8 AS end_lng
+FROM trips
+WHERE NOT timezones_resolved
+`
+
+type GetUnresolvedTripTimezonesRow struct {
+	ID       uuid.UUID
+	StartLat float64
+	StartLng float64
+	EndLat   sql.NullFloat64
+	EndLng   sql.NullFloat64
+}
+
+func (q *Queries) GetUnresolvedTripTimezones(ctx context.Context) ([]GetUnresolvedTripTimezonesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnresolvedTripTimezones)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnresolvedTripTimezonesRow
+	for rows.Next() {
+		var i GetUnresolvedTripTimezonesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartLat,
+			&i.StartLng,
+			&i.EndLat,
+			&i.EndLng,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markTripEnd = `-- name: MarkTripEnd :one 
 UPDATE trips
 SET
     end_location_name = $1,
     end_location =$2,
     end_date = $3,
-    updated_at = $4
+    updated_at = $4,
+    end_country_code = $7,
+    end_timezone = $8,
+    status = 'completed'
 WHERE
-    id = $5 AND user_id = $6
+    id = $5 AND status = 'active' AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trips.id AND a.user_id = $6 AND a.role IN ('owner', 'editor')
+    )
 RETURNING  id
 `
 
@@ -242,6 +523,8 @@ type MarkTripEndParams struct {
 	UpdatedAt       time.Time
 	ID              uuid.UUID
 	UserID          uuid.UUID
+	EndCountryCode  sql.NullString
+	EndTimezone     sql.NullString
 }
 
 func (q *Queries) MarkTripEnd(ctx context.Context, arg MarkTripEndParams) (uuid.UUID, error) {
@@ -252,12 +535,99 @@ func (q *Queries) MarkTripEnd(ctx context.Context, arg MarkTripEndParams) (uuid.
 		arg.UpdatedAt,
 		arg.ID,
 		arg.UserID,
+		arg.EndCountryCode,
+		arg.EndTimezone,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
 }
 
+const restoreTrip = `-- name: RestoreTrip :one
+UPDATE trips
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+RETURNING id
+`
+
+type RestoreTripParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RestoreTrip(ctx context.Context, arg RestoreTripParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, restoreTrip, arg.ID, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setTripCover = `-- name: SetTripCover :execrows
+UPDATE trips
+SET cover_media_id = $1, updated_at = NOW()
+WHERE id = $2 AND deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trips.id AND a.user_id = $3 AND a.role IN ('owner', 'editor')
+    )
+    AND ($1::uuid IS NULL OR EXISTS (
+        SELECT 1 FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+        WHERE m.id = $1::uuid AND m.photo_url IS NOT NULL
+            AND COALESCE(m.trip_id, s.trip_id) = trips.id
+            AND m.deleted_at IS NULL AND s.deleted_at IS NULL
+    ))
+`
+
+type SetTripCoverParams struct {
+	MediaID uuid.NullUUID
+	TripID  uuid.UUID
+	UserID  uuid.UUID
+}
+
+func (q *Queries) SetTripCover(ctx context.Context, arg SetTripCoverParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setTripCover, arg.MediaID, arg.TripID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setTripTimezones = `-- name: SetTripTimezones :exec
+UPDATE trips
+SET start_timezone = $1, end_timezone = $2, timezones_resolved = TRUE
+WHERE id = $3
+`
+
+type SetTripTimezonesParams struct {
+	StartTimezone string
+	EndTimezone   sql.NullString
+	ID            uuid.UUID
+}
+
+func (q *Queries) SetTripTimezones(ctx context.Context, arg SetTripTimezonesParams) error {
+	_, err := q.db.ExecContext(ctx, setTripTimezones, arg.StartTimezone, arg.EndTimezone, arg.ID)
+	return err
+}
+
+const trashTrip = `-- name: TrashTrip :execrows
+UPDATE trips
+SET deleted_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+`
+
+type TrashTripParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) TrashTrip(ctx context.Context, arg TrashTripParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, trashTrip, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateTrip = `-- name: UpdateTrip :one
 UPDATE trips
 SET
@@ -265,9 +635,25 @@ SET
     start_location =$1,
     trip_title = $2,
     end_date = $3,
-    updated_at = $5
+    updated_at = $5,
+    start_country_code = $8,
+    start_timezone = $9,
+    transport_mode = $10,
+    end_timezone = CASE
+        WHEN end_location IS NOT NULL THEN end_timezone
+        WHEN $3::TIMESTAMPTZ IS NULL THEN NULL
+        ELSE COALESCE((
+            SELECT s.timezone FROM trip_stop s
+            WHERE s.trip_id = trips.id AND s.deleted_at IS NULL
+            ORDER BY s.sequence DESC
+            LIMIT 1
+        ), $9)
+    END
 WHERE
-    id = $6 AND user_id =$7
+    id = $6 AND status IN ('planned', 'active') AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trips.id AND a.user_id = $7 AND a.role IN ('owner', 'editor')
+    )
 RETURNING  id
 `
 
@@ -279,6 +665,9 @@ type UpdateTripParams struct {
 	UpdatedAt         time.Time
 	ID                uuid.UUID
 	UserID            uuid.UUID
+	StartCountryCode  sql.NullString
+	StartTimezone     string
+	TransportMode     NullTransportMode
 }
 
 func (q *Queries) UpdateTrip(ctx context.Context, arg UpdateTripParams) (uuid.UUID, error) {
@@ -290,6 +679,41 @@ func (q *Queries) UpdateTrip(ctx context.Context, arg UpdateTripParams) (uuid.UU
 		arg.UpdatedAt,
 		arg.ID,
 		arg.UserID,
+		arg.StartCountryCode,
+		arg.StartTimezone,
+		arg.TransportMode,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const updateTripStatus = `-- name: UpdateTripStatus :one
+UPDATE trips
+SET
+    status = $1,
+    updated_at = NOW()
+WHERE
+    id = $2 AND status = $3 AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trips.id AND a.user_id = $4 AND a.role IN ('owner', 'editor')
+    )
+RETURNING id
+`
+
+type UpdateTripStatusParams struct {
+	Status        TripStatus
+	ID            uuid.UUID
+	CurrentStatus TripStatus
+	UserID        uuid.UUID
+}
+
+func (q *Queries) UpdateTripStatus(ctx context.Context, arg UpdateTripStatusParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, updateTripStatus,
+		arg.Status,
+		arg.ID,
+		arg.CurrentStatus,
+		arg.UserID,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,7 +21,7 @@ VALUES (
     $3,
     $4
 ) 
-RETURNING id, created_at, updated_at, username, password_hash, email
+RETURNING id, created_at, updated_at, username, password_hash, email, home_currency, keep_photo_location
 `
 
 type CreateUserParams struct {
@@ -45,6 +46,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Username,
 		&i.PasswordHash,
 		&i.Email,
+		&i.HomeCurrency,
+		&i.KeepPhotoLocation,
 	)
 	return i, err
 }
@@ -70,7 +73,7 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 
 const getUser = `-- name: GetUser :one
 
-SELECT id, username, email,password_hash, created_at
+SELECT id, username, email,password_hash, created_at, home_currency, keep_photo_location
 FROM USERS
 WHERE username = $1 OR id = $2 OR email = $3
 `
@@ -82,11 +85,13 @@ type GetUserParams struct {
 }
 
 type GetUserRow struct {
-	ID           uuid.UUID
-	Username     string
-	Email        string
-	PasswordHash string
-	CreatedAt    time.Time
+	ID                uuid.UUID
+	Username          string
+	Email             string
+	PasswordHash      string
+	CreatedAt         time.Time
+	HomeCurrency      string
+	KeepPhotoLocation bool
 }
 
 func (q *Queries) GetUser(ctx context.Context, arg GetUserParams) (GetUserRow, error) {
@@ -98,6 +103,8 @@ func (q *Queries) GetUser(ctx context.Context, arg GetUserParams) (GetUserRow, e
 		&i.Email,
 		&i.PasswordHash,
 		&i.CreatedAt,
+		&i.HomeCurrency,
+		&i.KeepPhotoLocation,
 	)
 	return i, err
 }
@@ -118,11 +125,38 @@ func (q *Queries) UpdatePassword(ctx context.Context, arg UpdatePasswordParams)
 	return err
 }
 
+const updateSettings = `-- name: UpdateSettings :one
+UPDATE users
+SET home_currency = $1,
+    keep_photo_location = COALESCE($2, keep_photo_location),
+    updated_at = NOW()
+WHERE id = $3
+RETURNING home_currency, keep_photo_location
+`
+
+type UpdateSettingsParams struct {
+	HomeCurrency      string
+	KeepPhotoLocation sql.NullBool
+	ID                uuid.UUID
+}
+
+type UpdateSettingsRow struct {
+	HomeCurrency      string
+	KeepPhotoLocation bool
+}
+
+func (q *Queries) UpdateSettings(ctx context.Context, arg UpdateSettingsParams) (UpdateSettingsRow, error) {
+	row := q.db.QueryRowContext(ctx, updateSettings, arg.HomeCurrency, arg.KeepPhotoLocation, arg.ID)
+	var i UpdateSettingsRow
+	err := row.Scan(&i.HomeCurrency, &i.KeepPhotoLocation)
+	return i, err
+}
+
 const updateUserDetails = `-- name: UpdateUserDetails :one
 UPDATE users
 SET username = $1, email = $2, updated_at = $3
 WHERE id = $1 
-RETURNING id, created_at, updated_at, username, password_hash, email
+RETURNING id, created_at, updated_at, username, password_hash, email, home_currency, keep_photo_location
 `
 
 type UpdateUserDetailsParams struct {
@@ -141,6 +175,8 @@ func (q *Queries) UpdateUserDetails(ctx context.Context, arg UpdateUserDetailsPa
 		&i.Username,
 		&i.PasswordHash,
 		&i.Email,
+		&i.HomeCurrency,
+		&i.KeepPhotoLocation,
 	)
 	return i, err
 }
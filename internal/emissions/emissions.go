@@ -0,0 +1,143 @@
+package emissions
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Modes lists the transport modes a leg can be travelled by. It mirrors the
+// transport_mode enum in the database.
+var Modes = []string{"walk", "bike", "car", "bus", "train", "ferry", "flight"}
+
+// The shipped factors are average grams of CO2e per passenger-kilometre
+// taken from the UK government greenhouse gas conversion factors.
+//
+//go:embed factors.json
+var defaultFactors []byte
+
+// Factors maps a transport mode to the grams of CO2e emitted per
+// passenger-kilometre.
+type Factors map[string]float64
+
+// Footprint accumulates the distance travelled per mode and the estimated
+// emissions. Distances are in meters and CO2 in grams.
+type Footprint struct {
+	CO2       float64
+	Distance  map[string]float64
+	Untracked float64
+}
+
+func Default() Factors {
+	factors, err := Parse(bytes.NewReader(defaultFactors))
+
+	if err != nil {
+		panic(fmt.Sprintf("emissions: invalid shipped factors: %v", err))
+	}
+
+	return factors
+}
+
+// Load reads an emission-factor table from a JSON file. Modes missing from
+// the file keep their shipped factor.
+func Load(path string) (Factors, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	overrides, err := Parse(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	factors := Default()
+
+	for mode, factor := range overrides {
+		factors[mode] = factor
+	}
+
+	return factors, nil
+}
+
+// Parse reads a JSON object of mode to grams of CO2e per passenger-kilometre.
+func Parse(r io.Reader) (Factors, error) {
+	factors := Factors{}
+
+	if err := json.NewDecoder(r).Decode(&factors); err != nil {
+		return nil, err
+	}
+
+	for mode, factor := range factors {
+		if !isMode(mode) {
+			return nil, fmt.Errorf("unknown transport mode %q", mode)
+		}
+
+		if factor < 0 {
+			return nil, fmt.Errorf("negative emission factor for %v", mode)
+		}
+	}
+
+	return factors, nil
+}
+
+// Estimate returns the grams of CO2e for travelling meters by mode. It
+// reports false when the mode has no factor.
+func (f Factors) Estimate(mode string, meters float64) (float64, bool) {
+	factor, ok := f[mode]
+
+	if !ok {
+		return 0, false
+	}
+
+	return factor * meters / 1000, true
+}
+
+// Add records a leg on the footprint. Legs without a known mode only count
+// towards the untracked distance.
+func (f Factors) Add(footprint *Footprint, mode string, meters float64) {
+	co2, ok := f.Estimate(mode, meters)
+
+	if !ok {
+		footprint.Untracked += meters
+		return
+	}
+
+	if footprint.Distance == nil {
+		footprint.Distance = make(map[string]float64)
+	}
+
+	footprint.Distance[mode] += meters
+	footprint.CO2 += co2
+}
+
+// Merge adds the distances and emissions of other to the footprint.
+func (footprint *Footprint) Merge(other Footprint) {
+	for mode, meters := range other.Distance {
+		if footprint.Distance == nil {
+			footprint.Distance = make(map[string]float64)
+		}
+
+		footprint.Distance[mode] += meters
+	}
+
+	footprint.CO2 += other.CO2
+	footprint.Untracked += other.Untracked
+}
+
+func isMode(mode string) bool {
+	for _, m := range Modes {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
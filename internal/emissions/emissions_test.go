@@ -0,0 +1,141 @@
+package emissions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    Factors
+		wantErr bool
+	}{
+		"Valid table": {
+			input: `{"car": 120, "train": 6}`,
+			want:  Factors{"car": 120, "train": 6},
+		},
+		"Unknown mode": {
+			input:   `{"rocket": 1000}`,
+			wantErr: true,
+		},
+		"Negative factor": {
+			input:   `{"bus": -1}`,
+			wantErr: true,
+		},
+		"Malformed JSON": {
+			input:   `{"car": }`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tc.input))
+
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefaultCoversAllModes(t *testing.T) {
+	factors := Default()
+
+	for _, mode := range Modes {
+		if _, ok := factors[mode]; !ok {
+			t.Errorf("Default() has no factor for %v", mode)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	type leg struct {
+		mode   string
+		meters float64
+	}
+
+	factors := Factors{"car": 150, "train": 30, "walk": 0}
+
+	tests := map[string]struct {
+		legs []leg
+		want Footprint
+	}{
+		"No legs": {
+			want: Footprint{},
+		},
+		"Mixed modes": {
+			legs: []leg{{"car", 10000}, {"train", 100000}, {"car", 5000}, {"walk", 2000}},
+			want: Footprint{
+				CO2:      150*15 + 30*100,
+				Distance: map[string]float64{"car": 15000, "train": 100000, "walk": 2000},
+			},
+		},
+		"Leg without mode": {
+			legs: []leg{{"", 4000}, {"train", 1000}},
+			want: Footprint{
+				CO2:       30,
+				Distance:  map[string]float64{"train": 1000},
+				Untracked: 4000,
+			},
+		},
+		"Mode missing from table": {
+			legs: []leg{{"ferry", 3000}},
+			want: Footprint{Untracked: 3000},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Footprint{}
+
+			for _, l := range tc.legs {
+				factors.Add(&got, l.mode, l.meters)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Add() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := map[string]struct {
+		footprints []Footprint
+		want       Footprint
+	}{
+		"Empty footprints": {
+			footprints: []Footprint{{}, {}},
+			want:       Footprint{},
+		},
+		"Overlapping modes": {
+			footprints: []Footprint{
+				{CO2: 100, Distance: map[string]float64{"car": 1000}, Untracked: 50},
+				{CO2: 40, Distance: map[string]float64{"car": 200, "bus": 300}},
+			},
+			want: Footprint{CO2: 140, Distance: map[string]float64{"car": 1200, "bus": 300}, Untracked: 50},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Footprint{}
+
+			for _, footprint := range tc.footprints {
+				got.Merge(footprint)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Merge() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupported = errors.New("exif: unsupported image format")
+	ErrNotFound    = errors.New("exif: no exif metadata")
+	ErrMalformed   = errors.New("exif: malformed metadata")
+)
+
+// maxExifSize bounds the metadata read into memory. A JPEG APP1 segment can
+// never exceed it and PNG eXIf chunks in the wild are far smaller.
+const maxExifSize = 64 << 10
+
+// Tags read from the TIFF structure, by the IFD they live in.
+const (
+	tagOrientation       = 0x0112
+	tagDateTime          = 0x0132
+	tagExifIFD           = 0x8769
+	tagGPSIFD            = 0x8825
+	tagDateTimeOriginal  = 0x9003
+	tagDateTimeDigitized = 0x9004
+	tagOffsetTimeOrig    = 0x9011
+	tagGPSLatitudeRef    = 0x0001
+	tagGPSLatitude       = 0x0002
+	tagGPSLongitudeRef   = 0x0003
+	tagGPSLongitude      = 0x0004
+)
+
+const dateTimeLayout = "2006:01:02 15:04:05"
+
+// Metadata is what Read pulls from a photo's EXIF. TakenAt is read as UTC
+// when the camera recorded no offset, see TakenIn.
+type Metadata struct {
+	Orientation int
+	TakenAt     time.Time
+	HasOffset   bool
+	HasLocation bool
+	Lat         float64
+	Lng         float64
+}
+
+// TakenIn returns the capture time, reading the camera's wall clock in loc
+// when it did not record its UTC offset.
+func (m Metadata) TakenIn(loc *time.Location) time.Time {
+	if m.TakenAt.IsZero() || m.HasOffset {
+		return m.TakenAt
+	}
+
+	t := m.TakenAt
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+}
+
+// Read finds the EXIF block of a JPEG or PNG and decodes the capture time,
+// GPS position and orientation. It stops reading at the image data of a
+// JPEG, which is where cameras put their metadata.
+func Read(r io.Reader) (Metadata, error) {
+	head := make([]byte, 8)
+
+	if _, err := io.ReadFull(r, head[:2]); err != nil {
+		return Metadata{}, ErrUnsupported
+	}
+
+	if head[0] == 0xFF && head[1] == 0xD8 {
+		return readJPEG(r)
+	}
+
+	if _, err := io.ReadFull(r, head[2:]); err != nil || !bytes.Equal(head, []byte("\x89PNG\r\n\x1a\n")) {
+		return Metadata{}, ErrUnsupported
+	}
+
+	return readPNG(r)
+}
+
+func readJPEG(r io.Reader) (Metadata, error) {
+	marker := make([]byte, 2)
+
+	for {
+		if _, err := io.ReadFull(r, marker); err != nil {
+			return Metadata{}, ErrNotFound
+		}
+
+		if marker[0] != 0xFF {
+			return Metadata{}, ErrMalformed
+		}
+
+		// Markers may be padded with any number of 0xFF fill bytes.
+		for marker[1] == 0xFF {
+			if _, err := io.ReadFull(r, marker[1:]); err != nil {
+				return Metadata{}, ErrNotFound
+			}
+		}
+
+		switch {
+		case marker[1] == 0xDA || marker[1] == 0xD9:
+			return Metadata{}, ErrNotFound
+		case marker[1] == 0x01 || (marker[1] >= 0xD0 && marker[1] <= 0xD7):
+			continue
+		}
+
+		length := make([]byte, 2)
+
+		if _, err := io.ReadFull(r, length); err != nil {
+			return Metadata{}, ErrMalformed
+		}
+
+		size := int64(binary.BigEndian.Uint16(length)) - 2
+
+		if size < 0 {
+			return Metadata{}, ErrMalformed
+		}
+
+		if marker[1] != 0xE1 {
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return Metadata{}, ErrMalformed
+			}
+			continue
+		}
+
+		segment := make([]byte, size)
+
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return Metadata{}, ErrMalformed
+		}
+
+		// APP1 also carries XMP, which is skipped.
+		if tiff, ok := bytes.CutPrefix(segment, []byte("Exif\x00\x00")); ok {
+			return parseTIFF(tiff)
+		}
+	}
+}
+
+func readPNG(r io.Reader) (Metadata, error) {
+	header := make([]byte, 8)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return Metadata{}, ErrNotFound
+		}
+
+		size := int64(binary.BigEndian.Uint32(header))
+
+		switch string(header[4:]) {
+		case "IEND":
+			return Metadata{}, ErrNotFound
+		case "eXIf":
+			if size > maxExifSize {
+				return Metadata{}, ErrMalformed
+			}
+
+			tiff := make([]byte, size)
+
+			if _, err := io.ReadFull(r, tiff); err != nil {
+				return Metadata{}, ErrMalformed
+			}
+
+			return parseTIFF(tiff)
+		}
+
+		// Skip the chunk data and its CRC.
+		if _, err := io.CopyN(io.Discard, r, size+4); err != nil {
+			return Metadata{}, ErrNotFound
+		}
+	}
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+// typeSizes gives the byte size of each TIFF field type used by EXIF.
+var typeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func parseTIFF(data []byte) (Metadata, error) {
+	if len(data) < 8 {
+		return Metadata{}, ErrMalformed
+	}
+
+	t := tiffReader{data: data}
+
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return Metadata{}, ErrMalformed
+	}
+
+	if t.order.Uint16(data[2:]) != 42 {
+		return Metadata{}, ErrMalformed
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(data[4:]))
+
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	m := Metadata{Orientation: 1}
+
+	if orientation, ok := t.uint(ifd0[tagOrientation]); ok && orientation >= 1 && orientation <= 8 {
+		m.Orientation = int(orientation)
+	}
+
+	taken, offset := t.ascii(ifd0[tagDateTime]), ""
+
+	if pointer, ok := t.uint(ifd0[tagExifIFD]); ok {
+		exifIFD, err := t.readIFD(pointer)
+
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		for _, tag := range []uint16{tagDateTimeDigitized, tagDateTimeOriginal} {
+			if value := t.ascii(exifIFD[tag]); value != "" {
+				taken = value
+			}
+		}
+
+		offset = t.ascii(exifIFD[tagOffsetTimeOrig])
+	}
+
+	if takenAt, err := time.Parse(dateTimeLayout, taken); err == nil {
+		m.TakenAt = takenAt
+
+		if zoned, err := time.Parse(dateTimeLayout+"-07:00", taken+offset); offset != "" && err == nil {
+			m.TakenAt, m.HasOffset = zoned, true
+		}
+	}
+
+	if pointer, ok := t.uint(ifd0[tagGPSIFD]); ok {
+		gpsIFD, err := t.readIFD(pointer)
+
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		lat, latOK := t.degrees(gpsIFD[tagGPSLatitude], t.ascii(gpsIFD[tagGPSLatitudeRef]), "N", "S")
+		lng, lngOK := t.degrees(gpsIFD[tagGPSLongitude], t.ascii(gpsIFD[tagGPSLongitudeRef]), "E", "W")
+
+		// Some phones write 0,0 rather than leaving out a position they never
+		// got a fix for.
+		if latOK && lngOK && lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180 && (lat != 0 || lng != 0) {
+			m.HasLocation, m.Lat, m.Lng = true, lat, lng
+		}
+	}
+
+	return m, nil
+}
+
+func (t tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, ErrMalformed
+	}
+
+	count := uint64(t.order.Uint16(t.data[offset:]))
+	start := uint64(offset) + 2
+
+	if start+count*12 > uint64(len(t.data)) {
+		return nil, ErrMalformed
+	}
+
+	entries := make(map[uint16]ifdEntry, count)
+
+	for i := range count {
+		raw := t.data[start+i*12 : start+i*12+12]
+		kind := t.order.Uint16(raw[2:])
+		n := t.order.Uint32(raw[4:])
+
+		size, known := typeSizes[kind]
+
+		if !known {
+			continue
+		}
+
+		total := uint64(size) * uint64(n)
+		value := raw[8:12]
+
+		if total > 4 {
+			valueOffset := uint64(t.order.Uint32(raw[8:]))
+
+			if valueOffset+total > uint64(len(t.data)) {
+				continue
+			}
+
+			value = t.data[valueOffset : valueOffset+total]
+		}
+
+		entries[t.order.Uint16(raw)] = ifdEntry{kind: kind, count: n, value: value[:min(total, uint64(len(value)))]}
+	}
+
+	return entries, nil
+}
+
+func (t tiffReader) uint(e ifdEntry) (uint32, bool) {
+	switch {
+	case e.kind == 3 && e.count >= 1:
+		return uint32(t.order.Uint16(e.value)), true
+	case e.kind == 4 && e.count >= 1:
+		return t.order.Uint32(e.value), true
+	}
+
+	return 0, false
+}
+
+func (t tiffReader) ascii(e ifdEntry) string {
+	if e.kind != 2 {
+		return ""
+	}
+
+	value, _, _ := bytes.Cut(e.value, []byte{0})
+	return strings.TrimSpace(string(value))
+}
+
+// degrees turns the degrees, minutes and seconds rationals of a GPS
+// coordinate into a signed decimal value.
+func (t tiffReader) degrees(e ifdEntry, ref, positive, negative string) (float64, bool) {
+	if e.kind != 5 || e.count != 3 {
+		return 0, false
+	}
+
+	var parts [3]float64
+
+	for i := range parts {
+		numerator := t.order.Uint32(e.value[i*8:])
+		denominator := t.order.Uint32(e.value[i*8+4:])
+
+		if denominator == 0 {
+			return 0, false
+		}
+
+		parts[i] = float64(numerator) / float64(denominator)
+	}
+
+	value := parts[0] + parts[1]/60 + parts[2]/3600
+
+	switch ref {
+	case positive:
+		return value, true
+	case negative:
+		return -value, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,235 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type field struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+func ascii(tag uint16, s string) field {
+	return field{tag: tag, kind: 2, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func short(order binary.AppendByteOrder, tag uint16, v uint16) field {
+	return field{tag: tag, kind: 3, count: 1, value: order.AppendUint16(nil, v)}
+}
+
+func long(order binary.AppendByteOrder, tag uint16, v uint32) field {
+	return field{tag: tag, kind: 4, count: 1, value: order.AppendUint32(nil, v)}
+}
+
+func dms(order binary.AppendByteOrder, tag uint16, d, m, s100 uint32) field {
+	var value []byte
+	for _, pair := range [][2]uint32{{d, 1}, {m, 1}, {s100, 100}} {
+		value = order.AppendUint32(value, pair[0])
+		value = order.AppendUint32(value, pair[1])
+	}
+	return field{tag: tag, kind: 5, count: 3, value: value}
+}
+
+// ifd lays out an IFD at offset, with values that do not fit inline placed
+// straight after it.
+func ifd(order binary.AppendByteOrder, offset uint32, fields []field) []byte {
+	out := order.AppendUint16(nil, uint16(len(fields)))
+	extraAt := offset + 2 + uint32(len(fields))*12 + 4
+	var extra []byte
+
+	for _, f := range fields {
+		out = order.AppendUint16(out, f.tag)
+		out = order.AppendUint16(out, f.kind)
+		out = order.AppendUint32(out, f.count)
+
+		if len(f.value) <= 4 {
+			out = append(out, append(f.value, make([]byte, 4-len(f.value))...)...)
+			continue
+		}
+
+		out = order.AppendUint32(out, extraAt+uint32(len(extra)))
+		extra = append(extra, f.value...)
+	}
+
+	out = order.AppendUint32(out, 0)
+	return append(out, extra...)
+}
+
+// tiff builds a TIFF block with IFD0, an Exif IFD and a GPS IFD, leaving out
+// the sub IFDs that have no fields.
+func tiff(order binary.AppendByteOrder, ifd0, exifFields, gpsFields []field) []byte {
+	header := []byte("II")
+	if order == binary.AppendByteOrder(binary.BigEndian) {
+		header = []byte("MM")
+	}
+	header = order.AppendUint16(header, 42)
+	header = order.AppendUint32(header, 8)
+
+	// IFD0 grows by one entry per sub IFD pointer, so size it first.
+	pointers := 0
+	if len(exifFields) > 0 {
+		pointers++
+	}
+	if len(gpsFields) > 0 {
+		pointers++
+	}
+
+	placeholder := append(append([]field{}, ifd0...), make([]field, pointers)...)
+	for i := len(ifd0); i < len(placeholder); i++ {
+		placeholder[i] = long(order, 0, 0)
+	}
+
+	next := uint32(8 + len(ifd(order, 8, placeholder)))
+	var tail []byte
+
+	if len(exifFields) > 0 {
+		ifd0 = append(ifd0, long(order, tagExifIFD, next))
+		block := ifd(order, next, exifFields)
+		tail = append(tail, block...)
+		next += uint32(len(block))
+	}
+
+	if len(gpsFields) > 0 {
+		ifd0 = append(ifd0, long(order, tagGPSIFD, next))
+		tail = append(tail, ifd(order, next, gpsFields)...)
+	}
+
+	return append(append(header, ifd(order, 8, ifd0)...), tail...)
+}
+
+func jpegWith(exif []byte) []byte {
+	app0 := []byte{0xFF, 0xE0, 0x00, 0x07, 'J', 'F', 'I', 'F', 0}
+	out := append([]byte{0xFF, 0xD8}, app0...)
+
+	if exif != nil {
+		payload := append([]byte("Exif\x00\x00"), exif...)
+		out = append(out, 0xFF, 0xE1)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+		out = append(out, payload...)
+	}
+
+	return append(out, 0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9)
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	out = append(out, kind...)
+	out = append(out, data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(append([]byte(kind), data...)))
+}
+
+func pngWith(exif []byte) []byte {
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = append(out, pngChunk("IHDR", make([]byte, 13))...)
+	out = append(out, pngChunk("IDAT", []byte{1, 2, 3})...)
+	out = append(out, pngChunk("eXIf", exif)...)
+	return append(out, pngChunk("IEND", nil)...)
+}
+
+func TestRead(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+
+	phone := tiff(le,
+		[]field{short(le, tagOrientation, 6), ascii(tagDateTime, "2024:06:02 09:00:00")},
+		[]field{ascii(tagDateTimeOriginal, "2024:06:01 18:30:15"), ascii(tagOffsetTimeOrig, "+02:00")},
+		[]field{ascii(tagGPSLatitudeRef, "S"), dms(le, tagGPSLatitude, 1, 17, 3000), ascii(tagGPSLongitudeRef, "E"), dms(le, tagGPSLongitude, 36, 49, 1800)},
+	)
+
+	tests := map[string]struct {
+		input   []byte
+		want    Metadata
+		wantErr error
+	}{
+		"phone JPEG with offset and GPS": {
+			input: jpegWith(phone),
+			want: Metadata{
+				Orientation: 6,
+				TakenAt:     time.Date(2024, 6, 1, 18, 30, 15, 0, time.FixedZone("", 2*60*60)),
+				HasOffset:   true,
+				HasLocation: true,
+				Lat:         -(1 + 17.0/60 + 30.0/3600),
+				Lng:         36 + 49.0/60 + 18.0/3600,
+			},
+		},
+		"big endian camera without offset or GPS": {
+			input: jpegWith(tiff(be, nil, []field{ascii(tagDateTimeOriginal, "2023:12:24 07:05:00")}, nil)),
+			want:  Metadata{Orientation: 1, TakenAt: time.Date(2023, 12, 24, 7, 5, 0, 0, time.UTC)},
+		},
+		"null island is treated as no fix": {
+			input: jpegWith(tiff(le, nil, nil, []field{ascii(tagGPSLatitudeRef, "N"), dms(le, tagGPSLatitude, 0, 0, 0), ascii(tagGPSLongitudeRef, "E"), dms(le, tagGPSLongitude, 0, 0, 0)})),
+			want:  Metadata{Orientation: 1},
+		},
+		"PNG with an eXIf chunk after the image data": {
+			input: pngWith(tiff(be, []field{short(be, tagOrientation, 3)}, nil, []field{ascii(tagGPSLatitudeRef, "N"), dms(be, tagGPSLatitude, 48, 51, 0), ascii(tagGPSLongitudeRef, "W"), dms(be, tagGPSLongitude, 2, 21, 0)})),
+			want:  Metadata{Orientation: 3, HasLocation: true, Lat: 48 + 51.0/60, Lng: -(2 + 21.0/60)},
+		},
+		"JPEG without EXIF": {
+			input:   jpegWith(nil),
+			wantErr: ErrNotFound,
+		},
+		"GIF": {
+			input:   []byte("GIF89a"),
+			wantErr: ErrUnsupported,
+		},
+		"IFD pointing past the block": {
+			input:   jpegWith([]byte{'I', 'I', 42, 0, 0xFF, 0, 0, 0}),
+			wantErr: ErrMalformed,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Read(bytes.NewReader(tc.input))
+
+			if err != tc.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Read() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTakenIn(t *testing.T) {
+	nairobi := time.FixedZone("EAT", 3*60*60)
+	wallClock := time.Date(2024, 6, 1, 18, 30, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		input Metadata
+		want  time.Time
+	}{
+		"wall clock read in the photo's zone": {
+			input: Metadata{TakenAt: wallClock},
+			want:  time.Date(2024, 6, 1, 18, 30, 0, 0, nairobi),
+		},
+		"recorded offset wins": {
+			input: Metadata{TakenAt: wallClock, HasOffset: true},
+			want:  wallClock,
+		},
+		"no capture time": {
+			input: Metadata{},
+			want:  time.Time{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.input.TakenIn(nairobi)
+
+			if !got.Equal(tc.want) {
+				t.Errorf("TakenIn() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// pngMetadataChunks are the PNG chunks that can carry EXIF, free text or a
+// timestamp and are dropped by Strip.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// Strip copies a JPEG or PNG from r to w without its metadata. Image data is
+// copied as is, so nothing is re-compressed. Colour profiles are kept, and
+// when the photo needs rotating a minimal EXIF block carrying only the
+// orientation is written in place of the original one.
+func Strip(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+
+	head, err := br.Peek(8)
+
+	switch {
+	case len(head) >= 2 && head[0] == 0xFF && head[1] == 0xD8:
+		err = stripJPEG(bw, br)
+	case err == nil && bytes.Equal(head, []byte("\x89PNG\r\n\x1a\n")):
+		err = stripPNG(bw, br)
+	default:
+		return ErrUnsupported
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// orientationTIFF is a TIFF block with a single IFD holding the orientation.
+func orientationTIFF(orientation int) []byte {
+	out := []byte("II*\x00\x08\x00\x00\x00")
+	out = binary.LittleEndian.AppendUint16(out, 1)
+	out = binary.LittleEndian.AppendUint16(out, tagOrientation)
+	out = binary.LittleEndian.AppendUint16(out, 3)
+	out = binary.LittleEndian.AppendUint32(out, 1)
+	out = binary.LittleEndian.AppendUint16(out, uint16(orientation))
+	out = append(out, 0, 0)
+	return binary.LittleEndian.AppendUint32(out, 0)
+}
+
+// keepJPEGSegment decides which application and comment segments survive.
+// Everything else in the header is needed to decode the image.
+func keepJPEGSegment(marker byte, payload []byte) bool {
+	switch {
+	case marker == 0xE0:
+		return bytes.HasPrefix(payload, []byte("JFIF\x00")) || bytes.HasPrefix(payload, []byte("JFXX\x00"))
+	case marker == 0xE2:
+		return bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+	case marker == 0xEE:
+		return bytes.HasPrefix(payload, []byte("Adobe"))
+	case marker > 0xE0 && marker <= 0xEF, marker == 0xFE:
+		return false
+	}
+
+	return true
+}
+
+func stripJPEG(w *bufio.Writer, r *bufio.Reader) error {
+	if _, err := r.Discard(2); err != nil {
+		return ErrMalformed
+	}
+
+	w.Write([]byte{0xFF, 0xD8})
+
+	orientation := 1
+	pendingOrientation := true
+
+	marker, err := nextJPEGMarker(r)
+
+	for {
+		if err != nil {
+			return ErrMalformed
+		}
+
+		if marker == 0xD9 {
+			// Anything after the end of image, such as the extra frames of a
+			// multi-picture file, is dropped along with its own metadata.
+			_, err := w.Write([]byte{0xFF, 0xD9})
+			return err
+		}
+
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			w.Write([]byte{0xFF, marker})
+			marker, err = nextJPEGMarker(r)
+			continue
+		}
+
+		length := make([]byte, 2)
+
+		if _, err := io.ReadFull(r, length); err != nil {
+			return ErrMalformed
+		}
+
+		size := int(binary.BigEndian.Uint16(length)) - 2
+
+		if size < 0 {
+			return ErrMalformed
+		}
+
+		payload := make([]byte, size)
+
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return ErrMalformed
+		}
+
+		if tiff, ok := bytes.CutPrefix(payload, []byte("Exif\x00\x00")); ok && marker == 0xE1 {
+			if m, err := parseTIFF(tiff); err == nil {
+				orientation = m.Orientation
+			}
+		}
+
+		if !keepJPEGSegment(marker, payload) {
+			marker, err = nextJPEGMarker(r)
+			continue
+		}
+
+		// The replacement EXIF goes after any JFIF header and before the
+		// first segment describing the image.
+		if pendingOrientation && marker != 0xE0 {
+			pendingOrientation = false
+
+			if orientation != 1 {
+				block := append([]byte("Exif\x00\x00"), orientationTIFF(orientation)...)
+				w.Write([]byte{0xFF, 0xE1})
+				w.Write(binary.BigEndian.AppendUint16(nil, uint16(len(block)+2)))
+				w.Write(block)
+			}
+		}
+
+		w.Write([]byte{0xFF, marker})
+		w.Write(length)
+		w.Write(payload)
+
+		if marker != 0xDA {
+			marker, err = nextJPEGMarker(r)
+			continue
+		}
+
+		marker, err = copyEntropyData(w, r)
+	}
+}
+
+func nextJPEGMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+
+	if err != nil || b != 0xFF {
+		return 0, ErrMalformed
+	}
+
+	// Markers may be padded with any number of 0xFF fill bytes.
+	for b == 0xFF {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, ErrMalformed
+		}
+	}
+
+	return b, nil
+}
+
+// copyEntropyData copies the compressed scan that follows a start of scan
+// header and returns the marker that ends it. Stuffed zero bytes and restart
+// markers are part of the scan.
+func copyEntropyData(w *bufio.Writer, r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+
+		if err != nil {
+			return 0, ErrMalformed
+		}
+
+		if b != 0xFF {
+			w.WriteByte(b)
+			continue
+		}
+
+		next, err := r.ReadByte()
+
+		for err == nil && next == 0xFF {
+			next, err = r.ReadByte()
+		}
+
+		if err != nil {
+			return 0, ErrMalformed
+		}
+
+		if next == 0x00 || (next >= 0xD0 && next <= 0xD7) {
+			w.Write([]byte{0xFF, next})
+			continue
+		}
+
+		return next, nil
+	}
+}
+
+func writePNGChunk(w *bufio.Writer, kind string, data []byte) {
+	w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(data))))
+	w.WriteString(kind)
+	w.Write(data)
+	w.Write(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(append([]byte(kind), data...))))
+}
+
+func stripPNG(w *bufio.Writer, r *bufio.Reader) error {
+	signature := make([]byte, 8)
+
+	if _, err := io.ReadFull(r, signature); err != nil {
+		return ErrMalformed
+	}
+
+	w.Write(signature)
+
+	orientation := 1
+	wroteOrientation := false
+	header := make([]byte, 8)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return ErrMalformed
+		}
+
+		size := int64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:])
+
+		if pngMetadataChunks[kind] {
+			if kind == "eXIf" && size <= maxExifSize {
+				tiff := make([]byte, size)
+
+				if _, err := io.ReadFull(r, tiff); err != nil {
+					return ErrMalformed
+				}
+
+				if m, err := parseTIFF(tiff); err == nil {
+					orientation = m.Orientation
+				}
+
+				size = 0
+			}
+
+			if _, err := io.CopyN(io.Discard, r, size+4); err != nil {
+				return ErrMalformed
+			}
+
+			continue
+		}
+
+		// eXIf belongs before the image data but may turn up after it, in
+		// which case the orientation is written just before the end.
+		if !wroteOrientation && orientation != 1 && (kind == "IDAT" || kind == "IEND") {
+			wroteOrientation = true
+			writePNGChunk(w, "eXIf", orientationTIFF(orientation))
+		}
+
+		w.Write(header)
+
+		if _, err := io.CopyN(w, r, size+4); err != nil {
+			return ErrMalformed
+		}
+
+		if kind == "IEND" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func segment(marker byte, payload []byte) []byte {
+	out := binary.BigEndian.AppendUint16([]byte{0xFF, marker}, uint16(len(payload)+2))
+	return append(out, payload...)
+}
+
+// withSegments inserts extra segments straight after the SOI marker of an
+// encoded JPEG and appends trailing bytes after its EOI marker.
+func withSegments(encoded []byte, trailing []byte, segments ...[]byte) []byte {
+	out := append([]byte{}, encoded[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	out = append(out, encoded[2:]...)
+	return append(out, trailing...)
+}
+
+// withChunks inserts extra chunks straight after the IHDR chunk of an
+// encoded PNG and appends others before IEND.
+func withChunks(encoded []byte, before, after [][]byte) []byte {
+	// The signature is 8 bytes and IHDR always 25.
+	out := append([]byte{}, encoded[:33]...)
+	for _, c := range before {
+		out = append(out, c...)
+	}
+	out = append(out, encoded[33:len(encoded)-12]...)
+	for _, c := range after {
+		out = append(out, c...)
+	}
+	return append(out, encoded[len(encoded)-12:]...)
+}
+
+func sample() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := range 8 {
+		for x := range 16 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 16), G: uint8(y * 32), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestStrip(t *testing.T) {
+	le := binary.LittleEndian
+
+	var jpegBuf, pngBuf bytes.Buffer
+
+	if err := jpeg.Encode(&jpegBuf, sample(), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+
+	if err := png.Encode(&pngBuf, sample()); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	gps := []field{ascii(tagGPSLatitudeRef, "S"), dms(le, tagGPSLatitude, 1, 17, 3000), ascii(tagGPSLongitudeRef, "E"), dms(le, tagGPSLongitude, 36, 49, 1800)}
+	taken := []field{ascii(tagDateTimeOriginal, "2024:06:01 18:30:15")}
+
+	rotated := tiff(le, []field{short(le, tagOrientation, 6), ascii(0x010F, "Phone Maker")}, taken, gps)
+	upright := tiff(le, []field{short(le, tagOrientation, 1)}, taken, gps)
+
+	tests := map[string]struct {
+		input       []byte
+		want        Metadata
+		wantErr     error
+		wantReadErr error
+		forbidden   []string
+	}{
+		"rotated JPEG with EXIF, XMP, a comment and a trailing frame": {
+			input: withSegments(jpegBuf.Bytes(), []byte("MPF second frame"),
+				segment(0xE1, append([]byte("Exif\x00\x00"), rotated...)),
+				segment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>serial 1234</x:xmpmeta>")),
+				segment(0xFE, []byte("shot at home")),
+			),
+			want:      Metadata{Orientation: 6},
+			forbidden: []string{"Phone Maker", "serial 1234", "shot at home", "MPF second frame", "2024:06:01"},
+		},
+		"upright JPEG keeps no EXIF at all": {
+			input:       withSegments(jpegBuf.Bytes(), nil, segment(0xE1, append([]byte("Exif\x00\x00"), upright...))),
+			wantReadErr: ErrNotFound,
+			forbidden:   []string{"Exif", "2024:06:01"},
+		},
+		"rotated PNG with text and an eXIf chunk after the image data": {
+			input: withChunks(pngBuf.Bytes(),
+				[][]byte{pngChunk("tEXt", []byte("Comment\x00shot at home")), pngChunk("tIME", make([]byte, 7))},
+				[][]byte{pngChunk("eXIf", rotated)},
+			),
+			want:      Metadata{Orientation: 6},
+			forbidden: []string{"Phone Maker", "shot at home", "tIME", "2024:06:01"},
+		},
+		"GIF": {
+			input:   []byte("GIF89a"),
+			wantErr: ErrUnsupported,
+		},
+		"truncated JPEG": {
+			input:   jpegBuf.Bytes()[:jpegBuf.Len()/2],
+			wantErr: ErrMalformed,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := Strip(&out, bytes.NewReader(tc.input))
+
+			if err != tc.wantErr {
+				t.Fatalf("Strip() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			for _, s := range tc.forbidden {
+				if bytes.Contains(out.Bytes(), []byte(s)) {
+					t.Errorf("Strip() output still contains %q", s)
+				}
+			}
+
+			got, err := Read(bytes.NewReader(out.Bytes()))
+
+			if err != tc.wantReadErr {
+				t.Fatalf("Read() of the stripped image error = %v, wantErr %v", err, tc.wantReadErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Read() of the stripped image mismatch (-want +got):\n%s", diff)
+			}
+
+			original, _, err := image.Decode(bytes.NewReader(tc.input))
+
+			if err != nil {
+				t.Fatalf("decoding the original failed: %v", err)
+			}
+
+			stripped, _, err := image.Decode(bytes.NewReader(out.Bytes()))
+
+			if err != nil {
+				t.Fatalf("decoding the stripped image failed: %v", err)
+			}
+
+			if diff := cmp.Diff(original, stripped); diff != "" {
+				t.Errorf("stripped pixels differ (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
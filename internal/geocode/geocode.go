@@ -0,0 +1,225 @@
+package geocode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	earthRadius = 6371000.0
+	// MaxDistance is how far, in meters, a point may be from the nearest
+	// gazetteer entry and still be resolved to it.
+	MaxDistance = 50000.0
+	cellSize    = 1.0
+	maxRings    = 2
+)
+
+var ErrNoPlaceFound = errors.New("no place found near the given coordinates")
+
+type Place struct {
+	Name        string
+	Region      string
+	CountryCode string
+	Lat         float64
+	Lng         float64
+}
+
+type cell struct {
+	x int
+	y int
+}
+
+type Gazetteer struct {
+	cells map[cell][]Place
+	size  int
+}
+
+func New() *Gazetteer {
+	return &Gazetteer{
+		cells: make(map[cell][]Place),
+	}
+}
+
+// Load reads a GeoNames cities file (e.g. cities15000.txt) and, when
+// admin1Path is set, the matching admin1CodesASCII.txt for region names.
+func Load(path string, admin1Path string) (*Gazetteer, error) {
+	regions := make(map[string]string)
+
+	if admin1Path != "" {
+		file, err := os.Open(admin1Path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer file.Close()
+
+		regions, err = ParseAdmin1(file)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return Parse(file, regions)
+}
+
+// ParseAdmin1 reads GeoNames admin1 codes keyed as "<country>.<admin1>".
+func ParseAdmin1(r io.Reader) (map[string]string, error) {
+	regions := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		regions[fields[0]] = fields[1]
+	}
+
+	return regions, scanner.Err()
+}
+
+// Parse reads GeoNames formatted rows from r. Column layout is documented at
+// https://download.geonames.org/export/dump/readme.txt.
+func Parse(r io.Reader, regions map[string]string) (*Gazetteer, error) {
+	g := New()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+
+		if len(fields) < 11 {
+			return nil, fmt.Errorf("gazetteer line %v: expected at least 11 columns got %v", line, len(fields))
+		}
+
+		lat, err := strconv.ParseFloat(fields[4], 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer line %v: %w", line, err)
+		}
+
+		lng, err := strconv.ParseFloat(fields[5], 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer line %v: %w", line, err)
+		}
+
+		countryCode := fields[8]
+		region := fields[10]
+
+		if name, ok := regions[countryCode+"."+fields[10]]; ok {
+			region = name
+		}
+
+		g.Add(Place{
+			Name:        fields[1],
+			Region:      region,
+			CountryCode: countryCode,
+			Lat:         lat,
+			Lng:         lng,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func (g *Gazetteer) Add(place Place) {
+	key := cellFor(place.Lat, place.Lng)
+	g.cells[key] = append(g.cells[key], place)
+	g.size++
+}
+
+func (g *Gazetteer) Len() int {
+	return g.size
+}
+
+// Reverse returns the nearest place to lat/lng within MaxDistance.
+func (g *Gazetteer) Reverse(lat, lng float64) (Place, error) {
+	if g == nil || g.size == 0 {
+		return Place{}, ErrNoPlaceFound
+	}
+
+	origin := cellFor(lat, lng)
+	best := Place{}
+	bestDistance := math.Inf(1)
+
+	for ring := 0; ring <= maxRings; ring++ {
+		for x := origin.x - ring; x <= origin.x+ring; x++ {
+			for y := origin.y - ring; y <= origin.y+ring; y++ {
+				if ring > 0 && x > origin.x-ring && x < origin.x+ring && y > origin.y-ring && y < origin.y+ring {
+					continue
+				}
+
+				for _, place := range g.cells[cell{x: wrapX(x), y: y}] {
+					distance := Haversine(lat, lng, place.Lat, place.Lng)
+
+					if distance < bestDistance {
+						best = place
+						bestDistance = distance
+					}
+				}
+			}
+		}
+	}
+
+	if bestDistance > MaxDistance {
+		return Place{}, ErrNoPlaceFound
+	}
+
+	return best, nil
+}
+
+// Haversine returns the great circle distance between two points in meters.
+func Haversine(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func cellFor(lat, lng float64) cell {
+	return cell{
+		x: wrapX(int(math.Floor(lng / cellSize))),
+		y: int(math.Floor(lat / cellSize)),
+	}
+}
+
+func wrapX(x int) int {
+	cells := int(360 / cellSize)
+	offset := int(180 / cellSize)
+
+	return ((x+offset)%cells+cells)%cells - offset
+}
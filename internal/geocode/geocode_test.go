@@ -0,0 +1,86 @@
+package geocode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const citiesFixture = "2643743\tLondon\tLondon\t\t51.50853\t-0.12574\tP\tPPLC\tGB\t\tENG\tGLA\t\t\t8961989\t\t25\tEurope/London\t2023-01-12\n" +
+	"184745\tNairobi\tNairobi\t\t-1.28333\t36.81667\tP\tPPLC\tKE\t\t05\t\t\t\t2750547\t\t1684\tAfrica/Nairobi\t2023-01-12\n" +
+	"4699066\tFiji Test\tFiji Test\t\t-17.0\t179.9\tP\tPPL\tFJ\t\t01\t\t\t\t1000\t\t1\tPacific/Fiji\t2023-01-12\n"
+
+const admin1Fixture = "GB.ENG\tEngland\tEngland\t6269131\nKE.05\tNairobi Area\tNairobi Area\t184742\n"
+
+func TestReverse(t *testing.T) {
+	regions, err := ParseAdmin1(strings.NewReader(admin1Fixture))
+	if err != nil {
+		t.Fatalf("Error parsing admin1 codes: %v", err)
+	}
+
+	gazetteer, err := Parse(strings.NewReader(citiesFixture), regions)
+	if err != nil {
+		t.Fatalf("Error parsing gazetteer: %v", err)
+	}
+
+	type Want struct {
+		name        string
+		region      string
+		countryCode string
+		found       bool
+	}
+
+	tests := map[string]struct {
+		lat  float64
+		lng  float64
+		want Want
+	}{
+		"Exact city": {
+			lat:  51.50853,
+			lng:  -0.12574,
+			want: Want{name: "London", region: "England", countryCode: "GB", found: true},
+		},
+		"Nearby point in neighbouring cell": {
+			lat:  -0.9,
+			lng:  36.9,
+			want: Want{name: "Nairobi", region: "Nairobi Area", countryCode: "KE", found: true},
+		},
+		"Across the antimeridian": {
+			lat:  -17.0,
+			lng:  -179.9,
+			want: Want{name: "Fiji Test", region: "01", countryCode: "FJ", found: true},
+		},
+		"Middle of the ocean": {
+			lat:  0,
+			lng:  -30,
+			want: Want{found: false},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			place, err := gazetteer.Reverse(tc.lat, tc.lng)
+
+			got := Want{
+				name:        place.Name,
+				region:      place.Region,
+				countryCode: place.CountryCode,
+				found:       err == nil,
+			}
+
+			diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(Want{}))
+			if diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseRejectsShortRows(t *testing.T) {
+	_, err := Parse(strings.NewReader("1\tBroken\t0\n"), nil)
+
+	if err == nil {
+		t.Error("Expected error for malformed gazetteer row")
+	}
+}
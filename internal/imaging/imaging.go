@@ -0,0 +1,169 @@
+package imaging
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// MaxPixels is the most pixels an image may have to be decoded. A small
+// compressed file can declare a huge canvas, and decoding and orienting it
+// allocates four bytes per pixel several times over.
+const MaxPixels = 50_000_000
+
+var (
+	ErrEmptyImage    = errors.New("imaging: image has no pixels")
+	ErrTooManyPixels = errors.New("imaging: image has too many pixels")
+)
+
+// CheckPixels reads only the header of an image and fails when it declares
+// more than MaxPixels. The decoders for the image's format must be
+// registered.
+func CheckPixels(r io.Reader) error {
+	config, _, err := image.DecodeConfig(r)
+
+	if err != nil {
+		return err
+	}
+
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return ErrTooManyPixels
+	}
+
+	return nil
+}
+
+// Decode decodes an image after checking its size with CheckPixels.
+func Decode(r io.ReadSeeker) (image.Image, string, error) {
+	if err := CheckPixels(r); err != nil {
+		return nil, "", err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+
+	return image.Decode(r)
+}
+
+// Fit returns the dimensions of a width by height image scaled down to fit
+// within a square of maxEdge, keeping its aspect ratio. Images that already
+// fit are left at their own size.
+func Fit(width, height, maxEdge int) (int, int) {
+	if width <= maxEdge && height <= maxEdge {
+		return width, height
+	}
+
+	if width >= height {
+		return maxEdge, max(1, (height*maxEdge+width/2)/width)
+	}
+
+	return max(1, (width*maxEdge+height/2)/height), maxEdge
+}
+
+// Resize scales src down to fit within maxEdge by averaging every source
+// pixel that falls inside each destination pixel. It never enlarges.
+func Resize(src image.Image, maxEdge int) (*image.RGBA, error) {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	if srcWidth < 1 || srcHeight < 1 {
+		return nil, ErrEmptyImage
+	}
+
+	// Averaging premultiplied values keeps transparent pixels from bleeding
+	// their colour into opaque neighbours.
+	rgba := image.NewRGBA(image.Rect(0, 0, srcWidth, srcHeight))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	width, height := Fit(srcWidth, srcHeight, maxEdge)
+
+	if width == srcWidth && height == srcHeight {
+		return rgba, nil
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for dy := range height {
+		y0, y1 := dy*srcHeight/height, (dy+1)*srcHeight/height
+		y1 = max(y1, y0+1)
+
+		for dx := range width {
+			x0, x1 := dx*srcWidth/width, (dx+1)*srcWidth/width
+			x1 = max(x1, x0+1)
+
+			var sum [4]uint64
+
+			for y := y0; y < y1; y++ {
+				row := rgba.Pix[y*rgba.Stride+x0*4 : y*rgba.Stride+x1*4]
+
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += uint64(row[i])
+					sum[1] += uint64(row[i+1])
+					sum[2] += uint64(row[i+2])
+					sum[3] += uint64(row[i+3])
+				}
+			}
+
+			count := uint64((x1 - x0) * (y1 - y0))
+			out := dst.Pix[dy*dst.Stride+dx*4:]
+
+			for c := range sum {
+				out[c] = uint8((sum[c] + count/2) / count)
+			}
+		}
+	}
+
+	return dst, nil
+}
+
+// Orient turns src upright according to its EXIF orientation, 1 to 8, so
+// copies that lose the tag still display the right way round.
+func Orient(src image.Image, orientation int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	if orientation < 2 || orientation > 8 {
+		return rgba
+	}
+
+	dstWidth, dstHeight := width, height
+
+	// Orientations 5 to 8 swap the axes.
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := range dstHeight {
+		for x := range dstWidth {
+			var sx, sy int
+
+			switch orientation {
+			case 2:
+				sx, sy = width-1-x, y
+			case 3:
+				sx, sy = width-1-x, height-1-y
+			case 4:
+				sx, sy = x, height-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, height-1-x
+			case 7:
+				sx, sy = width-1-y, height-1-x
+			case 8:
+				sx, sy = width-1-y, x
+			}
+
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], rgba.Pix[sy*rgba.Stride+sx*4:])
+		}
+	}
+
+	return dst
+}
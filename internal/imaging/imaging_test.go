@@ -0,0 +1,167 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFit(t *testing.T) {
+	tests := map[string]struct {
+		width, height, maxEdge int
+		wantWidth, wantHeight  int
+	}{
+		"landscape":         {width: 4000, height: 3000, maxEdge: 800, wantWidth: 800, wantHeight: 600},
+		"portrait":          {width: 3000, height: 4000, maxEdge: 800, wantWidth: 600, wantHeight: 800},
+		"already fits":      {width: 640, height: 480, maxEdge: 800, wantWidth: 640, wantHeight: 480},
+		"thin strip":        {width: 5000, height: 2, maxEdge: 320, wantWidth: 320, wantHeight: 1},
+		"square on the cap": {width: 800, height: 800, maxEdge: 800, wantWidth: 800, wantHeight: 800},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			width, height := Fit(tc.width, tc.height, tc.maxEdge)
+
+			if diff := cmp.Diff([2]int{tc.wantWidth, tc.wantHeight}, [2]int{width, height}); diff != "" {
+				t.Errorf("Fit() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResize(t *testing.T) {
+	checker := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := range 4 {
+		for y := range 2 {
+			if (x+y)%2 == 0 {
+				checker.Set(x, y, color.RGBA{R: 200, G: 100, B: 0, A: 255})
+			} else {
+				checker.Set(x, y, color.RGBA{R: 0, G: 100, B: 200, A: 255})
+			}
+		}
+	}
+
+	got, err := Resize(checker, 2)
+
+	if err != nil {
+		t.Fatalf("Resize() error = %v", err)
+	}
+
+	want := []uint8{100, 100, 100, 255, 100, 100, 100, 255}
+
+	if diff := cmp.Diff(image.Rect(0, 0, 2, 1), got.Bounds()); diff != "" {
+		t.Errorf("Resize() bounds mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(want, got.Pix); diff != "" {
+		t.Errorf("Resize() pixels mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := Resize(image.NewRGBA(image.Rect(0, 0, 0, 0)), 2); !errors.Is(err, ErrEmptyImage) {
+		t.Errorf("Resize() of an empty image error = %v, want %v", err, ErrEmptyImage)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image whose red channel numbers the pixels in reading order:
+	//   1 2 3
+	//   4 5 6
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := range 6 {
+		src.Set(i%3, i/3, color.RGBA{R: uint8(i + 1), A: 255})
+	}
+
+	tests := map[string]struct {
+		orientation int
+		want        [][]uint8
+	}{
+		"upright":               {orientation: 1, want: [][]uint8{{1, 2, 3}, {4, 5, 6}}},
+		"mirrored":              {orientation: 2, want: [][]uint8{{3, 2, 1}, {6, 5, 4}}},
+		"upside down":           {orientation: 3, want: [][]uint8{{6, 5, 4}, {3, 2, 1}}},
+		"flipped":               {orientation: 4, want: [][]uint8{{4, 5, 6}, {1, 2, 3}}},
+		"transposed":            {orientation: 5, want: [][]uint8{{1, 4}, {2, 5}, {3, 6}}},
+		"rotated clockwise":     {orientation: 6, want: [][]uint8{{4, 1}, {5, 2}, {6, 3}}},
+		"transversed":           {orientation: 7, want: [][]uint8{{6, 3}, {5, 2}, {4, 1}}},
+		"rotated anticlockwise": {orientation: 8, want: [][]uint8{{3, 6}, {2, 5}, {1, 4}}},
+		"unknown value":         {orientation: 9, want: [][]uint8{{1, 2, 3}, {4, 5, 6}}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Orient(src, tc.orientation)
+
+			var rows [][]uint8
+			for y := range got.Bounds().Dy() {
+				var row []uint8
+				for x := range got.Bounds().Dx() {
+					row = append(row, got.RGBAAt(x, y).R)
+				}
+				rows = append(rows, row)
+			}
+
+			if diff := cmp.Diff(tc.want, rows); diff != "" {
+				t.Errorf("Orient() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func flat(width, height int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// pngHeader returns a 1x1 PNG whose header claims width by height pixels,
+// which is all CheckPixels reads.
+func pngHeader(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	if err := png.Encode(&buf, flat(1, 1, color.NRGBA{A: 255})); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	data := buf.Bytes()
+
+	// IHDR follows the 8 byte signature, its length and its type.
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	return data
+}
+
+func TestCheckPixels(t *testing.T) {
+	tests := map[string]struct {
+		width, height uint32
+		wantErr       error
+	}{
+		"phone photo":        {width: 4032, height: 3024},
+		"on the limit":       {width: 10000, height: 5000},
+		"decompression bomb": {width: 20000, height: 20000, wantErr: ErrTooManyPixels},
+		"one long edge":      {width: 1 << 30, height: 1, wantErr: ErrTooManyPixels},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := CheckPixels(bytes.NewReader(pngHeader(t, tc.width, tc.height)))
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("CheckPixels() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
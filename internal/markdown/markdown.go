@@ -0,0 +1,702 @@
+// Package markdown renders journal entries to HTML.
+//
+// The renderer builds its output rather than cleaning up someone else's:
+// source text only ever reaches the page through html.EscapeString, and the
+// handful of tags and attributes below are written by this package alone.
+// That keeps the safety argument small enough to check here, without a
+// general purpose Markdown library and a separate HTML sanitiser in the
+// dependency tree. FuzzRender holds it to that, parsing the output like a
+// browser would and failing on any other tag, attribute or URL scheme.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Render converts a CommonMark subset to HTML. Raw HTML in the source is
+// always escaped and only the tags emitted here can appear in the output,
+// so the result is safe to embed without further sanitising. Links and
+// images are dropped to plain text unless their URL is http, https, mailto
+// or relative.
+//
+// Supported blocks are paragraphs, ATX headings, fenced code, blockquotes,
+// ordered and unordered lists and thematic breaks. Supported inlines are
+// emphasis, strong, strikethrough, code spans, links, images, autolinks and
+// hard line breaks. Markup nested more than maxNesting levels deep is
+// rendered as text.
+func Render(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\r", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+
+	out := &strings.Builder{}
+	renderBlocks(out, strings.Split(source, "\n"), 0)
+
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// maxNesting bounds how deeply lists, blockquotes, emphasis and links may
+// nest. Deeper markup is rendered as text, which keeps rendering time close
+// to linear in the size of the source.
+const maxNesting = 16
+
+// maxLinkParens is the most parentheses a link destination may nest,
+// the same limit CommonMark implementations use.
+const maxLinkParens = 32
+
+var (
+	headingPattern     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ ]+(.*?))?(?:[ ]+#+)?[ ]*$`)
+	fencePattern       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ ]*([^`\\s]*)")
+	thematicPattern    = regexp.MustCompile(`^ {0,3}(?:(?:\*[ ]*){3,}|(?:-[ ]*){3,}|(?:_[ ]*){3,})$`)
+	bulletPattern      = regexp.MustCompile(`^( {0,3})([-*+])( +|$)`)
+	orderedPattern     = regexp.MustCompile(`^( {0,3})(\d{1,9})([.)])( +|$)`)
+	blockquotePattern  = regexp.MustCompile(`^ {0,3}> ?`)
+	languagePattern    = regexp.MustCompile(`^[A-Za-z0-9_+-]+$`)
+	autolinkPattern    = regexp.MustCompile(`^<((?:https?://|mailto:)[^\s<>]+)>`)
+	linkDestinationEnd = regexp.MustCompile(`^\s*(?:"([^"]*)"|'([^']*)')?\s*\)`)
+)
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// listMarker reports whether line starts a list item, whether the list is
+// ordered, the bullet or start number and the item's content indent.
+func listMarker(line string) (ok bool, ordered bool, start string, indent int) {
+	if thematicPattern.MatchString(line) {
+		return false, false, "", 0
+	}
+
+	if m := bulletPattern.FindStringSubmatch(line); m != nil {
+		return true, false, m[2], len(m[0])
+	}
+
+	if m := orderedPattern.FindStringSubmatch(line); m != nil {
+		return true, true, m[2], len(m[0])
+	}
+
+	return false, false, "", 0
+}
+
+func startsBlock(line string) bool {
+	if headingPattern.MatchString(line) || fencePattern.MatchString(line) ||
+		thematicPattern.MatchString(line) || blockquotePattern.MatchString(line) {
+		return true
+	}
+
+	ok, _, _, _ := listMarker(line)
+
+	return ok
+}
+
+func renderBlocks(out *strings.Builder, lines []string, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case isBlank(line):
+			i++
+
+		case fencePattern.MatchString(line):
+			i = renderFence(out, lines, i)
+
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			level := string(rune('0' + len(m[1])))
+			out.WriteString("<h" + level + ">" + renderInline(m[2], 0) + "</h" + level + ">\n")
+			i++
+
+		case thematicPattern.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+
+		case depth < maxNesting && blockquotePattern.MatchString(line):
+			var quoted []string
+
+			for i < len(lines) && !isBlank(lines[i]) {
+				if loc := blockquotePattern.FindStringIndex(lines[i]); loc != nil {
+					quoted = append(quoted, lines[i][loc[1]:])
+				} else {
+					quoted = append(quoted, lines[i])
+				}
+				i++
+			}
+
+			out.WriteString("<blockquote>\n")
+			renderBlocks(out, quoted, depth+1)
+			out.WriteString("</blockquote>\n")
+
+		default:
+			if ok, _, _, _ := listMarker(line); ok && depth < maxNesting {
+				i = renderList(out, lines, i, depth)
+				continue
+			}
+
+			start := i
+			for i < len(lines) && !isBlank(lines[i]) && (i == start || !startsBlock(lines[i])) {
+				i++
+			}
+
+			out.WriteString("<p>" + renderParagraph(lines[start:i]) + "</p>\n")
+		}
+	}
+}
+
+func renderFence(out *strings.Builder, lines []string, i int) int {
+	m := fencePattern.FindStringSubmatch(lines[i])
+	fence := m[1]
+	language := m[2]
+
+	var code []string
+	i++
+
+	for i < len(lines) {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+
+		code = append(code, lines[i])
+		i++
+	}
+
+	out.WriteString("<pre><code")
+	if language != "" && languagePattern.MatchString(language) {
+		out.WriteString(` class="language-` + language + `"`)
+	}
+	out.WriteString(">")
+
+	for _, line := range code {
+		out.WriteString(html.EscapeString(line) + "\n")
+	}
+
+	out.WriteString("</code></pre>\n")
+
+	return i
+}
+
+func renderList(out *strings.Builder, lines []string, i int, depth int) int {
+	_, ordered, start, _ := listMarker(lines[i])
+	bullet := start
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+
+	out.WriteString("<" + tag)
+	if ordered && strings.TrimLeft(start, "0") != "1" {
+		number := strings.TrimLeft(start, "0")
+		if number == "" {
+			number = "0"
+		}
+		out.WriteString(` start="` + number + `"`)
+	}
+	out.WriteString(">\n")
+
+	for i < len(lines) {
+		ok, itemOrdered, marker, indent := listMarker(lines[i])
+
+		// Changing the bullet character starts a new list.
+		if !ok || itemOrdered != ordered || (!ordered && marker != bullet) {
+			break
+		}
+
+		item := []string{lines[i][indent:]}
+		i++
+
+		for i < len(lines) {
+			line := lines[i]
+
+			if isBlank(line) {
+				// A blank line only continues the item when the next line
+				// is indented under it.
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) >= indent && !isBlank(lines[i+1]) {
+					item = append(item, "")
+					i++
+					continue
+				}
+
+				break
+			}
+
+			if leadingSpaces(line) >= indent {
+				item = append(item, line[indent:])
+				i++
+				continue
+			}
+
+			if startsBlock(line) {
+				break
+			}
+
+			// Lazy continuation of the item's paragraph.
+			item = append(item, line)
+			i++
+		}
+
+		for i < len(lines) && isBlank(lines[i]) {
+			if i+1 < len(lines) {
+				if next, nextOrdered, marker, _ := listMarker(lines[i+1]); next && nextOrdered == ordered && (ordered || marker == bullet) {
+					i++
+					continue
+				}
+			}
+
+			break
+		}
+
+		out.WriteString("<li>")
+		out.WriteString(renderListItem(item, depth+1))
+		out.WriteString("</li>\n")
+	}
+
+	out.WriteString("</" + tag + ">\n")
+
+	return i
+}
+
+// renderListItem renders an item's blocks, unwrapping a leading paragraph so
+// simple lists stay tight.
+func renderListItem(item []string, depth int) string {
+	inner := &strings.Builder{}
+	renderBlocks(inner, item, depth)
+	rendered := strings.TrimSuffix(inner.String(), "\n")
+
+	if strings.HasPrefix(rendered, "<p>") {
+		end := strings.Index(rendered, "</p>")
+		rendered = rendered[3:end] + rendered[end+4:]
+	}
+
+	return rendered
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func renderParagraph(lines []string) string {
+	out := &strings.Builder{}
+
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " ")
+		last := i == len(lines)-1
+
+		hardBreak := false
+		if !last && (strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\")) {
+			hardBreak = true
+			line = strings.TrimSuffix(strings.TrimRight(line, " "), "\\")
+		} else {
+			line = strings.TrimRight(line, " ")
+		}
+
+		out.WriteString(renderInline(line, 0))
+
+		if hardBreak {
+			out.WriteString("<br>\n")
+		} else if !last {
+			out.WriteString("\n")
+		}
+	}
+
+	return out.String()
+}
+
+// inlineScan remembers what closer searches over one text have found, so an
+// opener without a closer does not make every later opener rescan the rest
+// of the text.
+type inlineScan struct {
+	// brackets maps each '[' to the ']' that closes it.
+	brackets map[int]int
+	// unclosed holds, for each delimiter run, the first position a search
+	// for its closer failed from. Searches from later positions fail too.
+	unclosed map[string]int
+}
+
+func newInlineScan(text string) *inlineScan {
+	scan := &inlineScan{
+		brackets: map[int]int{},
+		unclosed: map[string]int{},
+	}
+
+	var open []int
+
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			open = append(open, i)
+		case ']':
+			if len(open) > 0 {
+				scan.brackets[open[len(open)-1]] = i
+				open = open[:len(open)-1]
+			}
+		}
+	}
+
+	return scan
+}
+
+// closed reports whether a closer for delimiter may still follow position i.
+func (scan *inlineScan) closed(delimiter string, i int) bool {
+	from, ok := scan.unclosed[delimiter]
+
+	return !ok || i < from
+}
+
+func (scan *inlineScan) markUnclosed(delimiter string, i int) {
+	if from, ok := scan.unclosed[delimiter]; !ok || i < from {
+		scan.unclosed[delimiter] = i
+	}
+}
+
+func renderInline(text string, depth int) string {
+	out := &strings.Builder{}
+	scan := newInlineScan(text)
+
+	for i := 0; i < len(text); {
+		c := text[i]
+
+		switch {
+		case c == '\\' && i+1 < len(text) && isASCIIPunct(text[i+1]):
+			out.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+
+		case c == '`':
+			if n, code, ok := scan.codeSpan(text, i); ok {
+				out.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += n
+				continue
+			}
+
+			run := delimiterRun(text[i:], '`')
+			out.WriteString(text[i : i+run])
+			i += run
+
+		case c == '!' && strings.HasPrefix(text[i+1:], "[") && depth < maxNesting:
+			if n, label, destination, title, ok := scan.link(text, i+1); ok {
+				if safeURL(destination) {
+					out.WriteString(`<img src="` + html.EscapeString(destination) + `" alt="` + html.EscapeString(plainText(label)) + `"`)
+					if title != "" {
+						out.WriteString(` title="` + html.EscapeString(title) + `"`)
+					}
+					out.WriteString(">")
+				} else {
+					out.WriteString(html.EscapeString(plainText(label)))
+				}
+				i += n + 1
+				continue
+			}
+
+			out.WriteString("!")
+			i++
+
+		case c == '[' && depth < maxNesting:
+			if n, label, destination, title, ok := scan.link(text, i); ok {
+				if safeURL(destination) {
+					out.WriteString(`<a href="` + html.EscapeString(destination) + `"`)
+					if title != "" {
+						out.WriteString(` title="` + html.EscapeString(title) + `"`)
+					}
+					out.WriteString(` rel="nofollow noopener noreferrer">` + renderInline(label, depth+1) + "</a>")
+				} else {
+					out.WriteString(renderInline(label, depth+1))
+				}
+				i += n
+				continue
+			}
+
+			out.WriteString("[")
+			i++
+
+		case c == '<':
+			if m := autolinkPattern.FindStringSubmatch(text[i:]); m != nil && safeURL(m[1]) {
+				out.WriteString(`<a href="` + html.EscapeString(m[1]) + `" rel="nofollow noopener noreferrer">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+				continue
+			}
+
+			out.WriteString("&lt;")
+			i++
+
+		case (c == '*' || c == '_' || c == '~') && depth < maxNesting:
+			if n, rendered, ok := scan.emphasis(text, i, depth); ok {
+				out.WriteString(rendered)
+				i += n
+				continue
+			}
+
+			run := delimiterRun(text[i:], c)
+			out.WriteString(text[i : i+run])
+			i += run
+
+		default:
+			_, size := utf8.DecodeRuneInString(text[i:])
+			out.WriteString(html.EscapeString(text[i : i+size]))
+			i += size
+		}
+	}
+
+	return out.String()
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+func delimiterRun(text string, c byte) int {
+	n := 0
+	for n < len(text) && text[n] == c {
+		n++
+	}
+
+	return n
+}
+
+// codeSpan matches a backtick string with the closing run of the same
+// length and returns the consumed length and the code.
+func codeSpan(text string) (int, string, bool) {
+	run := delimiterRun(text, '`')
+
+	for i := run; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+
+		closing := delimiterRun(text[i:], '`')
+
+		if closing == run {
+			code := strings.ReplaceAll(text[run:i], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+
+			return i + closing, code, true
+		}
+
+		i += closing
+	}
+
+	return 0, "", false
+}
+
+// codeSpan matches a code span starting at text[i].
+func (scan *inlineScan) codeSpan(text string, i int) (int, string, bool) {
+	delimiter := text[i : i+delimiterRun(text[i:], '`')]
+
+	if !scan.closed(delimiter, i) {
+		return 0, "", false
+	}
+
+	n, code, ok := codeSpan(text[i:])
+
+	if !ok {
+		scan.markUnclosed(delimiter, i)
+	}
+
+	return n, code, ok
+}
+
+// link parses [label](destination "title") starting at text[start].
+func (scan *inlineScan) link(text string, start int) (n int, label string, destination string, title string, ok bool) {
+	end, ok := scan.brackets[start]
+
+	if !ok {
+		return 0, "", "", "", false
+	}
+
+	text = text[start:]
+	closeLabel := end - start
+
+	if closeLabel+1 >= len(text) || text[closeLabel+1] != '(' {
+		return 0, "", "", "", false
+	}
+
+	label = text[1:closeLabel]
+	rest := text[closeLabel+2:]
+	trimmed := strings.TrimLeft(rest, " ")
+	consumed := closeLabel + 2 + len(rest) - len(trimmed)
+
+	if strings.HasPrefix(trimmed, "<") {
+		if !scan.closed("<", start) {
+			return 0, "", "", "", false
+		}
+
+		end := strings.IndexAny(trimmed, ">\n")
+		if end < 0 {
+			scan.markUnclosed("<", start)
+		}
+
+		if end < 0 || trimmed[end] != '>' {
+			return 0, "", "", "", false
+		}
+
+		destination = trimmed[1:end]
+		consumed += end + 1
+		trimmed = trimmed[end+1:]
+	} else {
+		// Parentheses are allowed in the destination when balanced.
+		end := -1
+		parens := 0
+
+		for j, r := range trimmed {
+			if unicode.IsSpace(r) || (r == ')' && parens == 0) {
+				end = j
+				break
+			}
+
+			switch r {
+			case '(':
+				parens++
+				if parens > maxLinkParens {
+					return 0, "", "", "", false
+				}
+			case ')':
+				parens--
+			}
+		}
+
+		if end < 0 {
+			return 0, "", "", "", false
+		}
+
+		destination = trimmed[:end]
+		consumed += end
+		trimmed = trimmed[end:]
+	}
+
+	m := linkDestinationEnd.FindStringSubmatch(trimmed)
+
+	if m == nil {
+		return 0, "", "", "", false
+	}
+
+	title = m[1] + m[2]
+
+	return consumed + len(m[0]), label, destination, title, true
+}
+
+// emphasis matches *em*, **strong**, _em_, __strong__ and ~~del~~ starting
+// at text[i] and returns the consumed length and rendered HTML.
+func (scan *inlineScan) emphasis(text string, i int, depth int) (int, string, bool) {
+	c := text[i]
+	run := delimiterRun(text[i:], c)
+
+	if c == '~' && run != 2 {
+		return 0, "", false
+	}
+
+	if run > 3 {
+		return 0, "", false
+	}
+
+	// An opener must be followed by a non-space, and intraword underscores
+	// are literal.
+	if i+run >= len(text) || text[i+run] == ' ' {
+		return 0, "", false
+	}
+
+	if c == '_' && i > 0 && isWordByte(text[i-1]) {
+		return 0, "", false
+	}
+
+	delimiter := text[i : i+run]
+
+	if !scan.closed(delimiter, i) {
+		return 0, "", false
+	}
+
+	for j := i + run; j < len(text); j++ {
+		if text[j] == '\\' {
+			j++
+			continue
+		}
+
+		if text[j] == '`' {
+			if n, _, ok := scan.codeSpan(text, j); ok {
+				j += n - 1
+				continue
+			}
+		}
+
+		if !strings.HasPrefix(text[j:], delimiter) {
+			continue
+		}
+
+		// The closer must follow a non-space and be exactly as long as the
+		// opener. Either way the whole run is skipped.
+		if closing := delimiterRun(text[j:], c); text[j-1] == ' ' || closing != run {
+			j += closing - 1
+			continue
+		}
+
+		if c == '_' && j+run < len(text) && isWordByte(text[j+run]) {
+			continue
+		}
+
+		inner := renderInline(text[i+run:j], depth+1)
+
+		var rendered string
+		switch {
+		case c == '~':
+			rendered = "<del>" + inner + "</del>"
+		case run == 1:
+			rendered = "<em>" + inner + "</em>"
+		case run == 2:
+			rendered = "<strong>" + inner + "</strong>"
+		default:
+			rendered = "<em><strong>" + inner + "</strong></em>"
+		}
+
+		return j + run - i, rendered, true
+	}
+
+	scan.markUnclosed(delimiter, i)
+
+	return 0, "", false
+}
+
+func isWordByte(c byte) bool {
+	return c >= utf8.RuneSelf || c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// plainText strips inline markup from an image label for its alt text.
+func plainText(label string) string {
+	replacer := strings.NewReplacer("*", "", "_", "", "`", "", "~~", "", "[", "", "]", "")
+
+	return replacer.Replace(label)
+}
+
+// safeURL allows http, https and mailto URLs along with relative ones.
+func safeURL(raw string) bool {
+	if raw == "" {
+		return false
+	}
+
+	for _, r := range raw {
+		if unicode.IsControl(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	u, err := url.Parse(raw)
+
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return !strings.Contains(strings.ToLower(raw), "javascript:")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,260 @@
+package markdown
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestRender(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"Paragraphs": {
+			input: "First day in Nairobi.\nWent to the park.\n\nSecond paragraph.",
+			want:  "<p>First day in Nairobi.\nWent to the park.</p>\n<p>Second paragraph.</p>",
+		},
+		"Headings": {
+			input: "# Day one\n### Morning ###",
+			want:  "<h1>Day one</h1>\n<h3>Morning</h3>",
+		},
+		"Emphasis and strong": {
+			input: "A *long* **hot** ***dusty*** ~~wet~~ day",
+			want:  "<p>A <em>long</em> <strong>hot</strong> <em><strong>dusty</strong></em> <del>wet</del> day</p>",
+		},
+		"Intraword underscores stay literal": {
+			input: "snake_case_name",
+			want:  "<p>snake_case_name</p>",
+		},
+		"Unclosed delimiters stay literal": {
+			input: "2 * 3 and **open",
+			want:  "<p>2 * 3 and **open</p>",
+		},
+		"Code span escapes": {
+			input: "Run `<b>&</b>` now",
+			want:  "<p>Run <code>&lt;b&gt;&amp;&lt;/b&gt;</code> now</p>",
+		},
+		"Fenced code": {
+			input: "```go\nfmt.Println(\"<hi>\")\n```",
+			want:  "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;hi&gt;&#34;)\n</code></pre>",
+		},
+		"Links": {
+			input: "See [the *map*](https://example.com/map \"Map\")",
+			want:  "<p>See <a href=\"https://example.com/map\" title=\"Map\" rel=\"nofollow noopener noreferrer\">the <em>map</em></a></p>",
+		},
+		"Autolinks": {
+			input: "<https://example.com>",
+			want:  "<p><a href=\"https://example.com\" rel=\"nofollow noopener noreferrer\">https://example.com</a></p>",
+		},
+		"Images": {
+			input: "![Sunset *view*](/assets/sunset.jpg)",
+			want:  "<p><img src=\"/assets/sunset.jpg\" alt=\"Sunset view\"></p>",
+		},
+		"Unordered list": {
+			input: "- tent\n- stove\n  with fuel\n* new list",
+			want:  "<ul>\n<li>tent</li>\n<li>stove\nwith fuel</li>\n</ul>\n<ul>\n<li>new list</li>\n</ul>",
+		},
+		"Ordered list with start": {
+			input: "3. pack\n4. drive",
+			want:  "<ol start=\"3\">\n<li>pack</li>\n<li>drive</li>\n</ol>",
+		},
+		"Nested list": {
+			input: "- day one\n  - hike\n  - swim\n- day two",
+			want:  "<ul>\n<li>day one\n<ul>\n<li>hike</li>\n<li>swim</li>\n</ul></li>\n<li>day two</li>\n</ul>",
+		},
+		"Blockquote": {
+			input: "> Not all who wander\n> are lost",
+			want:  "<blockquote>\n<p>Not all who wander\nare lost</p>\n</blockquote>",
+		},
+		"Thematic break": {
+			input: "before\n\n---\n\nafter",
+			want:  "<p>before</p>\n<hr>\n<p>after</p>",
+		},
+		"Hard line break": {
+			input: "line one  \nline two\\\nline three",
+			want:  "<p>line one<br>\nline two<br>\nline three</p>",
+		},
+		"Backslash escapes": {
+			input: "\\*not em\\*",
+			want:  "<p>*not em*</p>",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Render(tc.input)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderSanitises(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"Raw HTML is escaped": {
+			input: "<script>alert(1)</script>",
+			want:  "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>",
+		},
+		"Inline event handlers are escaped": {
+			input: "<img src=x onerror=alert(1)>",
+			want:  "<p>&lt;img src=x onerror=alert(1)&gt;</p>",
+		},
+		"Javascript links are dropped": {
+			input: "[click](javascript:alert(1))",
+			want:  "<p>click</p>",
+		},
+		"Mixed case schemes are dropped": {
+			input: "[click](JaVaScRiPt:alert(1))",
+			want:  "<p>click</p>",
+		},
+		"Data images are dropped": {
+			input: "![pixel](data:image/png;base64,AAAA)",
+			want:  "<p>pixel</p>",
+		},
+		"Attribute quotes are escaped": {
+			input: "[x](https://example.com/\"onmouseover=\"alert(1))",
+			want:  "<p><a href=\"https://example.com/&#34;onmouseover=&#34;alert(1)\" rel=\"nofollow noopener noreferrer\">x</a></p>",
+		},
+		"Brackets inside link labels": {
+			input: "[day [one] photos](https://example.com) and [unclosed",
+			want:  "<p><a href=\"https://example.com\" rel=\"nofollow noopener noreferrer\">day [one] photos</a> and [unclosed</p>",
+		},
+		"Closers after an unclosed opener": {
+			input: "*a _b_ **c**",
+			want:  "<p>*a <em>b</em> <strong>c</strong></p>",
+		},
+		"Fence language is restricted": {
+			input: "```\"><script>\nx\n```",
+			want:  "<pre><code>x\n</code></pre>",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Render(tc.input)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestRenderPathological renders inputs that made earlier versions of the
+// renderer scan the rest of the text from every character.
+func TestRenderPathological(t *testing.T) {
+	const size = 100000
+
+	repeat := func(s string) string {
+		return strings.Repeat(s, size/len(s))
+	}
+
+	tests := map[string]string{
+		"Nested list markers":    repeat("- ") + "x",
+		"Nested blockquotes":     repeat("> ") + "x",
+		"Unclosed emphasis":      repeat("*a "),
+		"Unclosed underscores":   repeat("_a "),
+		"Nested emphasis":        repeat("*a _a ") + repeat("a_ a* "),
+		"Unclosed brackets":      repeat("["),
+		"Nested brackets":        repeat("[") + repeat("](x)"),
+		"Unclosed destinations":  repeat("[a]("),
+		"Unclosed angle links":   repeat("[a](<"),
+		"Unmatched code spans":   repeat("``a`"),
+		"Unclosed strikethrough": repeat("~~a "),
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			Render(input)
+
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Render() took %v for %d bytes", elapsed, len(input))
+			}
+		})
+	}
+}
+
+// renderedAttributes lists every tag Render emits with the attributes it
+// may carry.
+var renderedAttributes = map[string][]string{
+	"p": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"pre": nil, "code": {"class"}, "blockquote": nil, "ul": nil, "ol": {"start"},
+	"li": nil, "hr": nil, "br": nil, "em": nil, "strong": nil, "del": nil,
+	"a":   {"href", "title", "rel"},
+	"img": {"src", "alt", "title"},
+}
+
+// checkRendered parses out the way a browser would and fails on any tag,
+// attribute or URL scheme Render is not meant to produce.
+func checkRendered(t *testing.T, source, out string) {
+	t.Helper()
+
+	tokenizer := html.NewTokenizer(strings.NewReader(out))
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			token := tokenizer.Token()
+			allowed, ok := renderedAttributes[token.Data]
+
+			if !ok {
+				t.Fatalf("Render(%q) emitted <%v>:\n%s", source, token.Data, out)
+			}
+
+			for _, attr := range token.Attr {
+				if !slices.Contains(allowed, attr.Key) {
+					t.Fatalf("Render(%q) emitted %v on <%v>:\n%s", source, attr.Key, token.Data, out)
+				}
+
+				if (attr.Key == "href" || attr.Key == "src") && !safeURL(attr.Val) {
+					t.Fatalf("Render(%q) emitted unsafe %v %q:\n%s", source, attr.Key, attr.Val, out)
+				}
+			}
+		}
+	}
+}
+
+// FuzzRender checks that whatever the source, the output only holds the
+// markup Render emits itself. Run it with go test -fuzz=FuzzRender.
+func FuzzRender(f *testing.F) {
+	for _, seed := range []string{
+		"# Day one\n\n*Nairobi* and **Mombasa** with `code` and ~~plans~~.",
+		"- one\n  - two\n> quote\n\n1. first\n2) second",
+		"```go\nfmt.Println(\"<b>\")\n```",
+		"<script>alert(1)</script>",
+		"<img src=x onerror=alert(1)>",
+		"[click](javascript:alert(1)) [x](JaVaScRiPt:alert(1))",
+		"![pixel](data:image/png;base64,AAAA)",
+		"[x](https://example.com/\"onmouseover=\"alert(1))",
+		"[x](<java\nscript:alert(1)> \"t\") <https://example.com> <mailto:a@b.c>",
+		"[a [b] c](/relative 'title') and [unclosed",
+		"*a _b_ **c** ***d*** _e*",
+		"```\"><script>\nx\n```",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		start := time.Now()
+		out := Render(source)
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("Render(%q) took %v", source, elapsed)
+		}
+
+		checkRendered(t, source, out)
+	})
+}
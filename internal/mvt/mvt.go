@@ -0,0 +1,179 @@
+// Package mvt edits Mapbox vector tiles produced by PostGIS without decoding
+// their geometry.
+package mvt
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var ErrMalformed = errors.New("mvt: malformed tile")
+
+// Protobuf field numbers from the vector tile specification.
+const (
+	tileLayers        = 3
+	layerName         = 1
+	layerValues       = 4
+	valueStringValue  = 1
+	wireVarint        = 0
+	wireFixed64       = 1
+	wireLengthDelimit = 2
+	wireFixed32       = 5
+)
+
+// field is one protobuf field: raw holds it as encoded, tag included, and
+// payload the contents of a length delimited field.
+type field struct {
+	number   uint64
+	wireType uint64
+	raw      []byte
+	payload  []byte
+}
+
+func readField(data []byte) (field, error) {
+	tag, n := binary.Uvarint(data)
+
+	if n <= 0 {
+		return field{}, ErrMalformed
+	}
+
+	f := field{number: tag >> 3, wireType: tag & 7}
+	end := n
+
+	switch f.wireType {
+	case wireVarint:
+		_, m := binary.Uvarint(data[n:])
+
+		if m <= 0 {
+			return field{}, ErrMalformed
+		}
+
+		end += m
+	case wireFixed64:
+		end += 8
+	case wireFixed32:
+		end += 4
+	case wireLengthDelimit:
+		length, m := binary.Uvarint(data[n:])
+
+		if m <= 0 || length > uint64(len(data)-n-m) {
+			return field{}, ErrMalformed
+		}
+
+		end += m + int(length)
+		f.payload = data[n+m : end]
+	default:
+		return field{}, ErrMalformed
+	}
+
+	if end > len(data) {
+		return field{}, ErrMalformed
+	}
+
+	f.raw = data[:end]
+
+	return f, nil
+}
+
+// eachField calls fn for every field in a message.
+func eachField(data []byte, fn func(field) error) error {
+	for len(data) > 0 {
+		f, err := readField(data)
+
+		if err != nil {
+			return err
+		}
+
+		if err := fn(f); err != nil {
+			return err
+		}
+
+		data = data[len(f.raw):]
+	}
+
+	return nil
+}
+
+func appendBytes(out []byte, number uint64, payload []byte) []byte {
+	out = binary.AppendUvarint(out, number<<3|wireLengthDelimit)
+	out = binary.AppendUvarint(out, uint64(len(payload)))
+	return append(out, payload...)
+}
+
+// RewriteStrings returns a copy of tile in which every string value of the
+// named layers is replaced by rewrite. Everything else is copied as is.
+// Tiles made by joining several ST_AsMVT results are handled too, since
+// each is a list of layers.
+func RewriteStrings(tile []byte, layer string, rewrite func(string) string) ([]byte, error) {
+	out := make([]byte, 0, len(tile))
+
+	err := eachField(tile, func(f field) error {
+		if f.number != tileLayers || f.wireType != wireLengthDelimit {
+			out = append(out, f.raw...)
+			return nil
+		}
+
+		rewritten, err := rewriteLayer(f.payload, layer, rewrite)
+
+		if err != nil {
+			return err
+		}
+
+		out = appendBytes(out, tileLayers, rewritten)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func rewriteLayer(data []byte, layer string, rewrite func(string) string) ([]byte, error) {
+	name := ""
+
+	err := eachField(data, func(f field) error {
+		if f.number == layerName && f.wireType == wireLengthDelimit {
+			name = string(f.payload)
+		}
+
+		return nil
+	})
+
+	if err != nil || name != layer {
+		return data, err
+	}
+
+	out := make([]byte, 0, len(data))
+
+	err = eachField(data, func(f field) error {
+		if f.number != layerValues || f.wireType != wireLengthDelimit {
+			out = append(out, f.raw...)
+			return nil
+		}
+
+		value := make([]byte, 0, len(f.payload))
+
+		err := eachField(f.payload, func(v field) error {
+			if v.number == valueStringValue && v.wireType == wireLengthDelimit {
+				value = appendBytes(value, valueStringValue, []byte(rewrite(string(v.payload))))
+			} else {
+				value = append(value, v.raw...)
+			}
+
+			return nil
+		})
+
+		out = appendBytes(out, layerValues, value)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,148 @@
+package mvt
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func appendVarint(out []byte, number, value uint64) []byte {
+	out = binary.AppendUvarint(out, number<<3|wireVarint)
+	return binary.AppendUvarint(out, value)
+}
+
+// testLayer encodes a layer with one key, a feature and the given values.
+func testLayer(name string, values ...[]byte) []byte {
+	layer := appendVarint(nil, 15, 2)
+	layer = appendBytes(layer, layerName, []byte(name))
+	layer = appendBytes(layer, 2, appendVarint(nil, 3, 1))
+	layer = appendBytes(layer, 3, []byte("photo_url"))
+
+	for _, value := range values {
+		layer = appendBytes(layer, layerValues, value)
+	}
+
+	return appendVarint(layer, 5, 4096)
+}
+
+func stringValue(s string) []byte {
+	return appendBytes(nil, valueStringValue, []byte(s))
+}
+
+func intValue(i uint64) []byte {
+	return appendVarint(nil, 4, i)
+}
+
+// layerStrings lists the string values of every layer, by layer name.
+func layerStrings(t *testing.T, tile []byte) map[string][]string {
+	t.Helper()
+
+	got := map[string][]string{}
+
+	err := eachField(tile, func(f field) error {
+		name := ""
+		var values []string
+
+		err := eachField(f.payload, func(l field) error {
+			switch l.number {
+			case layerName:
+				name = string(l.payload)
+			case layerValues:
+				return eachField(l.payload, func(v field) error {
+					if v.number == valueStringValue {
+						values = append(values, string(v.payload))
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+
+		got[name] = append(got[name], values...)
+
+		return err
+	})
+
+	if err != nil {
+		t.Fatalf("could not read tile: %v", err)
+	}
+
+	return got
+}
+
+func TestRewriteStrings(t *testing.T) {
+	media := testLayer("media", stringValue("/assets/a.jpg"), intValue(3), stringValue("/assets/b.png"))
+	stops := testLayer("stops", stringValue("/assets/a.jpg"))
+
+	tests := map[string]struct {
+		tile []byte
+		want map[string][]string
+	}{
+		"Only the named layer": {
+			tile: appendBytes(appendBytes(nil, tileLayers, stops), tileLayers, media),
+			want: map[string][]string{
+				"stops": {"/assets/a.jpg"},
+				"media": {"/ASSETS/A.JPG", "/ASSETS/B.PNG"},
+			},
+		},
+		"Layer missing": {
+			tile: appendBytes(nil, tileLayers, stops),
+			want: map[string][]string{
+				"stops": {"/assets/a.jpg"},
+			},
+		},
+		"Empty tile": {
+			tile: nil,
+			want: map[string][]string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tile, err := RewriteStrings(tc.tile, "media", strings.ToUpper)
+
+			if err != nil {
+				t.Fatalf("RewriteStrings() error = %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, layerStrings(t, tile)); diff != "" {
+				t.Errorf("RewriteStrings() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRewriteStringsKeepsOtherFields(t *testing.T) {
+	tile := appendBytes(nil, tileLayers, testLayer("media", intValue(7)))
+
+	got, err := RewriteStrings(tile, "media", strings.ToUpper)
+
+	if err != nil {
+		t.Fatalf("RewriteStrings() error = %v", err)
+	}
+
+	if diff := cmp.Diff(tile, got); diff != "" {
+		t.Errorf("RewriteStrings() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRewriteStringsMalformed(t *testing.T) {
+	tests := map[string][]byte{
+		"Truncated length":  {tileLayers<<3 | wireLengthDelimit, 10, 1},
+		"Truncated varint":  {1<<3 | wireVarint, 0x80},
+		"Unknown wire type": {1<<3 | 3},
+	}
+
+	for name, tile := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := RewriteStrings(tile, "media", strings.ToUpper)
+
+			if !errors.Is(err, ErrMalformed) {
+				t.Errorf("RewriteStrings() error = %v, want %v", err, ErrMalformed)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local keeps objects as files under a root directory.
+type Local struct {
+	root    string
+	baseURL string
+}
+
+// NewLocal stores objects under root, creating it when missing. Presigned
+// URLs are baseURL joined with the key, so baseURL should point at wherever
+// root is served from.
+func NewLocal(root, baseURL string) (*Local, error) {
+	if err := os.MkdirAll(root, 0750); err != nil {
+		return nil, err
+	}
+
+	return &Local{root: root, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	key, err := CleanKey(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file next to the object and renames it into
+// place, so readers never see a partly written file.
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	filePath, err := l.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), ".put-*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tempFile.Name())
+
+	written, err := io.Copy(tempFile, r)
+
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if size >= 0 && written != size {
+		return fmt.Errorf("storage: wrote %d bytes of %v, expected %d", written, key, size)
+	}
+
+	return os.Rename(tempFile.Name(), filePath)
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	filePath, err := l.path(key)
+
+	if err != nil {
+		return nil, Object{}, err
+	}
+
+	file, err := os.Open(filePath)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Object{}, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, Object{}, err
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		file.Close()
+		return nil, Object{}, err
+	}
+
+	if info.IsDir() {
+		file.Close()
+		return nil, Object{}, ErrNotFound
+	}
+
+	return file, l.object(key, info), nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	filePath, err := l.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (Object, error) {
+	filePath, err := l.path(key)
+
+	if err != nil {
+		return Object{}, err
+	}
+
+	info, err := os.Stat(filePath)
+
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return Object{}, ErrNotFound
+	}
+
+	if err != nil {
+		return Object{}, err
+	}
+
+	return l.object(key, info), nil
+}
+
+// Presign returns the public URL of the object. Local files are served as
+// they are, so the URL does not expire.
+func (l *Local) Presign(ctx context.Context, key string, expires time.Duration) (string, error) {
+	key, err := CleanKey(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	return l.baseURL + "/" + key, nil
+}
+
+func (l *Local) object(key string, info fs.FileInfo) Object {
+	cleaned, _ := CleanKey(key)
+
+	return Object{
+		Key:         cleaned,
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(cleaned)),
+		ModTime:     info.ModTime(),
+		ETag:        fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+	}
+}
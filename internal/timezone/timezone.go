@@ -0,0 +1,212 @@
+package timezone
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+	_ "time/tzdata"
+)
+
+type ring [][2]float64
+
+type polygon struct {
+	outer ring
+	holes []ring
+}
+
+type zone struct {
+	tzid     string
+	polygons []polygon
+	minLng   float64
+	minLat   float64
+	maxLng   float64
+	maxLat   float64
+}
+
+// Finder resolves coordinates to IANA timezone names using the boundaries
+// published by timezone-boundary-builder.
+type Finder struct {
+	zones []zone
+}
+
+type featureCollection struct {
+	Features []struct {
+		Properties struct {
+			TZID string `json:"tzid"`
+		} `json:"properties"`
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+func New() *Finder {
+	return &Finder{}
+}
+
+func Load(path string) (*Finder, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// Parse reads a GeoJSON FeatureCollection whose features carry a tzid
+// property and a Polygon or MultiPolygon geometry.
+func Parse(r io.Reader) (*Finder, error) {
+	collection := featureCollection{}
+
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, err
+	}
+
+	finder := New()
+
+	for _, feature := range collection.Features {
+		if feature.Properties.TZID == "" {
+			continue
+		}
+
+		if _, err := time.LoadLocation(feature.Properties.TZID); err != nil {
+			return nil, fmt.Errorf("unknown timezone %v: %w", feature.Properties.TZID, err)
+		}
+
+		var rings [][][][2]float64
+
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var coordinates [][][2]float64
+
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &coordinates); err != nil {
+				return nil, err
+			}
+
+			rings = append(rings, coordinates)
+		case "MultiPolygon":
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &rings); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported geometry %v for %v", feature.Geometry.Type, feature.Properties.TZID)
+		}
+
+		z := zone{
+			tzid:   feature.Properties.TZID,
+			minLng: math.Inf(1),
+			minLat: math.Inf(1),
+			maxLng: math.Inf(-1),
+			maxLat: math.Inf(-1),
+		}
+
+		for _, polygonRings := range rings {
+			if len(polygonRings) == 0 {
+				continue
+			}
+
+			p := polygon{outer: polygonRings[0]}
+
+			for _, hole := range polygonRings[1:] {
+				p.holes = append(p.holes, hole)
+			}
+
+			for _, point := range p.outer {
+				z.minLng = math.Min(z.minLng, point[0])
+				z.maxLng = math.Max(z.maxLng, point[0])
+				z.minLat = math.Min(z.minLat, point[1])
+				z.maxLat = math.Max(z.maxLat, point[1])
+			}
+
+			z.polygons = append(z.polygons, p)
+		}
+
+		finder.zones = append(finder.zones, z)
+	}
+
+	return finder, nil
+}
+
+// Lookup returns the IANA timezone containing lat/lng. Points outside every
+// boundary, such as at sea, fall back to the nautical Etc/GMT zone.
+func (f *Finder) Lookup(lat, lng float64) string {
+	if f != nil {
+		for _, z := range f.zones {
+			if lng < z.minLng || lng > z.maxLng || lat < z.minLat || lat > z.maxLat {
+				continue
+			}
+
+			for _, p := range z.polygons {
+				if p.contains(lat, lng) {
+					return z.tzid
+				}
+			}
+		}
+	}
+
+	return nauticalZone(lng)
+}
+
+// Location is Lookup resolved to a *time.Location.
+func (f *Finder) Location(lat, lng float64) (*time.Location, error) {
+	tzid := f.Lookup(lat, lng)
+
+	location, err := time.LoadLocation(tzid)
+
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to load timezone %v", tzid), err)
+	}
+
+	return location, nil
+}
+
+func (p polygon) contains(lat, lng float64) bool {
+	if !p.outer.contains(lat, lng) {
+		return false
+	}
+
+	for _, hole := range p.holes {
+		if hole.contains(lat, lng) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r ring) contains(lat, lng float64) bool {
+	inside := false
+
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+func nauticalZone(lng float64) string {
+	offset := int(math.Round(lng / 15))
+
+	switch {
+	case offset == 0:
+		return "Etc/GMT"
+	case offset > 0:
+		// Etc zones use POSIX signs, so east of Greenwich is negative.
+		return fmt.Sprintf("Etc/GMT-%d", offset)
+	default:
+		return fmt.Sprintf("Etc/GMT+%d", -offset)
+	}
+}
@@ -0,0 +1,94 @@
+package timezone
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const boundariesFixture = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "properties": {"tzid": "Africa/Nairobi"},
+      "geometry": {
+        "type": "Polygon",
+        "coordinates": [
+          [[34.0, -4.7], [41.9, -4.7], [41.9, 5.0], [34.0, 5.0], [34.0, -4.7]],
+          [[36.0, -1.0], [37.0, -1.0], [37.0, 0.0], [36.0, 0.0], [36.0, -1.0]]
+        ]
+      }
+    },
+    {
+      "type": "Feature",
+      "properties": {"tzid": "Europe/London"},
+      "geometry": {
+        "type": "MultiPolygon",
+        "coordinates": [
+          [[[-6.0, 50.0], [2.0, 50.0], [2.0, 56.0], [-6.0, 56.0], [-6.0, 50.0]]],
+          [[[-8.0, 57.0], [-6.0, 57.0], [-6.0, 59.0], [-8.0, 59.0], [-8.0, 57.0]]]
+        ]
+      }
+    }
+  ]
+}`
+
+func TestLookup(t *testing.T) {
+	finder, err := Parse(strings.NewReader(boundariesFixture))
+	if err != nil {
+		t.Fatalf("Error parsing boundaries: %v", err)
+	}
+
+	tests := map[string]struct {
+		lat  float64
+		lng  float64
+		want string
+	}{
+		"Inside polygon": {
+			lat:  -3.0,
+			lng:  39.0,
+			want: "Africa/Nairobi",
+		},
+		"Inside hole falls back to nautical zone": {
+			lat:  -0.5,
+			lng:  36.5,
+			want: "Etc/GMT-2",
+		},
+		"Second part of multipolygon": {
+			lat:  58.0,
+			lng:  -7.0,
+			want: "Europe/London",
+		},
+		"Open sea west of Greenwich": {
+			lat:  30.0,
+			lng:  -45.0,
+			want: "Etc/GMT+3",
+		},
+		"Open sea at Greenwich": {
+			lat:  -40.0,
+			lng:  3.0,
+			want: "Etc/GMT",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := finder.Lookup(tc.lat, tc.lng)
+
+			diff := cmp.Diff(tc.want, got)
+			if diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnknownZone(t *testing.T) {
+	_, err := Parse(strings.NewReader(`{"features":[{"properties":{"tzid":"Mars/Olympus"},"geometry":{"type":"Polygon","coordinates":[]}}]}`))
+
+	if err == nil {
+		t.Error("Expected error for unknown timezone")
+	}
+}
@@ -0,0 +1,718 @@
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// Formats a probed container can be reported as.
+const (
+	FormatMP4  = "mp4"
+	FormatMOV  = "mov"
+	FormatWebM = "webm"
+)
+
+var (
+	ErrUnsupported = errors.New("video: unsupported container")
+	ErrMalformed   = errors.New("video: malformed container")
+)
+
+// Info is the metadata read from a container's headers. Width and height are
+// those of the first video track and are zero when the file has none.
+type Info struct {
+	Format   string
+	Duration time.Duration
+	Width    int
+	Height   int
+}
+
+// Probe reads the container headers of an MP4, QuickTime or WebM file. It
+// only walks the box and element headers, seeking past media data, so large
+// files are never read into memory.
+func Probe(r io.ReadSeeker) (Info, error) {
+	head := make([]byte, 12)
+
+	if _, err := io.ReadFull(r, head); err != nil {
+		return Info{}, ErrUnsupported
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Info{}, err
+	}
+
+	if bytes.Equal(head[:4], []byte{0x1A, 0x45, 0xDF, 0xA3}) {
+		return probeWebM(r)
+	}
+
+	switch string(head[4:8]) {
+	case "ftyp", "moov", "mdat", "wide", "free", "skip":
+		return probeISO(r)
+	}
+
+	return Info{}, ErrUnsupported
+}
+
+// ISO base media (MP4) and QuickTime share the same box structure.
+
+type box struct {
+	kind  string
+	start int64
+	end   int64
+}
+
+func readBox(r io.ReadSeeker, limit int64) (box, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return box{}, err
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return box{}, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	kind := string(header[4:8])
+
+	switch size {
+	case 0:
+		size = limit - start
+	case 1:
+		large := make([]byte, 8)
+		if _, err := io.ReadFull(r, large); err != nil {
+			return box{}, ErrMalformed
+		}
+
+		largeSize := binary.BigEndian.Uint64(large)
+		if largeSize > math.MaxInt64 {
+			return box{}, ErrMalformed
+		}
+
+		size = int64(largeSize)
+	}
+
+	if size < 8 || start+size > limit {
+		return box{}, ErrMalformed
+	}
+
+	return box{kind: kind, start: start, end: start + size}, nil
+}
+
+func probeISO(r io.ReadSeeker) (Info, error) {
+	limit, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Info{}, err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Info{}, err
+	}
+
+	info := Info{Format: FormatMOV}
+	foundMovie := false
+
+	for offset := int64(0); offset < limit; {
+		b, err := readBox(r, limit)
+		if err != nil {
+			return Info{}, ErrMalformed
+		}
+
+		switch b.kind {
+		case "ftyp":
+			brand := make([]byte, 4)
+			if _, err := io.ReadFull(r, brand); err != nil {
+				return Info{}, ErrMalformed
+			}
+
+			if string(brand) != "qt  " {
+				info.Format = FormatMP4
+			}
+		case "moov":
+			if err := parseMovie(r, b, &info); err != nil {
+				return Info{}, err
+			}
+
+			foundMovie = true
+		}
+
+		offset = b.end
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return Info{}, err
+		}
+	}
+
+	if !foundMovie {
+		return Info{}, ErrMalformed
+	}
+
+	return info, nil
+}
+
+func parseMovie(r io.ReadSeeker, moov box, info *Info) error {
+	for offset := moov.start + 8; offset < moov.end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		b, err := readBox(r, moov.end)
+		if err != nil {
+			return ErrMalformed
+		}
+
+		switch b.kind {
+		case "mvhd":
+			duration, err := parseMovieHeader(r)
+			if err != nil {
+				return err
+			}
+
+			info.Duration = duration
+		case "trak":
+			width, height, isVideo, err := parseTrack(r, b)
+			if err != nil {
+				return err
+			}
+
+			if isVideo && info.Width == 0 && info.Height == 0 {
+				info.Width, info.Height = width, height
+			}
+		}
+
+		offset = b.end
+	}
+
+	return nil
+}
+
+func parseMovieHeader(r io.Reader) (time.Duration, error) {
+	var version [4]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return 0, ErrMalformed
+	}
+
+	var timescale, duration uint64
+
+	if version[0] == 1 {
+		fields := make([]byte, 28)
+		if _, err := io.ReadFull(r, fields); err != nil {
+			return 0, ErrMalformed
+		}
+
+		timescale = uint64(binary.BigEndian.Uint32(fields[16:20]))
+		duration = binary.BigEndian.Uint64(fields[20:28])
+	} else {
+		fields := make([]byte, 16)
+		if _, err := io.ReadFull(r, fields); err != nil {
+			return 0, ErrMalformed
+		}
+
+		timescale = uint64(binary.BigEndian.Uint32(fields[8:12]))
+		duration = uint64(binary.BigEndian.Uint32(fields[12:16]))
+	}
+
+	if timescale == 0 {
+		return 0, ErrMalformed
+	}
+
+	return secondsToDuration(float64(duration) / float64(timescale)), nil
+}
+
+func parseTrack(r io.ReadSeeker, trak box) (width, height int, isVideo bool, err error) {
+	for offset := trak.start + 8; offset < trak.end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return 0, 0, false, err
+		}
+
+		b, err := readBox(r, trak.end)
+		if err != nil {
+			return 0, 0, false, ErrMalformed
+		}
+
+		switch b.kind {
+		case "tkhd":
+			width, height, err = parseTrackHeader(r)
+			if err != nil {
+				return 0, 0, false, err
+			}
+		case "mdia":
+			isVideo, err = isVideoMedia(r, b)
+			if err != nil {
+				return 0, 0, false, err
+			}
+		}
+
+		offset = b.end
+	}
+
+	return width, height, isVideo, nil
+}
+
+func parseTrackHeader(r io.Reader) (int, int, error) {
+	var version [4]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return 0, 0, ErrMalformed
+	}
+
+	// Skip the timestamps, track ID and duration, whose widths depend on the
+	// version, then the fixed layer, volume and matrix fields.
+	skip := 20
+	if version[0] == 1 {
+		skip = 32
+	}
+	skip += 8 + 2 + 2 + 2 + 2 + 36
+
+	fields := make([]byte, skip+8)
+	if _, err := io.ReadFull(r, fields); err != nil {
+		return 0, 0, ErrMalformed
+	}
+
+	width := binary.BigEndian.Uint32(fields[skip : skip+4])
+	height := binary.BigEndian.Uint32(fields[skip+4 : skip+8])
+
+	// Dimensions are 16.16 fixed point.
+	return int(width >> 16), int(height >> 16), nil
+}
+
+func isVideoMedia(r io.ReadSeeker, mdia box) (bool, error) {
+	for offset := mdia.start + 8; offset < mdia.end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return false, err
+		}
+
+		b, err := readBox(r, mdia.end)
+		if err != nil {
+			return false, ErrMalformed
+		}
+
+		if b.kind == "hdlr" {
+			fields := make([]byte, 12)
+			if _, err := io.ReadFull(r, fields); err != nil {
+				return false, ErrMalformed
+			}
+
+			return string(fields[8:12]) == "vide", nil
+		}
+
+		offset = b.end
+	}
+
+	return false, nil
+}
+
+// WebM is a Matroska subset built from EBML elements.
+
+const (
+	ebmlHeaderID    = 0x1A45DFA3
+	ebmlDocTypeID   = 0x4282
+	segmentID       = 0x18538067
+	infoID          = 0x1549A966
+	timecodeScaleID = 0x2AD7B1
+	durationID      = 0x4489
+	tracksID        = 0x1654AE6B
+	trackEntryID    = 0xAE
+	trackTypeID     = 0x83
+	trackVideoID    = 0xE0
+	pixelWidthID    = 0xB0
+	pixelHeightID   = 0xBA
+	clusterID       = 0x1F43B675
+
+	trackTypeVideo = 1
+
+	// unknownSize marks an element whose size was left open by a live
+	// encoder.
+	unknownSize = -1
+)
+
+type element struct {
+	id   uint64
+	size int64
+}
+
+func readVint(r io.Reader, keepMarker bool) (uint64, int, error) {
+	var first [1]byte
+	if _, err := io.ReadFull(r, first[:]); err != nil {
+		return 0, 0, err
+	}
+
+	length := 1
+	for mask := byte(0x80); length <= 8 && first[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+
+	if length > 8 {
+		return 0, 0, ErrMalformed
+	}
+
+	value := uint64(first[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+
+	rest := make([]byte, length-1)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return 0, 0, ErrMalformed
+	}
+
+	for _, b := range rest {
+		value = value<<8 | uint64(b)
+	}
+
+	return value, length, nil
+}
+
+func readElement(r io.Reader) (element, error) {
+	id, idLength, err := readVint(r, true)
+	if err != nil {
+		return element{}, err
+	}
+
+	if idLength > 4 {
+		return element{}, ErrMalformed
+	}
+
+	size, sizeLength, err := readVint(r, false)
+	if err != nil {
+		return element{}, ErrMalformed
+	}
+
+	if size == 1<<(7*sizeLength)-1 {
+		return element{id: id, size: unknownSize}, nil
+	}
+
+	if size > math.MaxInt64 {
+		return element{}, ErrMalformed
+	}
+
+	return element{id: id, size: int64(size)}, nil
+}
+
+func readPayload(r io.Reader, e element, max int64) ([]byte, error) {
+	if e.size == unknownSize || e.size > max {
+		return nil, ErrMalformed
+	}
+
+	payload := make([]byte, e.size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, ErrMalformed
+	}
+
+	return payload, nil
+}
+
+func readUint(r io.Reader, e element) (uint64, error) {
+	payload, err := readPayload(r, e, 8)
+	if err != nil {
+		return 0, err
+	}
+
+	var value uint64
+	for _, b := range payload {
+		value = value<<8 | uint64(b)
+	}
+
+	return value, nil
+}
+
+func readFloat(r io.Reader, e element) (float64, error) {
+	payload, err := readPayload(r, e, 8)
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(payload) {
+	case 0:
+		return 0, nil
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(payload))), nil
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), nil
+	}
+
+	return 0, ErrMalformed
+}
+
+func skipElement(r io.Seeker, e element) error {
+	if e.size == unknownSize {
+		return ErrMalformed
+	}
+
+	_, err := r.Seek(e.size, io.SeekCurrent)
+	return err
+}
+
+func probeWebM(r io.ReadSeeker) (Info, error) {
+	header, err := readElement(r)
+	if err != nil || header.id != ebmlHeaderID {
+		return Info{}, ErrMalformed
+	}
+
+	headerEnd, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Info{}, err
+	}
+	headerEnd += header.size
+
+	docType := ""
+	for {
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return Info{}, err
+		}
+
+		if offset >= headerEnd {
+			break
+		}
+
+		e, err := readElement(r)
+		if err != nil {
+			return Info{}, ErrMalformed
+		}
+
+		if e.id == ebmlDocTypeID {
+			payload, err := readPayload(r, e, 64)
+			if err != nil {
+				return Info{}, err
+			}
+
+			docType = string(bytes.TrimRight(payload, "\x00"))
+			continue
+		}
+
+		if err := skipElement(r, e); err != nil {
+			return Info{}, ErrMalformed
+		}
+	}
+
+	if docType != FormatWebM {
+		return Info{}, ErrUnsupported
+	}
+
+	segment, err := readElement(r)
+	if err != nil || segment.id != segmentID {
+		return Info{}, ErrMalformed
+	}
+
+	info := Info{Format: FormatWebM}
+	timecodeScale := uint64(1000000)
+	var duration float64
+	foundInfo := false
+
+	for {
+		e, err := readElement(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return Info{}, ErrMalformed
+		}
+
+		switch e.id {
+		case infoID:
+			scale, length, err := parseSegmentInfo(r, e)
+			if err != nil {
+				return Info{}, err
+			}
+
+			timecodeScale, duration = scale, length
+			foundInfo = true
+		case tracksID:
+			if err := parseTracks(r, e, &info); err != nil {
+				return Info{}, err
+			}
+		case clusterID:
+			// Headers come before the first cluster, so there is no need to
+			// walk the media data.
+			if !foundInfo {
+				return Info{}, ErrMalformed
+			}
+
+			info.Duration = secondsToDuration(duration * float64(timecodeScale) / float64(time.Second))
+			return info, nil
+		default:
+			if err := skipElement(r, e); err != nil {
+				return Info{}, ErrMalformed
+			}
+		}
+	}
+
+	if !foundInfo {
+		return Info{}, ErrMalformed
+	}
+
+	info.Duration = secondsToDuration(duration * float64(timecodeScale) / float64(time.Second))
+	return info, nil
+}
+
+func parseSegmentInfo(r io.ReadSeeker, info element) (uint64, float64, error) {
+	if info.size == unknownSize {
+		return 0, 0, ErrMalformed
+	}
+
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	timecodeScale := uint64(1000000)
+	var duration float64
+
+	for offset := start; offset < start+info.size; {
+		e, err := readElement(r)
+		if err != nil {
+			return 0, 0, ErrMalformed
+		}
+
+		switch e.id {
+		case timecodeScaleID:
+			if timecodeScale, err = readUint(r, e); err != nil {
+				return 0, 0, err
+			}
+		case durationID:
+			if duration, err = readFloat(r, e); err != nil {
+				return 0, 0, err
+			}
+		default:
+			if err := skipElement(r, e); err != nil {
+				return 0, 0, ErrMalformed
+			}
+		}
+
+		if offset, err = r.Seek(0, io.SeekCurrent); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return timecodeScale, duration, nil
+}
+
+func parseTracks(r io.ReadSeeker, tracks element, info *Info) error {
+	if tracks.size == unknownSize {
+		return ErrMalformed
+	}
+
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	for offset := start; offset < start+tracks.size; {
+		e, err := readElement(r)
+		if err != nil {
+			return ErrMalformed
+		}
+
+		if e.id == trackEntryID && info.Width == 0 && info.Height == 0 {
+			if err := parseTrackEntry(r, e, info); err != nil {
+				return err
+			}
+		} else if err := skipElement(r, e); err != nil {
+			return ErrMalformed
+		}
+
+		if offset, err = r.Seek(0, io.SeekCurrent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseTrackEntry(r io.ReadSeeker, entry element, info *Info) error {
+	if entry.size == unknownSize {
+		return ErrMalformed
+	}
+
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	var trackType uint64
+	var width, height uint64
+
+	for offset := start; offset < start+entry.size; {
+		e, err := readElement(r)
+		if err != nil {
+			return ErrMalformed
+		}
+
+		switch e.id {
+		case trackTypeID:
+			if trackType, err = readUint(r, e); err != nil {
+				return err
+			}
+		case trackVideoID:
+			if width, height, err = parseVideoSettings(r, e); err != nil {
+				return err
+			}
+		default:
+			if err := skipElement(r, e); err != nil {
+				return ErrMalformed
+			}
+		}
+
+		if offset, err = r.Seek(0, io.SeekCurrent); err != nil {
+			return err
+		}
+	}
+
+	if trackType == trackTypeVideo {
+		info.Width, info.Height = int(width), int(height)
+	}
+
+	return nil
+}
+
+func parseVideoSettings(r io.ReadSeeker, settings element) (uint64, uint64, error) {
+	if settings.size == unknownSize {
+		return 0, 0, ErrMalformed
+	}
+
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var width, height uint64
+
+	for offset := start; offset < start+settings.size; {
+		e, err := readElement(r)
+		if err != nil {
+			return 0, 0, ErrMalformed
+		}
+
+		switch e.id {
+		case pixelWidthID:
+			if width, err = readUint(r, e); err != nil {
+				return 0, 0, err
+			}
+		case pixelHeightID:
+			if height, err = readUint(r, e); err != nil {
+				return 0, 0, err
+			}
+		default:
+			if err := skipElement(r, e); err != nil {
+				return 0, 0, ErrMalformed
+			}
+		}
+
+		if offset, err = r.Seek(0, io.SeekCurrent); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return width, height, nil
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds < 0 || math.IsNaN(seconds) || seconds > math.MaxInt64/float64(time.Second) {
+		return 0
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
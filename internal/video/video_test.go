@@ -0,0 +1,132 @@
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func isoBox(kind string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(out, uint32(8+len(body)))
+	copy(out[4:], kind)
+	return append(out, body...)
+}
+
+func u32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func movieHeader(timescale, duration uint32) []byte {
+	return isoBox("mvhd", u32(0), u32(0), u32(0), u32(timescale), u32(duration), make([]byte, 80))
+}
+
+func trackHeader(width, height uint32) []byte {
+	return isoBox("tkhd", u32(0), make([]byte, 20), make([]byte, 52), u32(width<<16), u32(height<<16))
+}
+
+func handler(kind string) []byte {
+	return isoBox("mdia", isoBox("hdlr", u32(0), u32(0), []byte(kind), make([]byte, 12)))
+}
+
+func isoFile(brand string, moovFirst bool) []byte {
+	moov := isoBox("moov",
+		movieHeader(600, 4500),
+		isoBox("trak", trackHeader(0, 0), handler("soun")),
+		isoBox("trak", trackHeader(1920, 1080), handler("vide")),
+	)
+	mdat := isoBox("mdat", make([]byte, 64))
+	ftyp := isoBox("ftyp", []byte(brand), u32(0))
+
+	if moovFirst {
+		return bytes.Join([][]byte{ftyp, moov, mdat}, nil)
+	}
+	return bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+}
+
+func ebml(id []byte, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := append([]byte{}, id...)
+	// An 8 byte size keeps the builder simple for any payload length.
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(body)))
+	size[0] = 0x01
+	return append(append(out, size...), body...)
+}
+
+func webmFile(docType string) []byte {
+	header := ebml([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebml([]byte{0x42, 0x82}, []byte(docType)))
+	info := ebml([]byte{0x15, 0x49, 0xA9, 0x66},
+		ebml([]byte{0x2A, 0xD7, 0xB1}, []byte{0x0F, 0x42, 0x40}),
+		ebml([]byte{0x44, 0x89}, binary.BigEndian.AppendUint64(nil, math.Float64bits(12500))),
+	)
+	tracks := ebml([]byte{0x16, 0x54, 0xAE, 0x6B},
+		ebml([]byte{0xAE}, ebml([]byte{0x83}, []byte{2})),
+		ebml([]byte{0xAE},
+			ebml([]byte{0x83}, []byte{1}),
+			ebml([]byte{0xE0}, ebml([]byte{0xB0}, []byte{0x05, 0x00}), ebml([]byte{0xBA}, []byte{0x02, 0xD0})),
+		),
+	)
+	cluster := append([]byte{0x1F, 0x43, 0xB6, 0x75, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, make([]byte, 32)...)
+	segment := append([]byte{0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, bytes.Join([][]byte{info, tracks, cluster}, nil)...)
+	return append(header, segment...)
+}
+
+func TestProbe(t *testing.T) {
+	tests := map[string]struct {
+		input   []byte
+		want    Info
+		wantErr error
+	}{
+		"MP4 with moov before mdat": {
+			input: isoFile("isom", true),
+			want:  Info{Format: FormatMP4, Duration: 7500 * time.Millisecond, Width: 1920, Height: 1080},
+		},
+		"MP4 with moov at the end": {
+			input: isoFile("mp42", false),
+			want:  Info{Format: FormatMP4, Duration: 7500 * time.Millisecond, Width: 1920, Height: 1080},
+		},
+		"QuickTime": {
+			input: isoFile("qt  ", true),
+			want:  Info{Format: FormatMOV, Duration: 7500 * time.Millisecond, Width: 1920, Height: 1080},
+		},
+		"WebM": {
+			input: webmFile("webm"),
+			want:  Info{Format: FormatWebM, Duration: 12500 * time.Millisecond, Width: 1280, Height: 720},
+		},
+		"Matroska is not WebM": {
+			input:   webmFile("matroska"),
+			wantErr: ErrUnsupported,
+		},
+		"PNG image": {
+			input:   []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+			wantErr: ErrUnsupported,
+		},
+		"Truncated MP4": {
+			input:   isoFile("isom", true)[:40],
+			wantErr: ErrMalformed,
+		},
+		"MP4 without a movie box": {
+			input:   isoBox("ftyp", []byte("isom"), u32(0)),
+			wantErr: ErrMalformed,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Probe(bytes.NewReader(tc.input))
+
+			if err != tc.wantErr {
+				t.Fatalf("Probe() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Probe() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
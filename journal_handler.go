@@ -0,0 +1,617 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/markdown"
+	"github.com/mambo-dev/adventrak-backend/internal/utils"
+)
+
+const journalDateLayout = "2006-01-02"
+
+var (
+	errJournalMediaNotInTrip = errors.New("media does not belong to the trip")
+	errJournalStopNotInTrip  = errors.New("stop does not belong to the trip")
+)
+
+type JournalEntryParams struct {
+	TripStopID *uuid.UUID      `json:"tripStopId,omitempty"`
+	Title      string          `json:"title" validate:"max=200"`
+	Body       string          `json:"body" validate:"required,max=20000"`
+	EntryDate  string          `json:"entryDate" validate:"required,datetime=2006-01-02"`
+	Location   *utils.Location `json:"location,omitempty"`
+	MediaIDs   []uuid.UUID     `json:"mediaIds" validate:"max=50,unique"`
+}
+
+type JournalMediaResponse struct {
+	ID       uuid.UUID      `json:"id"`
+	PhotoUrl sql.NullString `json:"photoUrl"`
+	VideoUrl sql.NullString `json:"videoUrl"`
+}
+
+type JournalEntryResponse struct {
+	ID           uuid.UUID              `json:"id"`
+	TripID       uuid.UUID              `json:"tripId"`
+	TripStopID   uuid.NullUUID          `json:"tripStopId"`
+	Title        sql.NullString         `json:"title"`
+	Body         string                 `json:"body"`
+	BodyHTML     string                 `json:"bodyHtml"`
+	EntryDate    string                 `json:"entryDate"`
+	LocationName sql.NullString         `json:"locationName"`
+	Lat          interface{}            `json:"lat"`
+	Lng          interface{}            `json:"lng"`
+	Media        []JournalMediaResponse `json:"media"`
+	CreatedAt    time.Time              `json:"createdAt"`
+	UpdatedAt    time.Time              `json:"updatedAt"`
+}
+
+func convertToJournalEntryResponse(entry *database.GetJournalEntryRow, entries *database.GetJournalEntriesRow) JournalEntryResponse {
+	if entry != nil {
+		return JournalEntryResponse{
+			ID:           entry.ID,
+			TripID:       entry.TripID,
+			TripStopID:   entry.TripStopID,
+			Title:        entry.Title,
+			Body:         entry.Body,
+			BodyHTML:     entry.BodyHtml,
+			EntryDate:    entry.EntryDate.Format(journalDateLayout),
+			LocationName: entry.LocationName,
+			Lat:          entry.Lat,
+			Lng:          entry.Lng,
+			Media:        make([]JournalMediaResponse, 0),
+			CreatedAt:    entry.CreatedAt,
+			UpdatedAt:    entry.UpdatedAt,
+		}
+	}
+
+	return JournalEntryResponse{
+		ID:           entries.ID,
+		TripID:       entries.TripID,
+		TripStopID:   entries.TripStopID,
+		Title:        entries.Title,
+		Body:         entries.Body,
+		BodyHTML:     entries.BodyHtml,
+		EntryDate:    entries.EntryDate.Format(journalDateLayout),
+		LocationName: entries.LocationName,
+		Lat:          entries.Lat,
+		Lng:          entries.Lng,
+		Media:        make([]JournalMediaResponse, 0),
+		CreatedAt:    entries.CreatedAt,
+		UpdatedAt:    entries.UpdatedAt,
+	}
+}
+
+// getJournalMedia returns the linked media of each entry in link order.
+func (cfg apiConfig) getJournalMedia(ctx context.Context, entryIDs []uuid.UUID) (map[uuid.UUID][]JournalMediaResponse, error) {
+	rows, err := cfg.db.GetJournalEntryMedia(ctx, entryIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	media := make(map[uuid.UUID][]JournalMediaResponse, len(entryIDs))
+
+	for _, row := range rows {
+		media[row.JournalEntryID] = append(media[row.JournalEntryID], JournalMediaResponse{
+			ID:       row.ID,
+			PhotoUrl: cfg.signMediaNullURL(row.PhotoUrl),
+			VideoUrl: cfg.signMediaNullURL(row.VideoUrl),
+		})
+	}
+
+	return media, nil
+}
+
+// checkJournalMedia makes sure every linked media item was taken on the trip
+// or one of its stops.
+func (cfg apiConfig) checkJournalMedia(ctx context.Context, mediaIDs []uuid.UUID, tripID uuid.UUID) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	count, err := cfg.db.CountTripMedia(ctx, database.CountTripMediaParams{
+		MediaIds: mediaIDs,
+		TripID:   tripID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if count != int64(len(mediaIDs)) {
+		return errJournalMediaNotInTrip
+	}
+
+	return nil
+}
+
+// journalEntryLocation resolves the optional entry location into the
+// nullable name and point stored on the entry.
+func (cfg apiConfig) journalEntryLocation(loc *utils.Location) (sql.NullString, interface{}, error) {
+	if loc == nil {
+		return sql.NullString{}, nil, nil
+	}
+
+	location, err := cfg.resolveLocation(*loc)
+
+	if err != nil {
+		return sql.NullString{}, nil, err
+	}
+
+	return sql.NullString{String: location.Name, Valid: true}, utils.FormatPoint(location.Location), nil
+}
+
+func (params JournalEntryParams) tripStopID() uuid.NullUUID {
+	if params.TripStopID == nil {
+		return uuid.NullUUID{}
+	}
+
+	return uuid.NullUUID{UUID: *params.TripStopID, Valid: true}
+}
+
+func (params JournalEntryParams) title() sql.NullString {
+	return sql.NullString{String: params.Title, Valid: params.Title != ""}
+}
+
+func (cfg apiConfig) handlerGetJournalEntries(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	var stopID uuid.NullUUID
+
+	if stopParam := r.URL.Query().Get("stopID"); stopParam != "" {
+		stopUUID, err := uuid.Parse(stopParam)
+
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid stopID sent through query params", err, false)
+			return
+		}
+
+		stopID = uuid.NullUUID{UUID: stopUUID, Valid: true}
+	}
+
+	entries, err := cfg.db.GetJournalEntries(r.Context(), database.GetJournalEntriesParams{
+		TripID:     trip.ID,
+		UserID:     user.ID,
+		TripStopID: stopID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trip journal", err, false)
+		return
+	}
+
+	entryIDs := make([]uuid.UUID, 0, len(entries))
+	for _, entry := range entries {
+		entryIDs = append(entryIDs, entry.ID)
+	}
+
+	media, err := cfg.getJournalMedia(r.Context(), entryIDs)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return journal media", err, false)
+		return
+	}
+
+	entriesResponse := make([]JournalEntryResponse, 0, len(entries))
+
+	for _, entry := range entries {
+		entryResponse := convertToJournalEntryResponse(nil, &entry)
+
+		if entryMedia, ok := media[entry.ID]; ok {
+			entryResponse.Media = entryMedia
+		}
+
+		entriesResponse = append(entriesResponse, entryResponse)
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   entriesResponse,
+	})
+}
+
+func (cfg apiConfig) handlerGetJournalEntry(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	entryUUID, err := uuid.Parse(chi.URLParam(r, "entryID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	entry, err := cfg.db.GetJournalEntry(r.Context(), database.GetJournalEntryParams{
+		ID:     entryUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find journal entry possibly deleted", err, false)
+		return
+	}
+
+	media, err := cfg.getJournalMedia(r.Context(), []uuid.UUID{entry.ID})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return journal media", err, false)
+		return
+	}
+
+	entryResponse := convertToJournalEntryResponse(&entry, nil)
+
+	if entryMedia, ok := media[entry.ID]; ok {
+		entryResponse.Media = entryMedia
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   entryResponse,
+	})
+}
+
+func (cfg apiConfig) handlerCreateJournalEntry(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	params := &JournalEntryParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read journal entry", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	entryDate, err := time.Parse(journalDateLayout, params.EntryDate)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid journal entry date", err, false)
+		return
+	}
+
+	err = cfg.checkJournalMedia(r.Context(), params.MediaIDs, trip.ID)
+
+	if errors.Is(err, errJournalMediaNotInTrip) {
+		respondWithError(w, http.StatusBadRequest, "Journal entries can only link media from the same trip", err, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check journal media", err, false)
+		return
+	}
+
+	locationName, locationTag, err := cfg.journalEntryLocation(params.Location)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not resolve journal entry location name", err, false)
+		return
+	}
+
+	var entryID uuid.UUID
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		entryID, err = q.CreateJournalEntry(r.Context(), database.CreateJournalEntryParams{
+			TripID:       trip.ID,
+			TripStopID:   params.tripStopID(),
+			UserID:       user.ID,
+			Title:        params.title(),
+			Body:         params.Body,
+			BodyHtml:     markdown.Render(params.Body),
+			EntryDate:    entryDate,
+			LocationName: locationName,
+			LocationTag:  locationTag,
+		})
+
+		if err != nil || len(params.MediaIDs) == 0 {
+			return err
+		}
+
+		return q.AddJournalEntryMedia(r.Context(), database.AddJournalEntryMediaParams{
+			JournalEntryID: entryID,
+			MediaIds:       params.MediaIDs,
+		})
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Journal entries can only be attached to a stop of the same trip", errJournalStopNotInTrip, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create journal entry", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data: struct {
+			JournalEntryID uuid.UUID `json:"journalEntryID"`
+		}{
+			JournalEntryID: entryID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerUpdateJournalEntry(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	entryUUID, err := uuid.Parse(chi.URLParam(r, "entryID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	entry, err := cfg.db.GetJournalEntry(r.Context(), database.GetJournalEntryParams{
+		ID:     entryUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find journal entry possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(entry.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	params := &JournalEntryParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read journal entry", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	entryDate, err := time.Parse(journalDateLayout, params.EntryDate)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid journal entry date", err, false)
+		return
+	}
+
+	err = cfg.checkJournalMedia(r.Context(), params.MediaIDs, entry.TripID)
+
+	if errors.Is(err, errJournalMediaNotInTrip) {
+		respondWithError(w, http.StatusBadRequest, "Journal entries can only link media from the same trip", err, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check journal media", err, false)
+		return
+	}
+
+	locationName, locationTag, err := cfg.journalEntryLocation(params.Location)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not resolve journal entry location name", err, false)
+		return
+	}
+
+	var entryID uuid.UUID
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		entryID, err = q.UpdateJournalEntry(r.Context(), database.UpdateJournalEntryParams{
+			TripStopID:   params.tripStopID(),
+			Title:        params.title(),
+			Body:         params.Body,
+			BodyHtml:     markdown.Render(params.Body),
+			EntryDate:    entryDate,
+			LocationName: locationName,
+			LocationTag:  locationTag,
+			ID:           entry.ID,
+			UserID:       user.ID,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteJournalEntryMedia(r.Context(), entryID); err != nil || len(params.MediaIDs) == 0 {
+			return err
+		}
+
+		return q.AddJournalEntryMedia(r.Context(), database.AddJournalEntryMediaParams{
+			JournalEntryID: entryID,
+			MediaIds:       params.MediaIDs,
+		})
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Journal entries can only be attached to a stop of the same trip", errJournalStopNotInTrip, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update journal entry", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: struct {
+			JournalEntryID uuid.UUID `json:"journalEntryID"`
+		}{
+			JournalEntryID: entryID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerDeleteJournalEntry(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	entryUUID, err := uuid.Parse(chi.URLParam(r, "entryID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	entry, err := cfg.db.GetJournalEntry(r.Context(), database.GetJournalEntryParams{
+		ID:     entryUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find journal entry possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(entry.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	err = cfg.db.DeleteJournalEntry(r.Context(), database.DeleteJournalEntryParams{
+		ID:     entry.ID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete journal entry", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
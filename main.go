@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -12,17 +13,33 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/mambo-dev/adventrak-backend/internal/currency"
 	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/emissions"
+	"github.com/mambo-dev/adventrak-backend/internal/geocode"
+	"github.com/mambo-dev/adventrak-backend/internal/storage"
+	"github.com/mambo-dev/adventrak-backend/internal/timezone"
 	"github.com/mambo-dev/adventrak-backend/internal/utils"
 )
 
 type apiConfig struct {
-	db             *database.Queries
-	jwtSecret      string
-	sendGridApiKey string
-	frontEndURL    string
-	assetsRoot     string
-	baseApiUrl     string
+	db              *database.Queries
+	conn            *sql.DB
+	jwtSecret       string
+	sendGridApiKey  string
+	frontEndURL     string
+	baseApiUrl      string
+	geocoder        *geocode.Gazetteer
+	timezones       *timezone.Finder
+	emissionFactors emissions.Factors
+	exchangeRates   *currency.Rates
+	trashRetention  time.Duration
+	maxVideoSize    int64
+	uploadsRoot     string
+	storage         storage.Storage
+	redirectMedia   bool
+	mediaURLSecret  []byte
+	storageQuota    int64
 }
 
 func main() {
@@ -64,16 +81,75 @@ func main() {
 		log.Fatal("FATAL: frontend url not set")
 	}
 
-	assetsRoot := os.Getenv("ASSETS_ROOT")
-	if assetsRoot == "" {
-		log.Fatal("ASSETS_ROOT environment variable is not set")
+	mediaURLSecret := os.Getenv("MEDIA_URL_SECRET")
+	if mediaURLSecret == "" {
+		log.Printf("WARNING: MEDIA_URL_SECRET not set. Media URLs will be signed with JWT_SECRET")
+		mediaURLSecret = jwtSecret
 	}
 
+	storageBackend := os.Getenv("STORAGE_BACKEND")
+	assetsRoot := os.Getenv("ASSETS_ROOT")
+
 	baseApiUrl := os.Getenv("BASE_API_URL")
 	if baseApiUrl == "" {
 		log.Fatal("ASSETS_ROOT environment variable is not set")
 	}
 
+	gazetteerPath := os.Getenv("GAZETTEER_PATH")
+	if gazetteerPath == "" {
+		log.Printf("WARNING: GAZETTEER_PATH not set. Locations will not be reverse geocoded")
+	}
+
+	geocoder, err := loadGeocoder(gazetteerPath, os.Getenv("GAZETTEER_ADMIN1_PATH"))
+	if err != nil {
+		log.Fatalf("could not load gazetteer: %v", err)
+	}
+
+	timezoneBoundariesPath := os.Getenv("TIMEZONE_BOUNDARIES_PATH")
+	if timezoneBoundariesPath == "" {
+		log.Printf("WARNING: TIMEZONE_BOUNDARIES_PATH not set. Local times will use nautical timezones")
+	}
+
+	timezones, err := loadTimezones(timezoneBoundariesPath)
+	if err != nil {
+		log.Fatalf("could not load timezone boundaries: %v", err)
+	}
+
+	emissionFactors, err := loadEmissionFactors(os.Getenv("EMISSION_FACTORS_PATH"))
+	if err != nil {
+		log.Fatalf("could not load emission factors: %v", err)
+	}
+
+	exchangeRatesPath := os.Getenv("EXCHANGE_RATES_PATH")
+	if exchangeRatesPath == "" {
+		log.Printf("WARNING: EXCHANGE_RATES_PATH not set. Expenses will only be totalled in their own currency")
+	}
+
+	exchangeRates, err := loadExchangeRates(exchangeRatesPath)
+	if err != nil {
+		log.Fatalf("could not load exchange rates: %v", err)
+	}
+
+	trashRetention, err := parseTrashRetention(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil {
+		log.Fatalf("could not parse TRASH_RETENTION_DAYS: %v", err)
+	}
+
+	maxVideoSize, err := parseUploadLimit(os.Getenv("MAX_VIDEO_UPLOAD_MB"), defaultMaxVideoSize)
+	if err != nil {
+		log.Fatalf("could not parse MAX_VIDEO_UPLOAD_MB: %v", err)
+	}
+
+	storageQuota, err := parseUploadLimit(os.Getenv("STORAGE_QUOTA_MB"), defaultStorageQuota)
+	if err != nil {
+		log.Fatalf("could not parse STORAGE_QUOTA_MB: %v", err)
+	}
+
+	uploadsRoot := os.Getenv("UPLOADS_ROOT")
+	if uploadsRoot == "" {
+		uploadsRoot = defaultUploadDir
+	}
+
 	apiCfg := apiConfig{}
 
 	dbURL := os.Getenv("DATABASE_URL")
@@ -88,11 +164,20 @@ func main() {
 	}
 
 	apiCfg.db = database.New(db)
+	apiCfg.conn = db
 	apiCfg.jwtSecret = jwtSecret
 	apiCfg.sendGridApiKey = sendGridApiKey
 	apiCfg.frontEndURL = frontEndURL
-	apiCfg.assetsRoot = assetsRoot
 	apiCfg.baseApiUrl = baseApiUrl
+	apiCfg.geocoder = geocoder
+	apiCfg.timezones = timezones
+	apiCfg.emissionFactors = emissionFactors
+	apiCfg.exchangeRates = exchangeRates
+	apiCfg.trashRetention = trashRetention
+	apiCfg.maxVideoSize = maxVideoSize
+	apiCfg.storageQuota = storageQuota
+	apiCfg.uploadsRoot = uploadsRoot
+	apiCfg.mediaURLSecret = []byte(mediaURLSecret)
 
 	router := chi.NewRouter()
 	allowedOrigins := []string{"http://*"}
@@ -107,24 +192,39 @@ func main() {
 
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   allowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Location", "Upload-Offset", "Upload-Length", "Upload-Expires", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
-	assetsHandler := http.StripPrefix("/assets", http.FileServer(http.Dir(assetsRoot)))
+	apiCfg.storage, err = loadStorage(storageBackend, assetsRoot, fmt.Sprintf("%v/assets", apiCfg.baseApiUrl))
+
+	if err != nil {
+		log.Fatalf("could not open media storage: %v", err)
+	}
+
+	apiCfg.redirectMedia = storageBackend == storageS3
+
 	v1Router := chi.NewRouter()
 
-	err = utils.EnsureAssetsDir(apiCfg.assetsRoot)
+	err = utils.EnsureAssetsDir(apiCfg.uploadsRoot)
 
 	if err != nil {
-		log.Fatalf("Couldn't create assets directory: %v", err)
+		log.Fatalf("Couldn't create uploads directory: %v", err)
 	}
 
 	if apiCfg.db != nil {
 		log.Println("Db is active")
+		go apiCfg.purgeTrashPeriodically(time.Hour)
+		go apiCfg.expireUploadsPeriodically(time.Hour)
+		go apiCfg.resolveStoredTimezones(context.Background())
+		go func() {
+			apiCfg.recordBlobSizes(context.Background())
+			apiCfg.stripStoredPhotos(context.Background())
+		}()
+
 		v1Router.Post("/auth/signup", apiCfg.handlerSignup)
 		v1Router.Post("/auth/login", apiCfg.handlerLogin)
 		v1Router.Post("/auth/refresh", apiCfg.handlerRefresh)
@@ -139,20 +239,95 @@ func main() {
 		v1Router.Get("/trips/{tripID}", apiCfg.UseAuth(apiCfg.handlerGetTrip))
 		v1Router.Post("/trips", apiCfg.UseAuth(apiCfg.handlerCreateTrip))
 		v1Router.Put("/trips/{tripID}", apiCfg.UseAuth(apiCfg.handlerUpdateTripDetails))
+		v1Router.Put("/trips/{tripID}/cover", apiCfg.UseAuth(apiCfg.handlerSetTripCover))
 		v1Router.Patch("/trips/{tripID}/end", apiCfg.UseAuth(apiCfg.handlerMarkTripComplete))
+		v1Router.Patch("/trips/{tripID}/start", apiCfg.UseAuth(apiCfg.handlerTransitionTrip(startTrip)))
+		v1Router.Patch("/trips/{tripID}/archive", apiCfg.UseAuth(apiCfg.handlerTransitionTrip(archiveTrip)))
+		v1Router.Patch("/trips/{tripID}/reopen", apiCfg.UseAuth(apiCfg.handlerTransitionTrip(reopenTrip)))
+		v1Router.Post("/trips/{tripID}/duplicate", apiCfg.UseAuth(apiCfg.handlerDuplicateTrip))
 		v1Router.Delete("/trips/{tripID}", apiCfg.UseAuth(apiCfg.handlerDeleteTrip))
 
+		v1Router.Get("/trash", apiCfg.UseAuth(apiCfg.handlerGetTrash))
+		v1Router.Patch("/trash/trips/{tripID}/restore", apiCfg.UseAuth(apiCfg.handlerRestoreTrip))
+		v1Router.Patch("/trash/stops/{stopID}/restore", apiCfg.UseAuth(apiCfg.handlerRestoreStop))
+		v1Router.Patch("/trash/media/{mediaID}/restore", apiCfg.UseAuth(apiCfg.handlerRestoreMedia))
+
+		v1Router.Get("/trips/{tripID}/members", apiCfg.UseAuth(apiCfg.handlerGetTripMembers))
+		v1Router.Post("/trips/{tripID}/members", apiCfg.UseAuth(apiCfg.handlerInviteTripMember))
+		v1Router.Put("/trips/{tripID}/members/{memberID}", apiCfg.UseAuth(apiCfg.handlerUpdateTripMember))
+		v1Router.Delete("/trips/{tripID}/members/{memberID}", apiCfg.UseAuth(apiCfg.handlerRemoveTripMember))
+		v1Router.Get("/invites", apiCfg.UseAuth(apiCfg.handlerGetInvites))
+		v1Router.Patch("/invites/{inviteID}/accept", apiCfg.UseAuth(apiCfg.handlerAnswerInvite(true)))
+		v1Router.Patch("/invites/{inviteID}/decline", apiCfg.UseAuth(apiCfg.handlerAnswerInvite(false)))
+
+		v1Router.Get("/trips/{tripID}/shares", apiCfg.UseAuth(apiCfg.handlerGetTripShares))
+		v1Router.Post("/trips/{tripID}/shares", apiCfg.UseAuth(apiCfg.handlerCreateTripShare))
+		v1Router.Delete("/shares/{shareID}", apiCfg.UseAuth(apiCfg.handlerRevokeTripShare))
+		v1Router.Get("/shared/{token}", apiCfg.handlerGetSharedTrip)
+
+		v1Router.Get("/trips/{tripID}/plan", apiCfg.UseAuth(apiCfg.handlerGetPlan))
+		v1Router.Post("/trips/{tripID}/plan", apiCfg.UseAuth(apiCfg.handlerCreatePlannedStop))
+		v1Router.Get("/trips/{tripID}/plan/diff", apiCfg.UseAuth(apiCfg.handlerGetPlanDiff))
+		v1Router.Put("/plan/{plannedStopID}", apiCfg.UseAuth(apiCfg.handlerUpdatePlannedStop))
+		v1Router.Delete("/plan/{plannedStopID}", apiCfg.UseAuth(apiCfg.handlerDeletePlannedStop))
+
 		v1Router.Get("/stops", apiCfg.UseAuth(apiCfg.handlerGetStops))
 		v1Router.Get("/stops/{stopID}", apiCfg.UseAuth(apiCfg.handlerGetStop))
 		v1Router.Post("/stops/{tripID}", apiCfg.UseAuth(apiCfg.handlerCreateStop))
+		v1Router.Put("/trips/{tripID}/stops/order", apiCfg.UseAuth(apiCfg.handlerReorderStops))
 		v1Router.Put("/stops/{stopID}", apiCfg.UseAuth(apiCfg.handlerUpdateStop))
+		v1Router.Put("/stops/{stopID}/cover", apiCfg.UseAuth(apiCfg.handlerSetStopCover))
 		v1Router.Delete("/stops/{stopID}", apiCfg.UseAuth(apiCfg.handlerDeleteStop))
 
+		v1Router.Get("/trips/{tripID}/journal", apiCfg.UseAuth(apiCfg.handlerGetJournalEntries))
+		v1Router.Post("/trips/{tripID}/journal", apiCfg.UseAuth(apiCfg.handlerCreateJournalEntry))
+		v1Router.Get("/journal/{entryID}", apiCfg.UseAuth(apiCfg.handlerGetJournalEntry))
+		v1Router.Put("/journal/{entryID}", apiCfg.UseAuth(apiCfg.handlerUpdateJournalEntry))
+		v1Router.Delete("/journal/{entryID}", apiCfg.UseAuth(apiCfg.handlerDeleteJournalEntry))
+
+		v1Router.Get("/albums", apiCfg.UseAuth(apiCfg.handlerGetAlbums))
+		v1Router.Post("/albums", apiCfg.UseAuth(apiCfg.handlerCreateAlbum))
+		v1Router.Get("/albums/{albumID}", apiCfg.UseAuth(apiCfg.handlerGetAlbum))
+		v1Router.Put("/albums/{albumID}", apiCfg.UseAuth(apiCfg.handlerUpdateAlbum))
+		v1Router.Delete("/albums/{albumID}", apiCfg.UseAuth(apiCfg.handlerDeleteAlbum))
+
+		v1Router.Get("/trips/{tripID}/expenses", apiCfg.UseAuth(apiCfg.handlerGetTripExpenses))
+		v1Router.Post("/trips/{tripID}/expenses", apiCfg.UseAuth(apiCfg.handlerCreateExpense))
+		v1Router.Put("/trips/{tripID}/budget", apiCfg.UseAuth(apiCfg.handlerSetTripBudget))
+		v1Router.Get("/expenses/{expenseID}", apiCfg.UseAuth(apiCfg.handlerGetExpense))
+		v1Router.Put("/expenses/{expenseID}", apiCfg.UseAuth(apiCfg.handlerUpdateExpense))
+		v1Router.Delete("/expenses/{expenseID}", apiCfg.UseAuth(apiCfg.handlerDeleteExpense))
+
+		v1Router.Get("/account/settings", apiCfg.UseAuth(apiCfg.handlerGetSettings))
+		v1Router.Put("/account/settings", apiCfg.UseAuth(apiCfg.handlerUpdateSettings))
+		v1Router.Get("/account/usage", apiCfg.UseAuth(apiCfg.handlerGetUsage))
+
+		v1Router.Get("/tags", apiCfg.UseAuth(apiCfg.handlerGetTags))
+		v1Router.Post("/tags", apiCfg.UseAuth(apiCfg.handlerCreateTag))
+		v1Router.Post("/tags/assign", apiCfg.UseAuth(apiCfg.handlerBulkTag(true)))
+		v1Router.Post("/tags/unassign", apiCfg.UseAuth(apiCfg.handlerBulkTag(false)))
+		v1Router.Put("/tags/{tagID}", apiCfg.UseAuth(apiCfg.handlerUpdateTag))
+		v1Router.Delete("/tags/{tagID}", apiCfg.UseAuth(apiCfg.handlerDeleteTag))
+
 		v1Router.Post("/media/photos", apiCfg.UseAuth(apiCfg.handlerUploadPhotos))
+		v1Router.Post("/media/videos", apiCfg.UseAuth(apiCfg.handlerUploadVideo))
 		v1Router.Delete("/media/{mediaID}", apiCfg.UseAuth(apiCfg.handlerDeletePhoto))
 		v1Router.Get("/media/{mediaID}", apiCfg.UseAuth(apiCfg.handlerGetMedium))
 		v1Router.Get("/media/{mediaID}", apiCfg.UseAuth(apiCfg.handlerGetMedium))
+		v1Router.Patch("/media/{mediaID}", apiCfg.UseAuth(apiCfg.handlerUpdateMedium))
+		v1Router.Put("/media/order", apiCfg.UseAuth(apiCfg.handlerReorderMedia))
 		v1Router.Get("/media", apiCfg.UseAuth(apiCfg.handlerGetMedia))
+		v1Router.Options("/uploads", apiCfg.handlerTusOptions)
+		v1Router.Post("/uploads", apiCfg.UseAuth(apiCfg.handlerCreateUpload))
+		v1Router.Head("/uploads/{uploadID}", apiCfg.UseAuth(apiCfg.handlerGetUploadOffset))
+		v1Router.Patch("/uploads/{uploadID}", apiCfg.UseAuth(apiCfg.handlerPatchUpload))
+		v1Router.Delete("/uploads/{uploadID}", apiCfg.UseAuth(apiCfg.handlerDeleteUpload))
+
+		v1Router.Get("/tiles/{z}/{x}/{y}.mvt", apiCfg.UseAuth(apiCfg.handlerGetTile))
+
+		v1Router.Get("/stats", apiCfg.UseAuth(apiCfg.handlerGetStats))
+
+		v1Router.Get("/geocode/reverse", apiCfg.UseAuth(apiCfg.handlerReverseGeocode))
 	}
 
 	if workEnv == "dev" {
@@ -161,7 +336,8 @@ func main() {
 
 	v1Router.Get("/healthz", handlerReadiness)
 
-	router.Handle("/assets/*", assetsHandler)
+	router.Get("/assets/*", apiCfg.handlerServeMedia)
+	router.Head("/assets/*", apiCfg.handlerServeMedia)
 	router.Mount("/v1", v1Router)
 
 	srv := &http.Server{
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+)
+
+var (
+	errMediaOrder    = errors.New("media order does not match the trip or stop media")
+	errCoverNotFound = errors.New("cover photo does not belong to the trip or stop")
+)
+
+// MediaDetailsParams updates only the fields that are sent. An empty
+// caption or alt text clears it.
+type MediaDetailsParams struct {
+	Caption   *string `json:"caption" validate:"omitempty,max=2000"`
+	AltText   *string `json:"altText" validate:"omitempty,max=1000"`
+	Favourite *bool   `json:"favourite"`
+}
+
+type MediaOrderParams struct {
+	TripID   *uuid.UUID  `json:"tripID"`
+	StopID   *uuid.UUID  `json:"stopID"`
+	MediaIDs []uuid.UUID `json:"mediaIDs" validate:"required,min=1,unique"`
+}
+
+type CoverParams struct {
+	MediaID *uuid.UUID `json:"mediaID"`
+}
+
+func optionalText(value *string, current sql.NullString) sql.NullString {
+	if value == nil {
+		return current
+	}
+
+	return sql.NullString{String: *value, Valid: *value != ""}
+}
+
+func (cfg apiConfig) handlerUpdateMedium(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	mediaUUID, err := uuid.Parse(chi.URLParam(r, "mediaID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	params := &MediaDetailsParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read media details", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	media, err := cfg.db.GetTripMediaById(r.Context(), database.GetTripMediaByIdParams{
+		ID:     mediaUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find media possibly deleted", err, false)
+		return
+	}
+
+	favourite := media.Favourite
+
+	if params.Favourite != nil {
+		favourite = *params.Favourite
+	}
+
+	media, err = cfg.db.UpdateMediaDetails(r.Context(), database.UpdateMediaDetailsParams{
+		Caption:   optionalText(params.Caption, media.Caption),
+		AltText:   optionalText(params.AltText, media.AltText),
+		Favourite: favourite,
+		ID:        media.ID,
+		UserID:    user.ID,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondReadOnly(w)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update media details", err, false)
+		return
+	}
+
+	mediaResponse, err := cfg.transformMediaList(r.Context(), []database.TripMedium{media})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get this photo's sizes", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   mediaResponse[0],
+	})
+}
+
+// handlerReorderMedia sets the manual order of a trip's or a stop's media.
+// Like stop ordering, the new order has to list every item exactly once.
+func (cfg apiConfig) handlerReorderMedia(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	params := &MediaOrderParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read media order", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	if (params.TripID != nil) == (params.StopID != nil) {
+		respondWithError(w, http.StatusBadRequest, "Use either a trip or a stop id but not both", errMediaTargetBoth, false)
+		return
+	}
+
+	mediaParams := database.GetTripMediaByTripOrStopIDParams{UserID: user.ID}
+
+	if params.TripID != nil {
+		trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+			UserID: user.ID,
+			ID:     *params.TripID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Failed to get this trip, it may have been deleted", err, false)
+			return
+		}
+
+		if !canEditTrip(trip.Role) {
+			respondReadOnly(w)
+			return
+		}
+
+		mediaParams.TripID = uuid.NullUUID{UUID: trip.ID, Valid: true}
+	} else {
+		stop, err := cfg.db.GetStop(r.Context(), database.GetStopParams{
+			UserID: user.ID,
+			ID:     *params.StopID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Failed to get this stop, it may have been deleted", err, false)
+			return
+		}
+
+		if !canEditTrip(stop.Role) {
+			respondReadOnly(w)
+			return
+		}
+
+		mediaParams.TripStopID = uuid.NullUUID{UUID: stop.ID, Valid: true}
+	}
+
+	media, err := cfg.db.GetTripMediaByTripOrStopID(r.Context(), mediaParams)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get this trips medias", err, false)
+		return
+	}
+
+	listed := make(map[uuid.UUID]bool, len(media))
+	for _, medium := range media {
+		listed[medium.ID] = true
+	}
+
+	if len(params.MediaIDs) != len(listed) {
+		respondWithError(w, http.StatusBadRequest, "Media order must list every photo and video exactly once", errMediaOrder, false)
+		return
+	}
+
+	for _, mediaID := range params.MediaIDs {
+		if !listed[mediaID] {
+			respondWithError(w, http.StatusBadRequest, "Media order must list every photo and video exactly once", fmt.Errorf("%w: %v is not listed", errMediaOrder, mediaID), false)
+			return
+		}
+	}
+
+	_, err = cfg.db.ReorderMedia(r.Context(), database.ReorderMediaParams{
+		MediaIds: params.MediaIDs,
+		UserID:   user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to reorder media", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
+
+// readCoverParams reads the photo to use as a cover. A null or missing
+// mediaID clears the cover.
+func readCoverParams(r *http.Request) (uuid.NullUUID, error) {
+	params := &CoverParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	if params.MediaID == nil {
+		return uuid.NullUUID{}, nil
+	}
+
+	return uuid.NullUUID{UUID: *params.MediaID, Valid: true}, nil
+}
+
+func (cfg apiConfig) handlerSetTripCover(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	mediaID, err := readCoverParams(r)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read cover photo", err, false)
+		return
+	}
+
+	updated, err := cfg.db.SetTripCover(r.Context(), database.SetTripCoverParams{
+		MediaID: mediaID,
+		TripID:  trip.ID,
+		UserID:  user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to set the trip cover", err, false)
+		return
+	}
+
+	if updated == 0 {
+		respondWithError(w, http.StatusBadRequest, "The cover must be a photo from this trip or one of its stops", errCoverNotFound, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
+
+func (cfg apiConfig) handlerSetStopCover(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	stopUUID, err := uuid.Parse(chi.URLParam(r, "stopID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	stop, err := cfg.db.GetStop(r.Context(), database.GetStopParams{
+		UserID: user.ID,
+		ID:     stopUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find stop possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(stop.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	mediaID, err := readCoverParams(r)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read cover photo", err, false)
+		return
+	}
+
+	updated, err := cfg.db.SetStopCover(r.Context(), database.SetStopCoverParams{
+		MediaID: mediaID,
+		StopID:  stop.ID,
+		UserID:  user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to set the stop cover", err, false)
+		return
+	}
+
+	if updated == 0 {
+		respondWithError(w, http.StatusBadRequest, "The cover must be a photo from this stop", errCoverNotFound, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
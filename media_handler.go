@@ -2,37 +2,120 @@ package main
 
 import (
 	"database/sql"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime"
 	"net/http"
 	"os"
-	"path"
-	"path/filepath"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/mambo-dev/adventrak-backend/internal/database"
-	"github.com/mambo-dev/adventrak-backend/internal/utils"
+	"github.com/mambo-dev/adventrak-backend/internal/exif"
+	"github.com/mambo-dev/adventrak-backend/internal/imaging"
+	"github.com/mambo-dev/adventrak-backend/internal/video"
+)
+
+const defaultMaxVideoSize = 500 << 20
+
+// videoExtensions lists the accepted video containers by the format the
+// prober reports, which decides the stored extension.
+var videoExtensions = map[string]string{
+	video.FormatMP4:  ".mp4",
+	video.FormatMOV:  ".mov",
+	video.FormatWebM: ".webm",
+}
+
+var videoMediaTypes = map[string]bool{
+	"video/mp4":       true,
+	"video/webm":      true,
+	"video/quicktime": true,
+}
+
+var (
+	errVideoTooLarge   = errors.New("video exceeds the maximum upload size")
+	errPhotoTooLarge   = errors.New("photo exceeds the maximum upload size")
+	errBadUploadLimit  = errors.New("upload limit must be a whole number of megabytes of at least 1")
+	errMediaTargetBoth = errors.New("use either a trip or a stop id but not both")
 )
 
 type MediaResponse struct {
-	PhotoID  uuid.UUID `json:"photoID"`
-	PhotoURL string    `json:"photoURL"`
+	PhotoID         uuid.UUID `json:"photoID"`
+	PhotoURL        string    `json:"photoURL"`
+	VideoURL        string    `json:"videoURL,omitempty"`
+	DurationSeconds float64   `json:"durationSeconds,omitempty"`
+	Width           int32     `json:"width,omitempty"`
+	Height          int32     `json:"height,omitempty"`
+
+	ThumbnailURL string            `json:"thumbnailURL,omitempty"`
+	Srcset       map[string]string `json:"srcset,omitempty"`
+
+	TripStopID    *uuid.UUID             `json:"tripStopID,omitempty"`
+	TakenAt       *time.Time             `json:"takenAt,omitempty"`
+	Lat           *float64               `json:"lat,omitempty"`
+	Lng           *float64               `json:"lng,omitempty"`
+	SuggestedStop *SuggestedStopResponse `json:"suggestedStop,omitempty"`
+
+	Caption   string `json:"caption,omitempty"`
+	AltText   string `json:"altText,omitempty"`
+	SortOrder *int32 `json:"sortOrder,omitempty"`
+	Favourite bool   `json:"favourite"`
 
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-func transformMedia(media database.TripMedium) MediaResponse {
-	return MediaResponse{
-		PhotoID:   media.ID,
-		PhotoURL:  media.PhotoUrl.String,
-		CreatedAt: media.CreatedAt,
+func (cfg apiConfig) transformMedia(media database.TripMedium) MediaResponse {
+	response := MediaResponse{
+		PhotoID:         media.ID,
+		PhotoURL:        cfg.signMediaURL(media.PhotoUrl.String),
+		VideoURL:        cfg.signMediaURL(media.VideoUrl.String),
+		DurationSeconds: media.DurationSeconds.Float64,
+		Width:           media.Width.Int32,
+		Height:          media.Height.Int32,
+		Caption:         media.Caption.String,
+		AltText:         media.AltText.String,
+		Favourite:       media.Favourite,
+		CreatedAt:       media.CreatedAt,
+	}
+
+	if media.SortOrder.Valid {
+		response.SortOrder = &media.SortOrder.Int32
+	}
+
+	if media.TripStopID.Valid {
+		response.TripStopID = &media.TripStopID.UUID
+	}
+
+	if media.TakenAt.Valid {
+		takenAt := media.TakenAt.Time.UTC()
+		response.TakenAt = &takenAt
+	}
+
+	if media.Latitude.Valid && media.Longitude.Valid {
+		response.Lat, response.Lng = &media.Latitude.Float64, &media.Longitude.Float64
+	}
+
+	return response
+}
+
+// parseUploadLimit reads a size limit in megabytes, falling back to the
+// given default when it is not configured.
+func parseUploadLimit(megabytes string, fallback int64) (int64, error) {
+	if megabytes == "" {
+		return fallback, nil
 	}
+
+	n, err := strconv.ParseInt(megabytes, 10, 64)
+
+	if err != nil || n < 1 || n > math.MaxInt64>>20 {
+		return 0, errBadUploadLimit
+	}
+
+	return n << 20, nil
 }
 
 func (cfg apiConfig) handlerUploadPhotos(w http.ResponseWriter, r *http.Request) {
@@ -64,10 +147,93 @@ func (cfg apiConfig) handlerUploadPhotos(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The trip or stop is resolved before the photo is stored, so a request
+	// that cannot attach it never takes a reference on the stored file.
+	mediaParams := database.CreateTripMediaParams{
+		UserID: user.ID,
+	}
+
+	if len(tripID) > 0 {
+		tripUUID, err := uuid.Parse(tripID)
+
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid trip id", err, false)
+			return
+		}
+
+		trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+			UserID: user.ID,
+			ID:     tripUUID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Failed to get this trip, it may have been deleted", err, false)
+			return
+		}
+
+		if !canEditTrip(trip.Role) {
+			respondReadOnly(w)
+			return
+		}
+
+		mediaParams.TripID = uuid.NullUUID{UUID: trip.ID, Valid: true}
+	} else {
+		if len(stopID) <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid Stop ID passed", err, false)
+			return
+		}
+
+		stopUUID, err := uuid.Parse(stopID)
+
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid stop id", err, false)
+			return
+		}
+
+		stop, err := cfg.db.GetStop(r.Context(), database.GetStopParams{
+			UserID: user.ID,
+			ID:     stopUUID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Failed to get this stop, it may have been deleted", err, false)
+			return
+		}
+
+		if !canEditTrip(stop.Role) {
+			respondReadOnly(w)
+			return
+		}
+
+		mediaParams.TripStopID = uuid.NullUUID{UUID: stop.ID, Valid: true}
+	}
+
+	remaining, err := cfg.storageRemaining(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	// As with videos, the request carries a little more than the photo, so
+	// only turn it away early when even the boundaries cannot account for it.
+	if r.ContentLength > remaining+1<<20 {
+		cfg.respondQuotaExceeded(w)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSize+1<<20)
+
 	const maxMemory = 10 << 20
 
 	err = r.ParseMultipartForm(maxMemory)
 
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Photos can be at most %d MB.", maxPhotoSize>>20), errPhotoTooLarge, false)
+		return
+	}
+
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Failed to parse memory", err, false)
 		return
@@ -82,6 +248,11 @@ func (cfg apiConfig) handlerUploadPhotos(w http.ResponseWriter, r *http.Request)
 
 	defer file.Close()
 
+	if fileHeader.Size > maxPhotoSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Photos can be at most %d MB.", maxPhotoSize>>20), errPhotoTooLarge, false)
+		return
+	}
+
 	mediaTypeHeader := fileHeader.Header.Get("Content-Type")
 
 	mediaType, _, err := mime.ParseMediaType(mediaTypeHeader)
@@ -108,40 +279,104 @@ func (cfg apiConfig) handlerUploadPhotos(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	randomNumber, err := utils.Random32Generator()
+	metadata, err := readPhotoMetadata(file)
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
 		return
 	}
 
-	fileName := fmt.Sprintf("%v%v", base64.RawURLEncoding.EncodeToString([]byte(randomNumber)), extensions[0])
+	photoPath, err := cfg.stagePhoto(file)
 
-	imageFilePath := filepath.Join(cfg.assetsRoot, fileName)
-	imageFilePath = filepath.Clean(imageFilePath)
-
-	if !strings.HasPrefix(imageFilePath, "assets/") {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong", errors.New("failed to safely parse the filepath"), false)
+	if errors.Is(err, exif.ErrUnsupported) || errors.Is(err, exif.ErrMalformed) {
+		respondWithError(w, http.StatusBadRequest, "The photo could not be read as an image.", err, false)
 		return
 	}
 
-	savedFile, err := os.Create(imageFilePath)
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Photos can be at most %d megapixels.", imaging.MaxPixels/1_000_000), err, false)
+		return
+	}
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
 		return
 	}
 
-	defer savedFile.Close()
+	defer os.Remove(photoPath)
 
-	_, err = io.Copy(savedFile, file)
+	key, err := cfg.storeMedia(r.Context(), user.ID, photoPath, extensions[0], mediaType)
+
+	if errors.Is(err, errStorageQuota) {
+		cfg.respondQuotaExceeded(w)
+		return
+	}
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
 		return
 	}
 
-	photoURL := fmt.Sprintf("%v/%v", cfg.baseApiUrl, imageFilePath)
+	photoURL := cfg.mediaURL(key)
+
+	mediaParams.PhotoUrl = sql.NullString{
+		String: photoURL,
+		Valid:  true,
+	}
+
+	suggestedStop := cfg.geotagPhoto(r.Context(), &mediaParams, metadata, user.KeepPhotoLocation)
+
+	media, err := cfg.db.CreateTripMedia(r.Context(), mediaParams)
+
+	if err != nil {
+		cfg.releaseStoredMedia(r.Context(), photoURL)
+		respondWithError(w, http.StatusNotFound, "Could not create media", err, false)
+		return
+	}
+
+	mediaResponse := cfg.photoResponse(r.Context(), media, photoPath)
+	mediaResponse.SuggestedStop = suggestedStop
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data:   mediaResponse,
+	})
+}
+
+// handlerUploadVideo streams a video straight to the assets directory
+// rather than buffering the multipart form, then reads its duration and
+// dimensions from the container headers.
+func (cfg apiConfig) handlerUploadVideo(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripID := r.URL.Query().Get("tripID")
+
+	stopID := r.URL.Query().Get("stopID")
+
+	if len(tripID) > 0 && len(stopID) > 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid query params use either stop or trip id but not both", errMediaTargetBoth, false)
+		return
+	}
+
+	mediaParams := database.CreateTripMediaParams{
+		UserID: user.ID,
+	}
 
 	if len(tripID) > 0 {
 		tripUUID, err := uuid.Parse(tripID)
@@ -161,75 +396,168 @@ func (cfg apiConfig) handlerUploadPhotos(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		media, err := cfg.db.CreateTripMedia(r.Context(), database.CreateTripMediaParams{
-			TripID: uuid.NullUUID{
-				UUID:  trip.ID,
-				Valid: true,
-			},
-			PhotoUrl: sql.NullString{
-				String: photoURL,
-				Valid:  true,
-			},
+		if !canEditTrip(trip.Role) {
+			respondReadOnly(w)
+			return
+		}
+
+		mediaParams.TripID = uuid.NullUUID{UUID: trip.ID, Valid: true}
+	} else {
+		stopUUID, err := uuid.Parse(stopID)
+
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid stop id", err, false)
+			return
+		}
+
+		stop, err := cfg.db.GetStop(r.Context(), database.GetStopParams{
 			UserID: user.ID,
+			ID:     stopUUID,
 		})
 
 		if err != nil {
-			respondWithError(w, http.StatusNotFound, "Could not create media", err, false)
+			respondWithError(w, http.StatusNotFound, "Failed to get this stop, it may have been deleted", err, false)
 			return
 		}
 
-		respondWithJSON(w, http.StatusCreated, ApiResponse{
-			Status: "success",
-			Data:   transformMedia(media),
-		})
+		if !canEditTrip(stop.Role) {
+			respondReadOnly(w)
+			return
+		}
+
+		mediaParams.TripStopID = uuid.NullUUID{UUID: stop.ID, Valid: true}
+	}
+
+	remaining, err := cfg.storageRemaining(r.Context(), mediaParams.UserID)
 
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
 		return
 	}
 
-	if len(stopID) <= 0 {
-		respondWithError(w, http.StatusBadRequest, "Invalid Stop ID passed", err, false)
+	// The request is a little larger than the video it carries, so only
+	// turn it away early when even the boundaries cannot account for it.
+	if r.ContentLength > remaining+1<<20 {
+		cfg.respondQuotaExceeded(w)
 		return
 	}
 
-	stopUUID, err := uuid.Parse(stopID)
+	// Leave room for the multipart boundaries and headers around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxVideoSize+1<<20)
+
+	reader, err := r.MultipartReader()
 
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid stop id", err, false)
+		respondWithError(w, http.StatusBadRequest, "Expected a multipart upload", err, false)
 		return
 	}
 
-	stop, err := cfg.db.GetStop(r.Context(), database.GetStopParams{
-		UserID: user.ID,
-		ID:     stopUUID,
-	})
+	var part io.ReadCloser
+	var partType string
+
+	for {
+		next, err := reader.NextPart()
+
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Failed to get file", err, false)
+			return
+		}
+
+		if next.FormName() == "trip_video" {
+			part, partType = next, next.Header.Get("Content-Type")
+			break
+		}
+
+		next.Close()
+	}
+
+	defer part.Close()
+
+	mediaType, _, err := mime.ParseMediaType(partType)
 
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Failed to get this stop, it may have been deleted", err, false)
+		respondWithError(w, http.StatusBadRequest, "Invalid content type.", err, false)
 		return
 	}
 
-	media, err := cfg.db.CreateTripMedia(r.Context(), database.CreateTripMediaParams{
-		TripStopID: uuid.NullUUID{
-			UUID:  stop.ID,
-			Valid: true,
-		},
-		PhotoUrl: sql.NullString{
-			String: photoURL,
-			Valid:  true,
-		},
-		UserID: user.ID,
-	})
+	if !videoMediaTypes[mediaType] {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only .mp4, .webm and .mov videos allowed.", video.ErrUnsupported, false)
+		return
+	}
+
+	tempFile, err := os.CreateTemp(cfg.uploadsRoot, "video-*.part")
 
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Could not create media", err, false)
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	tempFilePath := tempFile.Name()
+	defer tempFile.Close()
+	defer os.Remove(tempFilePath)
+
+	written, err := io.Copy(tempFile, io.LimitReader(part, cfg.maxVideoSize+1))
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || written > cfg.maxVideoSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Videos can be at most %d MB.", cfg.maxVideoSize>>20), errVideoTooLarge, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read the uploaded video", err, false)
+		return
+	}
+
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	info, err := video.Probe(tempFile)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "The uploaded file is not a readable video", err, false)
+		return
+	}
+
+	if err := tempFile.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	key, err := cfg.storeMedia(r.Context(), mediaParams.UserID, tempFilePath, videoExtensions[info.Format], mediaType)
+
+	if errors.Is(err, errStorageQuota) {
+		cfg.respondQuotaExceeded(w)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	mediaParams.VideoUrl = sql.NullString{
+		String: cfg.mediaURL(key),
+		Valid:  true,
+	}
+	mediaParams.DurationSeconds = sql.NullFloat64{Float64: info.Duration.Seconds(), Valid: info.Duration > 0}
+	mediaParams.Width = sql.NullInt32{Int32: int32(info.Width), Valid: info.Width > 0}
+	mediaParams.Height = sql.NullInt32{Int32: int32(info.Height), Valid: info.Height > 0}
+
+	media, err := cfg.db.CreateTripMedia(r.Context(), mediaParams)
+
+	if err != nil {
+		cfg.releaseStoredMedia(r.Context(), mediaParams.VideoUrl.String)
+		respondWithError(w, http.StatusInternalServerError, "Could not create media", err, false)
 		return
 	}
 
 	respondWithJSON(w, http.StatusCreated, ApiResponse{
 		Status: "success",
-		Data:   transformMedia(media),
+		Data:   cfg.transformMedia(media),
 	})
-
 }
 
 func (cfg apiConfig) handlerDeletePhoto(w http.ResponseWriter, r *http.Request) {
@@ -265,7 +593,7 @@ func (cfg apiConfig) handlerDeletePhoto(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	media, err := cfg.db.GetTripMediaById(r.Context(), database.GetTripMediaByIdParams{
+	_, err = cfg.db.GetTripMediaById(r.Context(), database.GetTripMediaByIdParams{
 		ID:     photoUUID,
 		UserID: user.ID,
 	})
@@ -275,7 +603,7 @@ func (cfg apiConfig) handlerDeletePhoto(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = cfg.db.DeleteTripMedia(r.Context(), database.DeleteTripMediaParams{
+	trashed, err := cfg.db.TrashTripMedia(r.Context(), database.TrashTripMediaParams{
 		ID:     photoUUID,
 		UserID: user.ID,
 	})
@@ -285,11 +613,8 @@ func (cfg apiConfig) handlerDeletePhoto(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	imageFileName := strings.Split(media.PhotoUrl.String, "assets/")[1]
-	imageFilePath := path.Join(cfg.assetsRoot, imageFileName)
-
-	if err = utils.DeleteMedia(imageFilePath); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong.", err, false)
+	if trashed == 0 {
+		respondReadOnly(w)
 		return
 	}
 
@@ -342,9 +667,16 @@ func (cfg apiConfig) handlerGetMedium(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mediaResponse, err := cfg.transformMediaList(r.Context(), []database.TripMedium{media})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get this photo's sizes", err, false)
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, ApiResponse{
 		Status: "success",
-		Data:   transformMedia(media),
+		Data:   mediaResponse[0],
 	})
 }
 
@@ -407,10 +739,11 @@ func (cfg apiConfig) handlerGetMedia(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		mediaResponse := make([]MediaResponse, 0, len(media))
+		mediaResponse, err := cfg.transformMediaList(r.Context(), media)
 
-		for _, medium := range media {
-			mediaResponse = append(mediaResponse, transformMedia(medium))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to get this trips medias", err, false)
+			return
 		}
 
 		respondWithJSON(w, http.StatusOK, ApiResponse{
@@ -456,10 +789,11 @@ func (cfg apiConfig) handlerGetMedia(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mediaResponse := make([]MediaResponse, 0, len(media))
+	mediaResponse, err := cfg.transformMediaList(r.Context(), media)
 
-	for _, medium := range media {
-		mediaResponse = append(mediaResponse, transformMedia(medium))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get this trips medias", err, false)
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, ApiResponse{
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/mailer"
+)
+
+// Roles a user can hold on a trip. The owner is whoever created the trip;
+// everyone else is a member invited as a viewer or an editor.
+const (
+	tripRoleOwner  = "owner"
+	tripRoleEditor = string(database.TripRoleEditor)
+)
+
+var (
+	errTripReadOnly     = errors.New("trip is read only for viewers")
+	errNotTripOwner     = errors.New("only the trip owner can manage members")
+	errAlreadyMember    = errors.New("user is already a member of this trip")
+	errInviteOwnTrip    = errors.New("trip owners cannot invite themselves")
+	errInviteNotFound   = errors.New("invite not found or already answered")
+	errMemberNotRemoved = errors.New("member not found or not removable by this user")
+)
+
+type MemberInviteParams struct {
+	User string `json:"user" validate:"required,max=255"`
+	Role string `json:"role" validate:"required,oneof=viewer editor"`
+}
+
+type MemberRoleParams struct {
+	Role string `json:"role" validate:"required,oneof=viewer editor"`
+}
+
+type MemberResponse struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"userId"`
+	Username   string       `json:"username"`
+	Role       string       `json:"role"`
+	Accepted   bool         `json:"accepted"`
+	AcceptedAt sql.NullTime `json:"acceptedAt"`
+	InvitedAt  time.Time    `json:"invitedAt"`
+}
+
+type TripMembersResponse struct {
+	Owner   MemberResponse   `json:"owner"`
+	Members []MemberResponse `json:"members"`
+}
+
+type InviteResponse struct {
+	ID        uuid.UUID `json:"id"`
+	TripID    uuid.UUID `json:"tripId"`
+	TripTitle string    `json:"tripTitle"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invitedBy"`
+	InvitedAt time.Time `json:"invitedAt"`
+}
+
+// canEditTrip reports whether a role may modify a trip, its stops and media.
+func canEditTrip(role string) bool {
+	return role == tripRoleOwner || role == tripRoleEditor
+}
+
+func respondReadOnly(w http.ResponseWriter) {
+	respondWithError(w, http.StatusForbidden, "You only have view access to this trip", errTripReadOnly, false)
+}
+
+func (cfg apiConfig) handlerGetTripMembers(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	owner, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: trip.UserID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trip owner", err, false)
+		return
+	}
+
+	members, err := cfg.db.GetTripMembers(r.Context(), trip.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trip members", err, false)
+		return
+	}
+
+	membersResponse := make([]MemberResponse, 0, len(members))
+
+	for _, member := range members {
+		membersResponse = append(membersResponse, MemberResponse{
+			ID:         member.ID,
+			UserID:     member.UserID,
+			Username:   member.Username,
+			Role:       string(member.Role),
+			Accepted:   member.AcceptedAt.Valid,
+			AcceptedAt: member.AcceptedAt,
+			InvitedAt:  member.CreatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: TripMembersResponse{
+			Owner: MemberResponse{
+				UserID:    owner.ID,
+				Username:  owner.Username,
+				Role:      tripRoleOwner,
+				Accepted:  true,
+				InvitedAt: owner.CreatedAt,
+			},
+			Members: membersResponse,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerInviteTripMember(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if trip.Role != tripRoleOwner {
+		respondWithError(w, http.StatusForbidden, "Only the trip owner can invite members", errNotTripOwner, false)
+		return
+	}
+
+	params := &MemberInviteParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read invite details", err, false)
+		return
+	}
+
+	params.User = strings.TrimSpace(params.User)
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	// The invitee can be named by either their username or their email.
+	invitee, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		Username: params.User,
+		Email:    params.User,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "No user found with that username or email", err, false)
+		return
+	}
+
+	if invitee.ID == trip.UserID {
+		respondWithError(w, http.StatusBadRequest, "You already own this trip", errInviteOwnTrip, false)
+		return
+	}
+
+	memberID, err := cfg.db.CreateTripMember(r.Context(), database.CreateTripMemberParams{
+		TripID:    trip.ID,
+		UserID:    invitee.ID,
+		InvitedBy: user.ID,
+		Role:      database.TripRole(params.Role),
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "This user has already been invited to the trip", errAlreadyMember, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to invite member", err, false)
+		return
+	}
+
+	// The invite is stored either way, so a failed email only gets logged.
+	err = mailer.SendEmail(mailer.EmailDetails{
+		FromEmail: mailer.SystemEmails["system"].Email,
+		FromName:  mailer.SystemEmails["system"].Name,
+		ToEmail:   invitee.Email,
+		ToName:    invitee.Username,
+		Subject:   "You have been invited to a trip.",
+		HtmlContent: mailer.MakeEmailTemplate("Trip invite",
+			fmt.Sprintf(`%s invited you to join their trip from %s as a %s.`, html.EscapeString(user.Username), html.EscapeString(trip.StartLocationName), params.Role),
+			fmt.Sprintf("%sinvites", cfg.frontEndURL)),
+	}, cfg.sendGridApiKey)
+
+	if err != nil {
+		log.Printf("Failed to send trip invite email to %v: %v", invitee.ID, err)
+	}
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data: struct {
+			MemberID uuid.UUID `json:"memberID"`
+		}{
+			MemberID: memberID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerUpdateTripMember(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	memberUUID, err := uuid.Parse(chi.URLParam(r, "memberID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if trip.Role != tripRoleOwner {
+		respondWithError(w, http.StatusForbidden, "Only the trip owner can change member roles", errNotTripOwner, false)
+		return
+	}
+
+	params := &MemberRoleParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read member role", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	memberID, err := cfg.db.UpdateTripMemberRole(r.Context(), database.UpdateTripMemberRoleParams{
+		Role:   database.TripRole(params.Role),
+		ID:     memberUUID,
+		TripID: trip.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find member possibly removed", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: struct {
+			MemberID uuid.UUID `json:"memberID"`
+		}{
+			MemberID: memberID,
+		},
+	})
+}
+
+// handlerRemoveTripMember lets the owner remove a member or revoke an invite,
+// and lets a member leave the trip.
+func (cfg apiConfig) handlerRemoveTripMember(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	memberUUID, err := uuid.Parse(chi.URLParam(r, "memberID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	removed, err := cfg.db.DeleteTripMember(r.Context(), database.DeleteTripMemberParams{
+		ID:     memberUUID,
+		TripID: tripUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to remove member", err, false)
+		return
+	}
+
+	if removed == 0 {
+		respondWithError(w, http.StatusNotFound, "Unable to find member possibly removed", errMemberNotRemoved, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
+
+func (cfg apiConfig) handlerGetInvites(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	invites, err := cfg.db.GetInvites(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return invites", err, false)
+		return
+	}
+
+	invitesResponse := make([]InviteResponse, 0, len(invites))
+
+	for _, invite := range invites {
+		invitesResponse = append(invitesResponse, InviteResponse{
+			ID:        invite.ID,
+			TripID:    invite.TripID,
+			TripTitle: invite.TripTitle,
+			Role:      string(invite.Role),
+			InvitedBy: invite.InvitedBy,
+			InvitedAt: invite.CreatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   invitesResponse,
+	})
+}
+
+// handlerAnswerInvite accepts or declines a pending invite. Declining
+// deletes the invite so the owner can invite the user again later.
+func (cfg apiConfig) handlerAnswerInvite(accept bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := rateLimit(w, r, "general")
+
+		if err != nil {
+			respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+			return
+		}
+
+		userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+		user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+			ID: userID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+			return
+		}
+
+		inviteUUID, err := uuid.Parse(chi.URLParam(r, "inviteID"))
+
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+			return
+		}
+
+		if !accept {
+			declined, err := cfg.db.DeclineInvite(r.Context(), database.DeclineInviteParams{
+				ID:     inviteUUID,
+				UserID: user.ID,
+			})
+
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Failed to decline invite", err, false)
+				return
+			}
+
+			if declined == 0 {
+				respondWithError(w, http.StatusNotFound, "Unable to find a pending invite", errInviteNotFound, false)
+				return
+			}
+
+			respondWithJSON(w, http.StatusOK, ApiResponse{
+				Status: "success",
+				Data:   nil,
+			})
+			return
+		}
+
+		tripID, err := cfg.db.AcceptInvite(r.Context(), database.AcceptInviteParams{
+			ID:     inviteUUID,
+			UserID: user.ID,
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Unable to find a pending invite", errInviteNotFound, false)
+			return
+		}
+
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to accept invite", err, false)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, ApiResponse{
+			Status: "success",
+			Data: struct {
+				TripID uuid.UUID `json:"tripID"`
+			}{
+				TripID: tripID,
+			},
+		})
+	}
+}
@@ -0,0 +1,565 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/utils"
+)
+
+const (
+	// planMatchRadius is how close, in meters, a stop must be to a planned
+	// stop to count as visiting it.
+	planMatchRadius = 5000.0
+	// planMatchWindow is how far from its target date a planned stop may
+	// still be matched.
+	planMatchWindow = 72 * time.Hour
+	// planDelayTolerance is how late a visit may be before it is reported
+	// as delayed.
+	planDelayTolerance = 24 * time.Hour
+)
+
+type PlannedStopParams struct {
+	LocationTag utils.Location `json:"locationTag" validate:"required"`
+	TargetDate  *time.Time     `json:"targetDate,omitempty"`
+}
+
+type PlannedStopResponse struct {
+	ID            uuid.UUID     `json:"id"`
+	LocationName  string        `json:"locationName"`
+	Lat           interface{}   `json:"lat"`
+	Lng           interface{}   `json:"lng"`
+	TargetDate    sql.NullTime  `json:"targetDate"`
+	Position      int32         `json:"position"`
+	MatchedStopID uuid.NullUUID `json:"matchedStopId"`
+}
+
+type PlanDiffStopResponse struct {
+	PlannedStopResponse
+	Status             string          `json:"status"`
+	ActualLocationName sql.NullString  `json:"actualLocationName"`
+	ActualDate         sql.NullTime    `json:"actualDate"`
+	DelayHours         sql.NullFloat64 `json:"delayHours"`
+	OffsetDistance     sql.NullFloat64 `json:"offsetDistance"`
+}
+
+type PlanDiffAddedStopResponse struct {
+	ID           uuid.UUID   `json:"id"`
+	LocationName string      `json:"locationName"`
+	CreatedAt    time.Time   `json:"createdAt"`
+	Lat          interface{} `json:"lat"`
+	Lng          interface{} `json:"lng"`
+}
+
+type PlanDiffResponse struct {
+	Planned         []PlanDiffStopResponse      `json:"planned"`
+	Added           []PlanDiffAddedStopResponse `json:"added"`
+	Visited         int                         `json:"visited"`
+	Skipped         int                         `json:"skipped"`
+	Delayed         int                         `json:"delayed"`
+	Pending         int                         `json:"pending"`
+	PlannedDistance float64                     `json:"plannedDistance"`
+	ActualDistance  float64                     `json:"actualDistance"`
+	ExtraDistance   float64                     `json:"extraDistance"`
+}
+
+func convertToPlannedStopResponse(row database.GetPlannedStopsRow) PlannedStopResponse {
+	return PlannedStopResponse{
+		ID:            row.ID,
+		LocationName:  row.LocationName,
+		Lat:           row.Lat,
+		Lng:           row.Lng,
+		TargetDate:    row.TargetDate,
+		Position:      row.Position,
+		MatchedStopID: row.MatchedStopID,
+	}
+}
+
+// matchPlannedStop links a newly recorded stop to the closest unvisited
+// planned stop of its trip. Not finding one is expected and not an error.
+func (cfg apiConfig) matchPlannedStop(r *http.Request, stopID uuid.UUID) {
+	_, err := cfg.db.MatchPlannedStop(r.Context(), database.MatchPlannedStopParams{
+		StopID:        stopID,
+		Radius:        planMatchRadius,
+		WindowSeconds: planMatchWindow.Seconds(),
+	})
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Failed to match stop %v to the trip plan: %v", stopID, err)
+	}
+}
+
+// rematchPlannedStop drops a moved stop's old match before matching it
+// again, so the plan never points at a stop that is no longer there.
+func (cfg apiConfig) rematchPlannedStop(r *http.Request, stopID uuid.UUID) {
+	err := cfg.db.UnmatchPlannedStop(r.Context(), uuid.NullUUID{UUID: stopID, Valid: true})
+
+	if err != nil {
+		log.Printf("Failed to unmatch stop %v from the trip plan: %v", stopID, err)
+		return
+	}
+
+	cfg.matchPlannedStop(r, stopID)
+}
+
+func (cfg apiConfig) handlerGetPlan(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	plannedStops, err := cfg.db.GetPlannedStops(r.Context(), database.GetPlannedStopsParams{
+		TripID: trip.ID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trip plan", err, false)
+		return
+	}
+
+	plannedStopsResponse := make([]PlannedStopResponse, 0, len(plannedStops))
+
+	for _, plannedStop := range plannedStops {
+		plannedStopsResponse = append(plannedStopsResponse, convertToPlannedStopResponse(plannedStop))
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   plannedStopsResponse,
+	})
+}
+
+func (cfg apiConfig) handlerCreatePlannedStop(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	if !isTripEditable(trip.Status) {
+		respondWithError(w, http.StatusConflict, "Only planned or active trips can be planned. Reopen the trip first.", errTripNotEditable, false)
+		return
+	}
+
+	params := &PlannedStopParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read planned stop details", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	location, err := cfg.resolveLocation(params.LocationTag)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not resolve planned stop location name", err, false)
+		return
+	}
+
+	var targetDate sql.NullTime
+	if params.TargetDate != nil {
+		targetDate = sql.NullTime{Time: params.TargetDate.UTC(), Valid: true}
+	}
+
+	plannedStopID, err := cfg.db.CreatePlannedStop(r.Context(), database.CreatePlannedStopParams{
+		TripID:       trip.ID,
+		UserID:       user.ID,
+		LocationName: location.Name,
+		LocationTag:  utils.FormatPoint(location.Location),
+		TargetDate:   targetDate,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create planned stop", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data: struct {
+			PlannedStopID uuid.UUID `json:"plannedStopID"`
+		}{
+			PlannedStopID: plannedStopID,
+		},
+	})
+}
+
+// plannedStopTrip loads the trip a planned stop belongs to, so changes to a
+// plan are held to the same role and status checks as adding to it.
+func (cfg apiConfig) plannedStopTrip(ctx context.Context, plannedStopID, userID uuid.UUID) (database.GetTripRow, error) {
+	tripID, err := cfg.db.GetPlannedStopTrip(ctx, database.GetPlannedStopTripParams{
+		ID:     plannedStopID,
+		UserID: userID,
+	})
+
+	if err != nil {
+		return database.GetTripRow{}, err
+	}
+
+	return cfg.db.GetTrip(ctx, database.GetTripParams{
+		UserID: userID,
+		ID:     tripID,
+	})
+}
+
+func (cfg apiConfig) handlerUpdatePlannedStop(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	plannedStopUUID, err := uuid.Parse(chi.URLParam(r, "plannedStopID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.plannedStopTrip(r.Context(), plannedStopUUID, user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find planned stop possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	if !isTripEditable(trip.Status) {
+		respondWithError(w, http.StatusConflict, "Only planned or active trips can be planned. Reopen the trip first.", errTripNotEditable, false)
+		return
+	}
+
+	params := &PlannedStopParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read planned stop details", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	location, err := cfg.resolveLocation(params.LocationTag)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not resolve planned stop location name", err, false)
+		return
+	}
+
+	var targetDate sql.NullTime
+	if params.TargetDate != nil {
+		targetDate = sql.NullTime{Time: params.TargetDate.UTC(), Valid: true}
+	}
+
+	plannedStopID, err := cfg.db.UpdatePlannedStop(r.Context(), database.UpdatePlannedStopParams{
+		LocationName: location.Name,
+		LocationTag:  utils.FormatPoint(location.Location),
+		TargetDate:   targetDate,
+		ID:           plannedStopUUID,
+		UserID:       user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find planned stop to update", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: struct {
+			PlannedStopID uuid.UUID `json:"plannedStopID"`
+		}{
+			PlannedStopID: plannedStopID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerDeletePlannedStop(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	plannedStopUUID, err := uuid.Parse(chi.URLParam(r, "plannedStopID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.plannedStopTrip(r.Context(), plannedStopUUID, user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find planned stop possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	if !isTripEditable(trip.Status) {
+		respondWithError(w, http.StatusConflict, "Only planned or active trips can be planned. Reopen the trip first.", errTripNotEditable, false)
+		return
+	}
+
+	deleted, err := cfg.db.DeletePlannedStop(r.Context(), database.DeletePlannedStopParams{
+		ID:     plannedStopUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete planned stop", err, false)
+		return
+	}
+
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Unable to find planned stop possibly deleted", sql.ErrNoRows, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
+
+func (cfg apiConfig) handlerGetPlanDiff(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	plannedStops, err := cfg.db.GetPlannedStops(r.Context(), database.GetPlannedStopsParams{
+		TripID: trip.ID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trip plan", err, false)
+		return
+	}
+
+	unplannedStops, err := cfg.db.GetUnplannedStops(r.Context(), database.GetUnplannedStopsParams{
+		TripID: trip.ID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return unplanned stops", err, false)
+		return
+	}
+
+	distances, err := cfg.db.GetPlanDistances(r.Context(), database.GetPlanDistancesParams{
+		ID:     trip.ID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to compute plan distances", err, false)
+		return
+	}
+
+	tripFinished := trip.Status == database.TripStatusCompleted || trip.Status == database.TripStatusArchived
+
+	diff := PlanDiffResponse{
+		Planned:         make([]PlanDiffStopResponse, 0, len(plannedStops)),
+		Added:           make([]PlanDiffAddedStopResponse, 0, len(unplannedStops)),
+		PlannedDistance: math.Round(distances.PlannedDistance),
+		ActualDistance:  math.Round(distances.ActualDistance),
+		ExtraDistance:   math.Round(distances.ActualDistance - distances.PlannedDistance),
+	}
+
+	for _, plannedStop := range plannedStops {
+		item := PlanDiffStopResponse{
+			PlannedStopResponse: convertToPlannedStopResponse(plannedStop),
+			ActualLocationName:  plannedStop.ActualLocationName,
+			ActualDate:          plannedStop.ActualDate,
+			OffsetDistance:      plannedStop.OffsetDistance,
+		}
+
+		// The matched stop is only joined while it is live, so a match whose
+		// stop is in the trash has no actual date and does not count.
+		visited := plannedStop.MatchedStopID.Valid && plannedStop.ActualDate.Valid
+
+		switch {
+		case visited && plannedStop.TargetDate.Valid:
+			delay := plannedStop.ActualDate.Time.Sub(plannedStop.TargetDate.Time)
+			item.DelayHours = sql.NullFloat64{Float64: math.Round(delay.Hours()*10) / 10, Valid: true}
+			item.Status = "visited"
+			diff.Visited++
+
+			if delay > planDelayTolerance {
+				item.Status = "delayed"
+				diff.Delayed++
+			}
+		case visited:
+			item.Status = "visited"
+			diff.Visited++
+		case tripFinished || (plannedStop.TargetDate.Valid && time.Since(plannedStop.TargetDate.Time) > planMatchWindow):
+			item.Status = "skipped"
+			diff.Skipped++
+		default:
+			item.Status = "pending"
+			diff.Pending++
+		}
+
+		diff.Planned = append(diff.Planned, item)
+	}
+
+	for _, stop := range unplannedStops {
+		diff.Added = append(diff.Added, PlanDiffAddedStopResponse{
+			ID:           stop.ID,
+			LocationName: stop.LocationName,
+			CreatedAt:    stop.CreatedAt,
+			Lat:          stop.Lat,
+			Lng:          stop.Lng,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   diff,
+	})
+}
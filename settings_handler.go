@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+)
+
+type SettingsParams struct {
+	HomeCurrency      string `json:"homeCurrency" validate:"required,len=3"`
+	KeepPhotoLocation *bool  `json:"keepPhotoLocation"`
+}
+
+type SettingsResponse struct {
+	HomeCurrency      string `json:"homeCurrency"`
+	KeepPhotoLocation bool   `json:"keepPhotoLocation"`
+}
+
+func (cfg apiConfig) handlerGetSettings(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: SettingsResponse{
+			HomeCurrency:      user.HomeCurrency,
+			KeepPhotoLocation: user.KeepPhotoLocation,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	params := &SettingsParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read settings", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	homeCurrency, err := parseCurrencyCode(params.HomeCurrency)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err, false)
+		return
+	}
+
+	keepPhotoLocation := sql.NullBool{}
+
+	if params.KeepPhotoLocation != nil {
+		keepPhotoLocation = sql.NullBool{Bool: *params.KeepPhotoLocation, Valid: true}
+	}
+
+	settings, err := cfg.db.UpdateSettings(r.Context(), database.UpdateSettingsParams{
+		HomeCurrency:      homeCurrency,
+		KeepPhotoLocation: keepPhotoLocation,
+		ID:                user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update settings", err, false)
+		return
+	}
+
+	// Turning location off also forgets the positions already stored, so
+	// the setting covers every photo rather than just new uploads.
+	if !settings.KeepPhotoLocation {
+		if _, err := cfg.db.ClearMediaLocations(r.Context(), user.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to remove stored photo locations", err, false)
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: SettingsResponse{
+			HomeCurrency:      settings.HomeCurrency,
+			KeepPhotoLocation: settings.KeepPhotoLocation,
+		},
+	})
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/utils"
+)
+
+var (
+	errShareExpiryPast = errors.New("share expiry must be in the future")
+	errShareNotFound   = errors.New("share link is invalid, revoked or expired")
+)
+
+type ShareParams struct {
+	ExpiresAt       *time.Time `json:"expiresAt,omitempty"`
+	HideCoordinates bool       `json:"hideCoordinates"`
+}
+
+type ShareResponse struct {
+	ID              uuid.UUID    `json:"id"`
+	Token           string       `json:"token"`
+	URL             string       `json:"url"`
+	HideCoordinates bool         `json:"hideCoordinates"`
+	Active          bool         `json:"active"`
+	ExpiresAt       sql.NullTime `json:"expiresAt"`
+	RevokedAt       sql.NullTime `json:"revokedAt"`
+	CreatedBy       string       `json:"createdBy"`
+	CreatedAt       time.Time    `json:"createdAt"`
+}
+
+type SharedMediaResponse struct {
+	PhotoURL string `json:"photoUrl,omitempty"`
+	VideoURL string `json:"videoUrl,omitempty"`
+}
+
+type SharedStopResponse struct {
+	LocationName   string                `json:"locationName"`
+	CountryCode    sql.NullString        `json:"countryCode"`
+	Region         sql.NullString        `json:"region"`
+	ArrivedAt      sql.NullTime          `json:"arrivedAt"`
+	ArrivedAtLocal sql.NullString        `json:"arrivedAtLocal"`
+	DepartedAt     sql.NullTime          `json:"departedAt"`
+	Lat            interface{}           `json:"lat,omitempty"`
+	Lng            interface{}           `json:"lng,omitempty"`
+	Media          []SharedMediaResponse `json:"media"`
+}
+
+// SharedTripResponse is the public view of a trip behind a share link. It
+// leaves out every ID and, when the link hides coordinates, every point.
+type SharedTripResponse struct {
+	TripTitle         string                `json:"tripTitle"`
+	StartLocationName string                `json:"startLocationName"`
+	EndLocationName   sql.NullString        `json:"endLocationName"`
+	StartDate         time.Time             `json:"startDate"`
+	StartDateLocal    string                `json:"startDateLocal"`
+	EndDate           sql.NullTime          `json:"endDate"`
+	EndDateLocal      sql.NullString        `json:"endDateLocal"`
+	Status            string                `json:"status"`
+	DistanceTravelled sql.NullFloat64       `json:"distanceTravelled"`
+	StartCountryCode  sql.NullString        `json:"startCountryCode"`
+	EndCountryCode    sql.NullString        `json:"endCountryCode"`
+	StartLat          interface{}           `json:"startLat,omitempty"`
+	StartLng          interface{}           `json:"startLng,omitempty"`
+	EndLat            interface{}           `json:"endLat,omitempty"`
+	EndLng            interface{}           `json:"endLng,omitempty"`
+	HideCoordinates   bool                  `json:"hideCoordinates"`
+	Media             []SharedMediaResponse `json:"media"`
+	Stops             []SharedStopResponse  `json:"stops"`
+}
+
+func (cfg apiConfig) shareURL(token string) string {
+	return fmt.Sprintf("%sshared/%s", cfg.frontEndURL, token)
+}
+
+func (cfg apiConfig) convertToSharedMedia(medium database.GetSharedMediaRow) SharedMediaResponse {
+	return SharedMediaResponse{
+		PhotoURL: cfg.signMediaURL(medium.PhotoUrl.String),
+		VideoURL: cfg.signMediaURL(medium.VideoUrl.String),
+	}
+}
+
+func (cfg apiConfig) handlerGetTripShares(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	shares, err := cfg.db.GetTripShares(r.Context(), trip.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return share links", err, false)
+		return
+	}
+
+	now := time.Now()
+	sharesResponse := make([]ShareResponse, 0, len(shares))
+
+	for _, share := range shares {
+		sharesResponse = append(sharesResponse, ShareResponse{
+			ID:              share.ID,
+			Token:           share.Token,
+			URL:             cfg.shareURL(share.Token),
+			HideCoordinates: share.HideCoordinates,
+			Active:          !share.RevokedAt.Valid && (!share.ExpiresAt.Valid || share.ExpiresAt.Time.After(now)),
+			ExpiresAt:       share.ExpiresAt,
+			RevokedAt:       share.RevokedAt,
+			CreatedBy:       share.CreatedBy,
+			CreatedAt:       share.CreatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   sharesResponse,
+	})
+}
+
+func (cfg apiConfig) handlerCreateTripShare(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	params := &ShareParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read share details", err, false)
+		return
+	}
+
+	var expiresAt sql.NullTime
+	if params.ExpiresAt != nil {
+		if !params.ExpiresAt.After(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "Share links must expire in the future", errShareExpiryPast, false)
+			return
+		}
+
+		expiresAt = sql.NullTime{Time: params.ExpiresAt.UTC(), Valid: true}
+	}
+
+	token, err := utils.Random32Generator()
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	shareID, err := cfg.db.CreateTripShare(r.Context(), database.CreateTripShareParams{
+		TripID:          trip.ID,
+		UserID:          user.ID,
+		Token:           token,
+		HideCoordinates: params.HideCoordinates,
+		ExpiresAt:       expiresAt,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create share link", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data: ShareResponse{
+			ID:              shareID,
+			Token:           token,
+			URL:             cfg.shareURL(token),
+			HideCoordinates: params.HideCoordinates,
+			Active:          true,
+			ExpiresAt:       expiresAt,
+			CreatedBy:       user.Username,
+			CreatedAt:       time.Now().UTC(),
+		},
+	})
+}
+
+func (cfg apiConfig) handlerRevokeTripShare(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	shareUUID, err := uuid.Parse(chi.URLParam(r, "shareID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	shareID, err := cfg.db.RevokeTripShare(r.Context(), database.RevokeTripShareParams{
+		ID:     shareUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find an active share link", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: struct {
+			ShareID uuid.UUID `json:"shareID"`
+		}{
+			ShareID: shareID,
+		},
+	})
+}
+
+// handlerGetSharedTrip serves a trip to anyone holding a valid share token.
+// It is the only trip endpoint that does not require authentication.
+func (cfg apiConfig) handlerGetSharedTrip(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetSharedTrip(r.Context(), chi.URLParam(r, "token"))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "This share link is invalid or has expired", errShareNotFound, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return shared trip", err, false)
+		return
+	}
+
+	stops, err := cfg.db.GetSharedStops(r.Context(), database.GetSharedStopsParams{
+		HideCoordinates: trip.HideCoordinates,
+		TripID:          trip.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return shared trip stops", err, false)
+		return
+	}
+
+	media, err := cfg.db.GetSharedMedia(r.Context(), trip.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return shared trip media", err, false)
+		return
+	}
+
+	tripMedia := make([]SharedMediaResponse, 0)
+	stopMedia := make(map[uuid.UUID][]SharedMediaResponse)
+
+	for _, medium := range media {
+		if medium.TripStopID.Valid {
+			stopMedia[medium.TripStopID.UUID] = append(stopMedia[medium.TripStopID.UUID], cfg.convertToSharedMedia(medium))
+			continue
+		}
+
+		tripMedia = append(tripMedia, cfg.convertToSharedMedia(medium))
+	}
+
+	stopsResponse := make([]SharedStopResponse, 0, len(stops))
+
+	for _, stop := range stops {
+		stopResponse := SharedStopResponse{
+			LocationName:   stop.LocationName,
+			CountryCode:    stop.CountryCode,
+			Region:         stop.Region,
+			ArrivedAt:      stop.ArrivedAt,
+			ArrivedAtLocal: formatNullLocalTime(stop.ArrivedAt, sql.NullString{String: stop.Timezone, Valid: true}),
+			DepartedAt:     stop.DepartedAt,
+			Lat:            stop.Lat,
+			Lng:            stop.Lng,
+			Media:          make([]SharedMediaResponse, 0),
+		}
+
+		if media, ok := stopMedia[stop.ID]; ok {
+			stopResponse.Media = media
+		}
+
+		stopsResponse = append(stopsResponse, stopResponse)
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: SharedTripResponse{
+			TripTitle:         trip.TripTitle,
+			StartLocationName: trip.StartLocationName,
+			EndLocationName:   trip.EndLocationName,
+			StartDate:         trip.StartDate.UTC(),
+			StartDateLocal:    formatLocalTime(trip.StartDate, trip.StartTimezone),
+			EndDate:           trip.EndDate,
+			EndDateLocal:      formatNullLocalTime(trip.EndDate, trip.EndTimezone),
+			Status:            string(trip.Status),
+			DistanceTravelled: trip.DistanceTravelled,
+			StartCountryCode:  trip.StartCountryCode,
+			EndCountryCode:    trip.EndCountryCode,
+			StartLat:          trip.StartLat,
+			StartLng:          trip.StartLng,
+			EndLat:            trip.EndLat,
+			EndLng:            trip.EndLng,
+			HideCoordinates:   trip.HideCoordinates,
+			Media:             tripMedia,
+			Stops:             stopsResponse,
+		},
+	})
+}
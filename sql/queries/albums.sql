@@ -0,0 +1,69 @@
+-- name: CreateAlbum :one
+INSERT INTO albums (user_id, title, description)
+VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: UpdateAlbum :one
+UPDATE albums
+SET title = $1, description = $2, updated_at = NOW()
+WHERE id = $3 AND user_id = $4
+RETURNING id;
+
+-- name: DeleteAlbum :execrows
+DELETE FROM albums
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetAlbum :one
+SELECT id, user_id, title, description, created_at, updated_at
+FROM albums
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetAlbums :many
+SELECT
+    al.id,
+    al.title,
+    al.description,
+    al.created_at,
+    al.updated_at,
+    COUNT(m.id)::INTEGER AS media_count,
+    (array_agg(m.photo_url ORDER BY am.position) FILTER (WHERE m.photo_url IS NOT NULL))[1]::VARCHAR AS cover_photo_url
+FROM albums al
+LEFT JOIN album_media am ON am.album_id = al.id
+LEFT JOIN trip_media m ON m.id = am.trip_media_id AND m.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = al.user_id
+      AND a.trip_id IN (m.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = m.trip_stop_id AND s.deleted_at IS NULL))
+)
+WHERE al.user_id = $1
+GROUP BY al.id
+ORDER BY al.updated_at DESC;
+
+-- name: GetAlbumMedia :many
+SELECT m.id, m.trip_id, m.trip_stop_id, m.photo_url, m.video_url, m.created_at, m.updated_at, m.user_id, m.deleted_at, m.duration_seconds, m.width, m.height, m.taken_at, m.location, m.latitude, m.longitude, m.caption, m.alt_text, m.sort_order, m.favourite
+FROM album_media am
+JOIN albums al ON al.id = am.album_id
+JOIN trip_media m ON m.id = am.trip_media_id
+WHERE am.album_id = $1 AND m.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = al.user_id
+      AND a.trip_id IN (m.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = m.trip_stop_id AND s.deleted_at IS NULL))
+)
+ORDER BY am.position;
+
+-- name: CountViewableMedia :one
+SELECT COUNT(*)
+FROM trip_media m
+WHERE m.id = ANY(sqlc.arg(media_ids)::uuid[]) AND m.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = sqlc.arg(user_id)
+      AND a.trip_id IN (m.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = m.trip_stop_id AND s.deleted_at IS NULL))
+);
+
+-- name: DeleteAlbumMedia :exec
+DELETE FROM album_media
+WHERE album_id = $1;
+
+-- name: AddAlbumMedia :exec
+INSERT INTO album_media (album_id, trip_media_id, position)
+SELECT sqlc.arg(album_id), added.id, added.position - 1
+FROM unnest(sqlc.arg(media_ids)::uuid[]) WITH ORDINALITY AS added(id, position);
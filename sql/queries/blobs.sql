@@ -0,0 +1,65 @@
+-- name: AcquireMediaBlob :one
+INSERT INTO media_blobs (storage_key, user_id, sha256, size, ref_count)
+VALUES ($1, $2, $3, $4, 1)
+ON CONFLICT (user_id, sha256) DO UPDATE
+SET ref_count = media_blobs.ref_count + 1, updated_at = NOW()
+RETURNING storage_key, ref_count;
+
+-- name: ReleaseMediaBlob :one
+UPDATE media_blobs
+SET ref_count = ref_count - 1, updated_at = NOW()
+WHERE storage_key = $1 AND ref_count > 0
+RETURNING ref_count;
+
+-- name: GetUnreferencedMediaBlobs :many
+SELECT storage_key FROM media_blobs
+WHERE ref_count = 0;
+
+-- name: LockUnreferencedMediaBlob :one
+SELECT storage_key FROM media_blobs
+WHERE storage_key = $1 AND ref_count = 0
+FOR UPDATE;
+
+-- name: DeleteMediaBlob :exec
+DELETE FROM media_blobs
+WHERE storage_key = $1 AND ref_count = 0;
+
+-- name: GetMediaBlobKeys :many
+SELECT storage_key FROM media_blobs;
+
+-- name: GetStorageUsed :one
+SELECT COALESCE(SUM(size), 0)::BIGINT AS used
+FROM media_blobs
+WHERE user_id = $1;
+
+-- name: GetStorageUsageByTrip :many
+WITH trip_blobs AS (
+    SELECT DISTINCT COALESCE(m.trip_id, s.trip_id) AS trip_id, b.storage_key, b.size
+    FROM trip_media m
+    LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+    JOIN media_blobs b ON b.storage_key = split_part(COALESCE(m.photo_url, m.video_url), '/assets/', 2)
+    WHERE m.user_id = $1
+)
+SELECT t.id AS trip_id, t.trip_title, COUNT(*)::INTEGER AS files, COALESCE(SUM(tb.size), 0)::BIGINT AS bytes
+FROM trip_blobs tb
+JOIN trips t ON t.id = tb.trip_id
+GROUP BY t.id, t.trip_title
+ORDER BY bytes DESC, t.trip_title;
+
+-- name: GetUnsizedMediaBlobs :many
+SELECT storage_key FROM media_blobs
+WHERE size IS NULL;
+
+-- name: SetMediaBlobSize :exec
+UPDATE media_blobs
+SET size = $1, updated_at = NOW()
+WHERE storage_key = $2;
+
+-- name: GetUnstrippedMediaBlobs :many
+SELECT storage_key FROM media_blobs
+WHERE stripped_at IS NULL;
+
+-- name: SetMediaBlobStripped :exec
+UPDATE media_blobs
+SET stripped_at = NOW(), size = COALESCE($1, size), updated_at = NOW()
+WHERE storage_key = $2;
@@ -0,0 +1,125 @@
+-- name: CreateExpense :one
+INSERT INTO trip_expenses (
+    trip_id,
+    trip_stop_id,
+    user_id,
+    amount,
+    currency,
+    category,
+    spent_on,
+    payer,
+    description
+)
+SELECT
+    sqlc.arg(trip_id)::uuid,
+    sqlc.narg(trip_stop_id)::uuid,
+    sqlc.arg(user_id)::uuid,
+    sqlc.arg(amount)::NUMERIC,
+    sqlc.arg(currency)::CHAR(3),
+    sqlc.arg(category)::expense_category,
+    sqlc.arg(spent_on)::DATE,
+    sqlc.narg(payer)::VARCHAR,
+    sqlc.narg(description)::VARCHAR
+WHERE sqlc.narg(trip_stop_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM trip_stop
+    WHERE id = sqlc.narg(trip_stop_id)::uuid AND trip_id = sqlc.arg(trip_id)::uuid AND deleted_at IS NULL
+)
+RETURNING id;
+
+-- name: UpdateExpense :one
+UPDATE trip_expenses
+SET
+    trip_stop_id = sqlc.narg(trip_stop_id),
+    amount = sqlc.arg(amount),
+    currency = sqlc.arg(currency),
+    category = sqlc.arg(category),
+    spent_on = sqlc.arg(spent_on),
+    payer = sqlc.narg(payer),
+    description = sqlc.narg(description),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_expenses.trip_id AND a.user_id = sqlc.arg(user_id) AND a.role IN ('owner', 'editor')
+    )
+    AND (sqlc.narg(trip_stop_id)::uuid IS NULL OR EXISTS (
+        SELECT 1 FROM trip_stop s
+        WHERE s.id = sqlc.narg(trip_stop_id)::uuid AND s.trip_id = trip_expenses.trip_id AND s.deleted_at IS NULL
+    ))
+RETURNING id;
+
+-- name: DeleteExpense :exec
+DELETE FROM trip_expenses
+WHERE id = $1 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = trip_expenses.trip_id AND a.user_id = $2 AND a.role IN ('owner', 'editor')
+);
+
+-- name: GetExpense :one
+SELECT
+    id,
+    trip_expenses.trip_id,
+    trip_stop_id,
+    amount::FLOAT8 AS amount,
+    currency,
+    category,
+    spent_on,
+    payer,
+    description,
+    created_at,
+    updated_at,
+    a.role
+FROM trip_expenses
+JOIN trip_access a ON a.trip_id = trip_expenses.trip_id AND a.user_id = $2
+WHERE id = $1;
+
+-- name: GetTripExpenses :many
+SELECT
+    id,
+    trip_id,
+    trip_stop_id,
+    amount::FLOAT8 AS amount,
+    currency,
+    category,
+    spent_on,
+    payer,
+    description,
+    created_at,
+    updated_at
+FROM trip_expenses
+WHERE trip_id = $1 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = trip_expenses.trip_id AND a.user_id = $2
+)
+ORDER BY spent_on, created_at;
+
+-- name: GetTripExpenseTotals :many
+SELECT
+    category,
+    currency,
+    payer,
+    SUM(amount)::FLOAT8 AS total,
+    COUNT(*) AS expenses
+FROM trip_expenses
+WHERE trip_id = $1 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = trip_expenses.trip_id AND a.user_id = $2
+)
+GROUP BY category, currency, payer
+ORDER BY category, currency, payer;
+
+-- name: GetTripBudget :one
+SELECT budget_amount::FLOAT8 AS budget_amount, budget_currency
+FROM trips
+WHERE id = $1 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = trips.id AND a.user_id = $2
+);
+
+-- name: SetTripBudget :one
+UPDATE trips
+SET budget_amount = sqlc.narg(budget_amount), budget_currency = sqlc.narg(budget_currency), updated_at = NOW()
+WHERE id = sqlc.arg(id) AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = trips.id AND a.user_id = sqlc.arg(user_id) AND a.role IN ('owner', 'editor')
+)
+RETURNING id;
@@ -0,0 +1,126 @@
+-- name: CreateJournalEntry :one
+INSERT INTO journal_entries (
+    trip_id,
+    trip_stop_id,
+    user_id,
+    title,
+    body,
+    body_html,
+    entry_date,
+    location_name,
+    location_tag
+)
+SELECT
+    sqlc.arg(trip_id)::uuid,
+    sqlc.narg(trip_stop_id)::uuid,
+    sqlc.arg(user_id)::uuid,
+    sqlc.narg(title)::VARCHAR,
+    sqlc.arg(body)::TEXT,
+    sqlc.arg(body_html)::TEXT,
+    sqlc.arg(entry_date)::DATE,
+    sqlc.narg(location_name)::VARCHAR,
+    sqlc.narg(location_tag)::GEOGRAPHY
+WHERE sqlc.narg(trip_stop_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM trip_stop
+    WHERE id = sqlc.narg(trip_stop_id)::uuid AND trip_id = sqlc.arg(trip_id)::uuid AND deleted_at IS NULL
+)
+RETURNING id;
+
+-- name: UpdateJournalEntry :one
+UPDATE journal_entries
+SET
+    trip_stop_id = sqlc.narg(trip_stop_id),
+    title = sqlc.narg(title),
+    body = sqlc.arg(body),
+    body_html = sqlc.arg(body_html),
+    entry_date = sqlc.arg(entry_date),
+    location_name = sqlc.narg(location_name),
+    location_tag = sqlc.narg(location_tag),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = journal_entries.trip_id AND a.user_id = sqlc.arg(user_id) AND a.role IN ('owner', 'editor')
+    )
+    AND (sqlc.narg(trip_stop_id)::uuid IS NULL OR EXISTS (
+        SELECT 1 FROM trip_stop s
+        WHERE s.id = sqlc.narg(trip_stop_id)::uuid AND s.trip_id = journal_entries.trip_id AND s.deleted_at IS NULL
+    ))
+RETURNING id;
+
+-- name: DeleteJournalEntry :exec
+DELETE FROM journal_entries
+WHERE id = $1 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = journal_entries.trip_id AND a.user_id = $2 AND a.role IN ('owner', 'editor')
+);
+
+-- name: GetJournalEntries :many
+SELECT
+    id,
+    trip_id,
+    trip_stop_id,
+    title,
+    body,
+    body_html,
+    entry_date,
+    location_name,
+    created_at,
+    updated_at,
+    ST_Y(location_tag::geometry) AS lat,
+    ST_X(location_tag::geometry) AS lng
+FROM journal_entries
+WHERE trip_id = sqlc.arg(trip_id) AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = journal_entries.trip_id AND a.user_id = sqlc.arg(user_id)
+    )
+    AND (sqlc.narg(trip_stop_id)::uuid IS NULL OR trip_stop_id = sqlc.narg(trip_stop_id)::uuid)
+ORDER BY entry_date, created_at;
+
+-- name: GetJournalEntry :one
+SELECT
+    id,
+    journal_entries.trip_id,
+    trip_stop_id,
+    title,
+    body,
+    body_html,
+    entry_date,
+    location_name,
+    created_at,
+    updated_at,
+    ST_Y(location_tag::geometry) AS lat,
+    ST_X(location_tag::geometry) AS lng,
+    a.role
+FROM journal_entries
+JOIN trip_access a ON a.trip_id = journal_entries.trip_id AND a.user_id = $2
+WHERE id = $1;
+
+-- name: CountTripMedia :one
+SELECT COUNT(*)
+FROM trip_media m
+LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+WHERE m.id = ANY(sqlc.arg(media_ids)::uuid[])
+    AND COALESCE(m.trip_id, s.trip_id) = sqlc.arg(trip_id)
+    AND m.deleted_at IS NULL AND s.deleted_at IS NULL;
+
+-- name: DeleteJournalEntryMedia :exec
+DELETE FROM journal_entry_media
+WHERE journal_entry_id = $1;
+
+-- name: AddJournalEntryMedia :exec
+INSERT INTO journal_entry_media (journal_entry_id, trip_media_id, position)
+SELECT sqlc.arg(journal_entry_id), linked.id, linked.position - 1
+FROM unnest(sqlc.arg(media_ids)::uuid[]) WITH ORDINALITY AS linked(id, position);
+
+-- name: GetJournalEntryMedia :many
+SELECT
+    jm.journal_entry_id,
+    m.id,
+    m.photo_url,
+    m.video_url
+FROM journal_entry_media jm
+JOIN trip_media m ON m.id = jm.trip_media_id
+LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+WHERE jm.journal_entry_id = ANY(sqlc.arg(journal_entry_ids)::uuid[])
+    AND m.deleted_at IS NULL AND s.deleted_at IS NULL
+ORDER BY jm.journal_entry_id, jm.position;
@@ -1,29 +1,94 @@
 -- name: CreateTripMedia :one
-INSERT INTO trip_media(trip_id, trip_stop_id, photo_url, video_url, user_id)
+INSERT INTO trip_media(trip_id, trip_stop_id, photo_url, video_url, user_id, duration_seconds, width, height, taken_at, location)
 VALUES(
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
 )
-RETURNING *;
+RETURNING id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id, deleted_at, duration_seconds, width, height, taken_at, location, latitude, longitude, caption, alt_text, sort_order, favourite;
 
--- name: UpdateTripMedia :one
+-- name: UpdateMediaDetails :one
 UPDATE trip_media
-SET photo_url = $1, video_url = $2, updated_at = NOW()
-WHERE id = $3 AND user_id = $4
-RETURNING id;
+SET caption = $1, alt_text = $2, favourite = $3, updated_at = NOW()
+WHERE id = $4 AND deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $5 AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
+RETURNING id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id, deleted_at, duration_seconds, width, height, taken_at, location, latitude, longitude, caption, alt_text, sort_order, favourite;
 
--- name: DeleteTripMedia :exec
-DELETE FROM trip_media
-WHERE id = $1 AND user_id = $2;
+-- name: ReorderMedia :execrows
+UPDATE trip_media
+SET sort_order = ordered.position, updated_at = NOW()
+FROM unnest(sqlc.arg(media_ids)::uuid[]) WITH ORDINALITY AS ordered(id, position)
+WHERE trip_media.id = ordered.id AND trip_media.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = sqlc.arg(user_id) AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+);
+
+-- name: TrashTripMedia :execrows
+UPDATE trip_media
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $2 AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+);
 
 -- name: GetTripMediaById :one
-SELECT * FROM trip_media
-WHERE id = $1 AND user_id = $2;
+SELECT id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id, deleted_at, duration_seconds, width, height, taken_at, location, latitude, longitude, caption, alt_text, sort_order, favourite FROM trip_media
+WHERE id = $1 AND deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $2
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+);
 
 -- name: GetTripMediaByTripOrStopID :many
-SELECT * FROM trip_media
-WHERE trip_id = $1 OR trip_stop_id = $2 AND user_id = $3;
+SELECT id, trip_id, trip_stop_id, photo_url, video_url, created_at, updated_at, user_id, deleted_at, duration_seconds, width, height, taken_at, location, latitude, longitude, caption, alt_text, sort_order, favourite FROM trip_media
+WHERE (trip_id = $1 OR trip_stop_id = $2) AND deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $3
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
+ORDER BY sort_order NULLS LAST, created_at;
+
+-- name: GetTrashedMedia :many
+SELECT id, trip_id, trip_stop_id, photo_url, video_url, deleted_at::TIMESTAMPTZ AS deleted_at
+FROM trip_media
+WHERE deleted_at IS NOT NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $1 AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
+ORDER BY deleted_at DESC;
 
+-- name: RestoreTripMedia :one
+UPDATE trip_media
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.user_id = $2 AND a.role IN ('owner', 'editor')
+      AND a.trip_id IN (trip_media.trip_id, (SELECT s.trip_id FROM trip_stop s WHERE s.id = trip_media.trip_stop_id AND s.deleted_at IS NULL))
+)
+RETURNING id;
+
+-- name: FindNearestStop :one
+SELECT s.id, s.location_name, ST_Distance(s.location_tag, sqlc.arg(point)::geography)::FLOAT8 AS distance
+FROM trip_stop s
+WHERE s.trip_id = sqlc.arg(trip_id) AND s.deleted_at IS NULL
+    AND ST_DWithin(s.location_tag, sqlc.arg(point)::geography, sqlc.arg(radius)::FLOAT8)
+ORDER BY distance, s.sequence
+LIMIT 1;
+
+-- name: ClearMediaLocations :execrows
+UPDATE trip_media
+SET location = NULL, updated_at = NOW()
+WHERE user_id = $1 AND location IS NOT NULL;
@@ -0,0 +1,56 @@
+-- name: CreateTripMember :one
+INSERT INTO trip_members (trip_id, user_id, invited_by, role)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (trip_id, user_id) DO NOTHING
+RETURNING id;
+
+-- name: GetTripMembers :many
+SELECT
+    m.id,
+    m.user_id,
+    u.username,
+    m.role,
+    m.accepted_at,
+    m.created_at
+FROM trip_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.trip_id = $1
+ORDER BY m.created_at;
+
+-- name: UpdateTripMemberRole :one
+UPDATE trip_members
+SET role = sqlc.arg(role), updated_at = NOW()
+WHERE id = sqlc.arg(id) AND trip_id = sqlc.arg(trip_id)
+RETURNING id;
+
+-- name: DeleteTripMember :execrows
+DELETE FROM trip_members
+WHERE id = sqlc.arg(id) AND trip_id = sqlc.arg(trip_id)
+    AND (user_id = sqlc.arg(user_id) OR EXISTS (
+        SELECT 1 FROM trips t
+        WHERE t.id = trip_members.trip_id AND t.user_id = sqlc.arg(user_id)
+    ));
+
+-- name: GetInvites :many
+SELECT
+    m.id,
+    m.trip_id,
+    t.trip_title,
+    m.role,
+    u.username AS invited_by,
+    m.created_at
+FROM trip_members m
+JOIN trips t ON t.id = m.trip_id
+JOIN users u ON u.id = m.invited_by
+WHERE m.user_id = $1 AND m.accepted_at IS NULL AND t.deleted_at IS NULL
+ORDER BY m.created_at DESC;
+
+-- name: AcceptInvite :one
+UPDATE trip_members
+SET accepted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND accepted_at IS NULL
+RETURNING trip_id;
+
+-- name: DeclineInvite :execrows
+DELETE FROM trip_members
+WHERE id = $1 AND user_id = $2 AND accepted_at IS NULL;
@@ -0,0 +1,118 @@
+-- name: CreatePlannedStop :one
+INSERT INTO planned_stops (
+    trip_id,
+    user_id,
+    location_name,
+    location_tag,
+    target_date,
+    position
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    COALESCE((SELECT MAX(position) + 1 FROM planned_stops WHERE trip_id = $1), 0)
+)
+RETURNING id;
+
+-- name: UpdatePlannedStop :one
+UPDATE planned_stops
+SET location_name = $1, location_tag = $2, target_date = $3, updated_at = NOW()
+WHERE id = $4 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = planned_stops.trip_id AND a.user_id = $5 AND a.role IN ('owner', 'editor')
+)
+RETURNING id;
+
+-- name: GetPlannedStopTrip :one
+SELECT p.trip_id FROM planned_stops p
+WHERE p.id = $1 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = p.trip_id AND a.user_id = $2
+);
+
+-- name: DeletePlannedStop :execrows
+DELETE FROM planned_stops
+WHERE id = $1 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = planned_stops.trip_id AND a.user_id = $2 AND a.role IN ('owner', 'editor')
+);
+
+-- name: GetPlannedStops :many
+SELECT
+    p.id,
+    p.location_name,
+    p.target_date,
+    p.position,
+    p.matched_stop_id,
+    ST_Y(p.location_tag::geometry) AS lat,
+    ST_X(p.location_tag::geometry) AS lng,
+    s.location_name AS actual_location_name,
+    COALESCE(s.arrived_at, s.created_at)::TIMESTAMPTZ AS actual_date,
+    ST_Distance(p.location_tag, s.location_tag)::FLOAT8 AS offset_distance
+FROM planned_stops p
+LEFT JOIN trip_stop s ON s.id = p.matched_stop_id AND s.deleted_at IS NULL
+WHERE p.trip_id = $1 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = p.trip_id AND a.user_id = $2
+)
+ORDER BY p.position, p.created_at;
+
+-- name: GetUnplannedStops :many
+SELECT
+    s.id,
+    s.location_name,
+    s.created_at,
+    ST_Y(s.location_tag::geometry) AS lat,
+    ST_X(s.location_tag::geometry) AS lng
+FROM trip_stop s
+WHERE s.trip_id = $1 AND s.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = s.trip_id AND a.user_id = $2
+)
+    AND NOT EXISTS (SELECT 1 FROM planned_stops p WHERE p.matched_stop_id = s.id)
+ORDER BY s.sequence, s.created_at;
+
+-- name: GetPlanDistances :one
+SELECT
+    COALESCE((
+        SELECT ST_Length(ST_MakeLine(points.geom ORDER BY points.position)::geography)
+        FROM (
+            SELECT -1 AS position, t.start_location::geometry AS geom
+            UNION ALL
+            SELECT p.position, p.location_tag::geometry
+            FROM planned_stops p
+            WHERE p.trip_id = t.id
+        ) points
+        HAVING COUNT(*) > 1
+    ), 0)::FLOAT8 AS planned_distance,
+    ts.distance AS actual_distance
+FROM trips t
+JOIN trip_summaries ts ON ts.trip_id = t.id
+WHERE t.id = $1 AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = t.id AND a.user_id = $2
+);
+
+-- name: MatchPlannedStop :one
+UPDATE planned_stops
+SET matched_stop_id = s.id, updated_at = NOW()
+FROM trip_stop s
+WHERE s.id = sqlc.arg(stop_id) AND s.deleted_at IS NULL AND planned_stops.id = (
+    SELECT p.id
+    FROM planned_stops p
+    WHERE p.trip_id = s.trip_id
+        AND p.matched_stop_id IS NULL
+        AND ST_DWithin(p.location_tag, s.location_tag, sqlc.arg(radius)::FLOAT8)
+        AND (p.target_date IS NULL OR ABS(EXTRACT(EPOCH FROM (p.target_date - COALESCE(s.arrived_at, s.created_at)))) <= sqlc.arg(window_seconds)::FLOAT8)
+    ORDER BY ST_Distance(p.location_tag, s.location_tag), p.position
+    LIMIT 1
+)
+RETURNING planned_stops.id;
+
+-- name: UnmatchPlannedStop :exec
+UPDATE planned_stops
+SET matched_stop_id = NULL, updated_at = NOW()
+WHERE matched_stop_id = $1;
@@ -0,0 +1,76 @@
+-- name: CreateTripShare :one
+INSERT INTO trip_shares (trip_id, user_id, token, hide_coordinates, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: GetTripShares :many
+SELECT
+    sh.id,
+    sh.token,
+    sh.hide_coordinates,
+    sh.expires_at,
+    sh.revoked_at,
+    sh.created_at,
+    u.username AS created_by
+FROM trip_shares sh
+JOIN users u ON u.id = sh.user_id
+WHERE sh.trip_id = $1
+ORDER BY sh.created_at DESC;
+
+-- name: RevokeTripShare :one
+UPDATE trip_shares
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = sqlc.arg(id) AND revoked_at IS NULL AND EXISTS (
+    SELECT 1 FROM trip_access a
+    WHERE a.trip_id = trip_shares.trip_id AND a.user_id = sqlc.arg(user_id) AND a.role IN ('owner', 'editor')
+)
+RETURNING id;
+
+-- name: GetSharedTrip :one
+SELECT
+    t.id,
+    t.trip_title,
+    t.start_location_name,
+    t.end_location_name,
+    t.start_date,
+    t.end_date,
+    t.start_timezone,
+    t.end_timezone,
+    t.status,
+    t.distance_travelled,
+    t.start_country_code,
+    t.end_country_code,
+    CASE WHEN sh.hide_coordinates THEN NULL ELSE ST_Y(t.start_location::geometry) END AS start_lat,
+    CASE WHEN sh.hide_coordinates THEN NULL ELSE ST_X(t.start_location::geometry) END AS start_lng,
+    CASE WHEN sh.hide_coordinates THEN NULL ELSE ST_Y(t.end_location::geometry) END AS end_lat,
+    CASE WHEN sh.hide_coordinates THEN NULL ELSE ST_X(t.end_location::geometry) END AS end_lng,
+    sh.hide_coordinates
+FROM trip_shares sh
+JOIN trips t ON t.id = sh.trip_id
+WHERE sh.token = $1
+    AND t.deleted_at IS NULL
+    AND sh.revoked_at IS NULL
+    AND (sh.expires_at IS NULL OR sh.expires_at > NOW());
+
+-- name: GetSharedStops :many
+SELECT
+    s.id,
+    s.location_name,
+    s.country_code,
+    s.region,
+    s.timezone,
+    s.arrived_at,
+    s.departed_at,
+    CASE WHEN sqlc.arg(hide_coordinates)::bool THEN NULL ELSE ST_Y(s.location_tag::geometry) END AS lat,
+    CASE WHEN sqlc.arg(hide_coordinates)::bool THEN NULL ELSE ST_X(s.location_tag::geometry) END AS lng
+FROM trip_stop s
+WHERE s.trip_id = sqlc.arg(trip_id) AND s.deleted_at IS NULL
+ORDER BY s.sequence, s.created_at;
+
+-- name: GetSharedMedia :many
+SELECT m.id, m.trip_stop_id, m.photo_url, m.video_url
+FROM trip_media m
+LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+WHERE COALESCE(m.trip_id, s.trip_id) = sqlc.arg(trip_id)::uuid
+    AND m.deleted_at IS NULL AND s.deleted_at IS NULL
+ORDER BY m.created_at;
@@ -0,0 +1,127 @@
+-- name: GetTravelTotals :one
+SELECT
+    COUNT(*) AS total_trips,
+    COALESCE(SUM(distance), 0)::FLOAT8 AS total_distance,
+    COALESCE(SUM(days), 0)::BIGINT AS days_on_the_road,
+    COALESCE(SUM(stop_count), 0)::BIGINT AS total_stops,
+    COALESCE(SUM(photo_count), 0)::BIGINT AS total_photos
+FROM trip_summaries
+WHERE user_id = sqlc.arg(user_id)
+    AND (sqlc.narg(year)::int IS NULL OR EXTRACT(YEAR FROM start_date)::int = sqlc.narg(year)::int);
+
+-- name: GetLongestTrip :one
+SELECT trip_id, trip_title, start_date, end_date, distance, days
+FROM trip_summaries
+WHERE user_id = sqlc.arg(user_id)
+    AND (sqlc.narg(year)::int IS NULL OR EXTRACT(YEAR FROM start_date)::int = sqlc.narg(year)::int)
+ORDER BY distance DESC, days DESC
+LIMIT 1;
+
+-- name: GetMostVisitedPlaces :many
+SELECT
+    s.location_name,
+    COUNT(*) AS visits,
+    COUNT(DISTINCT s.trip_id) AS trips,
+    ST_Y(ST_Centroid(ST_Collect(s.location_tag::geometry))) AS lat,
+    ST_X(ST_Centroid(ST_Collect(s.location_tag::geometry))) AS lng
+FROM trip_stop s
+JOIN trips t ON t.id = s.trip_id
+WHERE t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL AND s.deleted_at IS NULL
+    AND (sqlc.narg(year)::int IS NULL OR EXTRACT(YEAR FROM t.start_date)::int = sqlc.narg(year)::int)
+GROUP BY s.location_name
+ORDER BY visits DESC, s.location_name
+LIMIT 10;
+
+-- name: GetYearlyTravelStats :many
+SELECT
+    EXTRACT(YEAR FROM start_date)::int AS year,
+    COUNT(*) AS trips,
+    COALESCE(SUM(distance), 0)::FLOAT8 AS distance,
+    COALESCE(SUM(days), 0)::BIGINT AS days,
+    COALESCE(SUM(photo_count), 0)::BIGINT AS photos
+FROM trip_summaries
+WHERE user_id = sqlc.arg(user_id)
+    AND (sqlc.narg(year)::int IS NULL OR EXTRACT(YEAR FROM start_date)::int = sqlc.narg(year)::int)
+GROUP BY 1
+ORDER BY 1;
+
+-- name: GetMonthlyTravelStats :many
+SELECT
+    EXTRACT(YEAR FROM start_date)::int AS year,
+    EXTRACT(MONTH FROM start_date)::int AS month,
+    COUNT(*) AS trips,
+    COALESCE(SUM(distance), 0)::FLOAT8 AS distance,
+    COALESCE(SUM(days), 0)::BIGINT AS days,
+    COALESCE(SUM(photo_count), 0)::BIGINT AS photos
+FROM trip_summaries
+WHERE user_id = sqlc.arg(user_id)
+    AND (sqlc.narg(year)::int IS NULL OR EXTRACT(YEAR FROM start_date)::int = sqlc.narg(year)::int)
+GROUP BY 1, 2
+ORDER BY 1, 2;
+
+-- name: GetVisitedCountries :many
+SELECT
+    visited.country_code::VARCHAR AS country_code,
+    COUNT(DISTINCT visited.trip_id) AS trips
+FROM (
+    SELECT t.id AS trip_id, t.start_date, t.start_country_code AS country_code
+    FROM trips t
+    WHERE t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL
+    UNION ALL
+    SELECT t.id, t.start_date, t.end_country_code
+    FROM trips t
+    WHERE t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL
+    UNION ALL
+    SELECT t.id, t.start_date, s.country_code
+    FROM trip_stop s
+    JOIN trips t ON t.id = s.trip_id
+    WHERE t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL AND s.deleted_at IS NULL
+) visited
+WHERE visited.country_code IS NOT NULL
+    AND (sqlc.narg(year)::int IS NULL OR EXTRACT(YEAR FROM visited.start_date)::int = sqlc.narg(year)::int)
+GROUP BY visited.country_code
+ORDER BY trips DESC, visited.country_code;
+
+-- name: GetTripLegs :many
+SELECT
+    legs.trip_id,
+    EXTRACT(YEAR FROM legs.start_date)::int AS year,
+    legs.transport_mode,
+    legs.distance
+FROM (
+    SELECT
+        t.id AS trip_id,
+        t.start_date,
+        COALESCE(s.transport_mode, t.transport_mode) AS transport_mode,
+        ST_Distance(
+            COALESCE(LAG(s.location_tag) OVER (PARTITION BY t.id ORDER BY s.sequence, s.created_at), t.start_location),
+            s.location_tag
+        )::FLOAT8 AS distance
+    FROM trip_stop s
+    JOIN trips t ON t.id = s.trip_id
+    WHERE s.deleted_at IS NULL AND t.id IN (
+        SELECT a.trip_id FROM trip_access a
+        WHERE a.user_id = sqlc.arg(user_id) AND (a.role = 'owner' OR NOT sqlc.arg(owned_only)::bool)
+    )
+    UNION ALL
+    SELECT
+        t.id,
+        t.start_date,
+        t.transport_mode,
+        ST_Distance(COALESCE(last_stop.location_tag, t.start_location), t.end_location)::FLOAT8
+    FROM trips t
+    LEFT JOIN LATERAL (
+        SELECT s.location_tag
+        FROM trip_stop s
+        WHERE s.trip_id = t.id AND s.deleted_at IS NULL
+        ORDER BY s.sequence DESC, s.created_at DESC
+        LIMIT 1
+    ) last_stop ON TRUE
+    WHERE t.id IN (
+        SELECT a.trip_id FROM trip_access a
+        WHERE a.user_id = sqlc.arg(user_id) AND (a.role = 'owner' OR NOT sqlc.arg(owned_only)::bool)
+    ) AND t.end_location IS NOT NULL
+) legs
+WHERE (sqlc.narg(trip_id)::uuid IS NULL OR legs.trip_id = sqlc.narg(trip_id)::uuid)
+    AND (sqlc.narg(year)::int IS NULL OR EXTRACT(YEAR FROM legs.start_date)::int = sqlc.narg(year)::int)
+ORDER BY legs.trip_id;
@@ -1,22 +1,70 @@
 -- name: GetStops :many
 SELECT
-    id,
-    location_name,
-    created_at,
-    ST_Y(location_tag::geometry) AS end_lat,
-    ST_X(location_tag::geometry) AS end_lng
-FROM trip_stop
-WHERE trip_id = $1 AND user_id = $2;
+    id, location_name, created_at, country_code, region, timezone, sequence, arrived_at, departed_at,
+    transport_mode, stop_duration, leg_distance, leg_travel_time, end_lat, end_lng, cover_media_id, cover_photo_url
+FROM (
+SELECT
+    s.id,
+    s.location_name,
+    s.created_at,
+    s.country_code,
+    s.region,
+    s.timezone,
+    s.sequence,
+    s.arrived_at,
+    s.departed_at,
+    s.transport_mode,
+    EXTRACT(EPOCH FROM (s.departed_at - s.arrived_at))::FLOAT8 AS stop_duration,
+    ST_Distance(
+        COALESCE(LAG(s.location_tag) OVER legs, t.start_location),
+        s.location_tag
+    )::FLOAT8 AS leg_distance,
+    EXTRACT(EPOCH FROM (
+        s.arrived_at - CASE
+            WHEN LAG(s.id) OVER legs IS NULL THEN t.start_date
+            ELSE LAG(s.departed_at) OVER legs
+        END
+    ))::FLOAT8 AS leg_travel_time,
+    ST_Y(s.location_tag::geometry) AS end_lat,
+    ST_X(s.location_tag::geometry) AS end_lng,
+    s.cover_media_id,
+    (SELECT c.photo_url FROM trip_media c WHERE c.id = s.cover_media_id AND c.deleted_at IS NULL) AS cover_photo_url
+FROM trip_stop s
+JOIN trips t ON t.id = s.trip_id
+JOIN trip_access a ON a.trip_id = t.id AND a.user_id = sqlc.arg(user_id)
+WHERE s.trip_id = sqlc.arg(trip_id) AND s.deleted_at IS NULL
+WINDOW legs AS (ORDER BY s.sequence, s.created_at)
+) trip_stops
+WHERE sqlc.narg(tags)::text[] IS NULL OR (
+    SELECT COUNT(*)
+    FROM stop_tags st
+    JOIN tags g ON g.id = st.tag_id
+    WHERE st.trip_stop_id = trip_stops.id AND LOWER(g.name) = ANY(sqlc.narg(tags)::text[])
+) >= CASE WHEN sqlc.arg(match_all_tags)::bool THEN cardinality(sqlc.narg(tags)::text[]) ELSE 1 END
+ORDER BY trip_stops.sequence, trip_stops.created_at;
 
 -- name: GetStop :one
 SELECT
     id,
     location_name,
     created_at,
+    country_code,
+    region,
+    timezone,
+    sequence,
+    arrived_at,
+    departed_at,
+    transport_mode,
+    EXTRACT(EPOCH FROM (departed_at - arrived_at))::FLOAT8 AS stop_duration,
     ST_Y(location_tag::geometry) AS end_lat,
-    ST_X(location_tag::geometry) AS end_lng
+    ST_X(location_tag::geometry) AS end_lng,
+    cover_media_id,
+    (SELECT c.photo_url FROM trip_media c WHERE c.id = trip_stop.cover_media_id AND c.deleted_at IS NULL) AS cover_photo_url,
+    trip_stop.trip_id,
+    a.role
 FROM trip_stop
-WHERE user_id = $1 AND id = $2;
+JOIN trip_access a ON a.trip_id = trip_stop.trip_id AND a.user_id = $1
+WHERE id = $2 AND deleted_at IS NULL;
 
 
 -- name: CreateStop :one
@@ -24,23 +72,106 @@ INSERT INTO trip_stop (
     location_name,
     location_tag,
     trip_id,
-    user_id
+    user_id,
+    country_code,
+    region,
+    timezone,
+    arrived_at,
+    departed_at,
+    transport_mode,
+    sequence
 )
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    COALESCE((SELECT MAX(sequence) + 1 FROM trip_stop WHERE trip_id = $3), 0)
 )
 RETURNING id;
 
 -- name: UpdateStop :one
 UPDATE trip_stop
-SET location_name = $1, location_tag= $2
-WHERE id = $3 AND user_id = $4
+SET location_name = $1, location_tag= $2, country_code = $5, region = $6, timezone = $7,
+    arrived_at = $8, departed_at = $9, transport_mode = $10, updated_at = NOW()
+WHERE id = $3 AND deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = $4 AND a.role IN ('owner', 'editor')
+    )
 RETURNING id;
 
--- name: DeleteStop :exec
-DELETE FROM trip_stop 
-WHERE id = $1 AND user_id = $2; 
+-- name: TrashStop :execrows
+UPDATE trip_stop
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = $2 AND a.role IN ('owner', 'editor')
+    );
+
+-- name: ReorderStops :execrows
+UPDATE trip_stop
+SET sequence = ordered.position, updated_at = NOW()
+FROM unnest(sqlc.arg(stop_ids)::uuid[]) WITH ORDINALITY AS ordered(id, position)
+WHERE trip_stop.id = ordered.id AND trip_stop.trip_id = sqlc.arg(trip_id) AND trip_stop.deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = sqlc.arg(user_id) AND a.role IN ('owner', 'editor')
+    );
+
+-- name: GetTrashedStops :many
+SELECT
+    s.id,
+    s.trip_id,
+    t.trip_title,
+    s.location_name,
+    s.deleted_at::TIMESTAMPTZ AS deleted_at
+FROM trip_stop s
+JOIN trips t ON t.id = s.trip_id
+JOIN trip_access a ON a.trip_id = s.trip_id AND a.user_id = $1 AND a.role IN ('owner', 'editor')
+WHERE s.deleted_at IS NOT NULL
+ORDER BY s.deleted_at DESC;
 
+-- name: RestoreStop :one
+UPDATE trip_stop
+-- The stop goes back at the end of the itinerary, since the live stops may
+-- have been reordered while it was in the trash.
+SET deleted_at = NULL, updated_at = NOW(), sequence = COALESCE((
+        SELECT MAX(s.sequence) + 1 FROM trip_stop s
+        WHERE s.trip_id = trip_stop.trip_id AND s.deleted_at IS NULL
+    ), 0)
+WHERE id = $1 AND deleted_at IS NOT NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = $2 AND a.role IN ('owner', 'editor')
+    )
+RETURNING id;
+
+-- name: SetStopCover :execrows
+UPDATE trip_stop
+SET cover_media_id = sqlc.narg(media_id), updated_at = NOW()
+WHERE id = sqlc.arg(stop_id) AND deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trip_stop.trip_id AND a.user_id = sqlc.arg(user_id) AND a.role IN ('owner', 'editor')
+    )
+    AND (sqlc.narg(media_id)::uuid IS NULL OR EXISTS (
+        SELECT 1 FROM trip_media m
+        WHERE m.id = sqlc.narg(media_id)::uuid AND m.photo_url IS NOT NULL
+            AND m.trip_stop_id = trip_stop.id AND m.deleted_at IS NULL
+    ));
+
+-- name: GetUnresolvedStopTimezones :many
+SELECT
+    id,
+    ST_Y(location_tag::geometry)::FLOAT8 AS lat,
+    ST_X(location_tag::geometry)::FLOAT8 AS lng
+FROM trip_stop
+WHERE NOT timezone_resolved;
+
+-- name: SetStopTimezone :exec
+UPDATE trip_stop
+SET timezone = $1, timezone_resolved = TRUE
+WHERE id = $2;
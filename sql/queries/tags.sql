@@ -0,0 +1,98 @@
+-- name: CreateTag :one
+INSERT INTO tags (user_id, name, color)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+RETURNING id;
+
+-- name: UpdateTag :one
+UPDATE tags
+SET name = sqlc.arg(name), color = sqlc.narg(color), updated_at = NOW()
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+    AND NOT EXISTS (
+        SELECT 1 FROM tags other
+        WHERE other.user_id = sqlc.arg(user_id) AND LOWER(other.name) = LOWER(sqlc.arg(name)) AND other.id <> sqlc.arg(id)
+    )
+RETURNING id;
+
+-- name: DeleteTag :exec
+DELETE FROM tags
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetTag :one
+SELECT id, name, color, created_at, updated_at
+FROM tags
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetTags :many
+SELECT
+    g.id,
+    g.name,
+    g.color,
+    g.created_at,
+    g.updated_at,
+    (SELECT COUNT(*) FROM trip_tags tt WHERE tt.tag_id = g.id) AS trip_count,
+    (SELECT COUNT(*) FROM stop_tags st WHERE st.tag_id = g.id) AS stop_count
+FROM tags g
+WHERE g.user_id = $1
+ORDER BY LOWER(g.name);
+
+-- name: CountTags :one
+SELECT COUNT(*)
+FROM tags
+WHERE id = ANY(sqlc.arg(tag_ids)::uuid[]) AND user_id = sqlc.arg(user_id);
+
+-- name: CountTrips :one
+SELECT COUNT(*)
+FROM trips
+WHERE id = ANY(sqlc.arg(trip_ids)::uuid[]) AND user_id = sqlc.arg(user_id) AND deleted_at IS NULL;
+
+-- name: CountStops :one
+SELECT COUNT(*)
+FROM trip_stop
+WHERE id = ANY(sqlc.arg(stop_ids)::uuid[]) AND deleted_at IS NULL AND trip_id IN (SELECT id FROM trips WHERE user_id = sqlc.arg(user_id) AND deleted_at IS NULL);
+
+-- name: AssignTripTags :execrows
+INSERT INTO trip_tags (trip_id, tag_id)
+SELECT t.id, g.id
+FROM trips t
+CROSS JOIN tags g
+WHERE t.id = ANY(sqlc.arg(trip_ids)::uuid[]) AND t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL
+    AND g.id = ANY(sqlc.arg(tag_ids)::uuid[]) AND g.user_id = sqlc.arg(user_id)
+ON CONFLICT DO NOTHING;
+
+-- name: UnassignTripTags :execrows
+DELETE FROM trip_tags tt
+USING trips t
+WHERE tt.trip_id = t.id AND t.user_id = sqlc.arg(user_id)
+    AND tt.trip_id = ANY(sqlc.arg(trip_ids)::uuid[])
+    AND tt.tag_id = ANY(sqlc.arg(tag_ids)::uuid[]);
+
+-- name: AssignStopTags :execrows
+INSERT INTO stop_tags (trip_stop_id, tag_id)
+SELECT s.id, g.id
+FROM trip_stop s
+CROSS JOIN tags g
+WHERE s.id = ANY(sqlc.arg(stop_ids)::uuid[]) AND s.deleted_at IS NULL AND s.trip_id IN (SELECT id FROM trips WHERE user_id = sqlc.arg(user_id) AND deleted_at IS NULL)
+    AND g.id = ANY(sqlc.arg(tag_ids)::uuid[]) AND g.user_id = sqlc.arg(user_id)
+ON CONFLICT DO NOTHING;
+
+-- name: UnassignStopTags :execrows
+DELETE FROM stop_tags st
+USING trip_stop s
+WHERE st.trip_stop_id = s.id AND s.trip_id IN (SELECT id FROM trips WHERE user_id = sqlc.arg(user_id))
+    AND st.trip_stop_id = ANY(sqlc.arg(stop_ids)::uuid[])
+    AND st.tag_id = ANY(sqlc.arg(tag_ids)::uuid[]);
+
+-- name: GetTripTags :many
+SELECT tt.trip_id, g.id, g.name, g.color
+FROM trip_tags tt
+JOIN tags g ON g.id = tt.tag_id
+WHERE tt.trip_id = ANY(sqlc.arg(trip_ids)::uuid[])
+ORDER BY tt.trip_id, LOWER(g.name);
+
+-- name: GetStopTags :many
+SELECT st.trip_stop_id, g.id, g.name, g.color
+FROM stop_tags st
+JOIN tags g ON g.id = st.tag_id
+WHERE st.trip_stop_id = ANY(sqlc.arg(stop_ids)::uuid[])
+ORDER BY st.trip_stop_id, LOWER(g.name);
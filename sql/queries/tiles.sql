@@ -0,0 +1,91 @@
+-- name: GetUserTile :one
+WITH bounds AS (
+    SELECT
+        ST_TileEnvelope(sqlc.arg(z)::int, sqlc.arg(x)::int, sqlc.arg(y)::int) AS geom,
+        ST_Transform(ST_TileEnvelope(sqlc.arg(z)::int, sqlc.arg(x)::int, sqlc.arg(y)::int), 4326) AS geog_bbox
+),
+trip_points AS (
+    SELECT
+        ST_AsMVTGeom(ST_Transform(t.start_location::geometry, 3857), bounds.geom) AS geom,
+        t.id AS trip_id,
+        t.trip_title,
+        t.start_location_name AS location_name,
+        'start' AS kind
+    FROM trips t, bounds
+    WHERE t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL AND t.start_location::geometry && bounds.geog_bbox
+    UNION ALL
+    SELECT
+        ST_AsMVTGeom(ST_Transform(t.end_location::geometry, 3857), bounds.geom) AS geom,
+        t.id AS trip_id,
+        t.trip_title,
+        t.end_location_name AS location_name,
+        'end' AS kind
+    FROM trips t, bounds
+    WHERE t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL AND t.end_location IS NOT NULL AND t.end_location::geometry && bounds.geog_bbox
+),
+stop_clusters AS (
+    SELECT
+        ST_AsMVTGeom(ST_Centroid(ST_Collect(ST_Transform(s.location_tag::geometry, 3857))), bounds.geom) AS geom,
+        COUNT(*) AS point_count,
+        CASE WHEN COUNT(*) = 1 THEN (array_agg(s.id))[1] END AS stop_id,
+        CASE WHEN COUNT(*) = 1 THEN (array_agg(s.trip_id))[1] END AS trip_id,
+        CASE WHEN COUNT(*) = 1 THEN (array_agg(s.location_name))[1] END AS location_name
+    FROM trip_stop s, bounds
+    WHERE s.deleted_at IS NULL AND s.trip_id IN (SELECT id FROM trips WHERE user_id = sqlc.arg(user_id) AND deleted_at IS NULL) AND s.location_tag::geometry && bounds.geog_bbox
+    GROUP BY bounds.geom, ST_SnapToGrid(ST_Transform(s.location_tag::geometry, 3857), sqlc.arg(cluster_size)::float8)
+),
+track_points AS (
+    SELECT t.id AS trip_id, 0 AS position, 0 AS sequence, t.start_location::geometry AS geom
+    FROM trips t
+    WHERE t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL
+    UNION ALL
+    SELECT s.trip_id, 1, s.sequence, s.location_tag::geometry
+    FROM trip_stop s
+    WHERE s.deleted_at IS NULL AND s.trip_id IN (SELECT id FROM trips WHERE user_id = sqlc.arg(user_id) AND deleted_at IS NULL)
+    UNION ALL
+    SELECT t.id, 2, 0, t.end_location::geometry
+    FROM trips t
+    WHERE t.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL AND t.end_location IS NOT NULL
+),
+-- Only trips whose points span part of the tile have their line built.
+track_trips AS (
+    SELECT track_points.trip_id
+    FROM track_points, bounds
+    GROUP BY track_points.trip_id, bounds.geog_bbox
+    HAVING COUNT(*) > 1 AND ST_SetSRID(ST_Extent(track_points.geom)::geometry, 4326) && bounds.geog_bbox
+),
+tracks AS (
+    SELECT
+        ST_AsMVTGeom(ST_Transform(line.geom, 3857), bounds.geom) AS geom,
+        line.trip_id
+    FROM (
+        SELECT trip_id, ST_MakeLine(geom ORDER BY position, sequence) AS geom
+        FROM track_points
+        WHERE trip_id IN (SELECT trip_id FROM track_trips)
+        GROUP BY trip_id
+    ) line, bounds
+    WHERE line.geom && bounds.geog_bbox
+),
+media_points AS (
+    SELECT
+        ST_AsMVTGeom(ST_Transform(p.geom, 3857), bounds.geom) AS geom,
+        COUNT(*) AS media_count,
+        p.stop_id,
+        (array_agg(p.photo_url ORDER BY p.created_at DESC))[1] AS photo_url
+    FROM (
+        -- Photo URLs are signed by the API once the tile is rendered.
+        SELECT COALESCE(m.location, s.location_tag)::geometry AS geom, s.id AS stop_id, m.created_at, m.photo_url
+        FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id AND s.deleted_at IS NULL
+        WHERE m.deleted_at IS NULL AND COALESCE(m.location, s.location_tag) IS NOT NULL
+            AND COALESCE(m.trip_id, s.trip_id) IN (SELECT id FROM trips WHERE user_id = sqlc.arg(user_id) AND deleted_at IS NULL)
+    ) p, bounds
+    WHERE p.geom && bounds.geog_bbox
+    GROUP BY bounds.geom, p.stop_id, p.geom
+)
+SELECT (
+    COALESCE((SELECT ST_AsMVT(trip_points.*, 'trips', 4096, 'geom') FROM trip_points), ''::bytea) ||
+    COALESCE((SELECT ST_AsMVT(stop_clusters.*, 'stops', 4096, 'geom') FROM stop_clusters), ''::bytea) ||
+    COALESCE((SELECT ST_AsMVT(tracks.*, 'tracks', 4096, 'geom') FROM tracks), ''::bytea) ||
+    COALESCE((SELECT ST_AsMVT(media_points.*, 'media', 4096, 'geom') FROM media_points), ''::bytea)
+)::bytea AS tile;
@@ -0,0 +1,34 @@
+-- name: GetPurgeableMedia :many
+SELECT m.id
+FROM trip_media m
+LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+JOIN trips t ON t.id = COALESCE(m.trip_id, s.trip_id)
+WHERE m.deleted_at < sqlc.arg(cutoff)::TIMESTAMPTZ
+    OR s.deleted_at < sqlc.arg(cutoff)::TIMESTAMPTZ
+    OR t.deleted_at < sqlc.arg(cutoff)::TIMESTAMPTZ;
+
+-- name: DeletePurgedMedia :exec
+WITH purged AS (
+    DELETE FROM trip_media
+    WHERE id = $1
+    RETURNING photo_url, video_url
+)
+UPDATE media_blobs b
+SET ref_count = b.ref_count - 1, updated_at = NOW()
+FROM purged
+WHERE b.storage_key IN (split_part(purged.photo_url, '/assets/', 2), split_part(purged.video_url, '/assets/', 2))
+    AND b.ref_count > 0;
+
+-- name: PurgeTrashedStops :execrows
+DELETE FROM trip_stop s
+WHERE s.deleted_at < sqlc.arg(cutoff)::TIMESTAMPTZ
+    AND NOT EXISTS (SELECT 1 FROM trip_media m WHERE m.trip_stop_id = s.id);
+
+-- name: PurgeTrashedTrips :execrows
+DELETE FROM trips t
+WHERE t.deleted_at < sqlc.arg(cutoff)::TIMESTAMPTZ
+    AND NOT EXISTS (
+        SELECT 1 FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+        WHERE COALESCE(m.trip_id, s.trip_id) = t.id
+    );
@@ -7,7 +7,11 @@ INSERT INTO trips (
     end_location,
     end_date,
     distance_travelled,
-    user_id
+    user_id,
+    start_country_code,
+    start_timezone,
+    status,
+    transport_mode
 ) VALUES (
 $1,
 $2,
@@ -16,7 +20,11 @@ $4,
 $5,
 $6,
 $7,
-$8
+$8,
+$9,
+$10,
+$11,
+$12
 )
 RETURNING  id;
 
@@ -29,20 +37,38 @@ SET
     start_location =$1,
     trip_title = $2,
     end_date = $3,
-    updated_at = $5
+    updated_at = $5,
+    start_country_code = $8,
+    start_timezone = $9,
+    transport_mode = $10,
+    end_timezone = CASE
+        WHEN end_location IS NOT NULL THEN end_timezone
+        WHEN $3::TIMESTAMPTZ IS NULL THEN NULL
+        ELSE COALESCE((
+            SELECT s.timezone FROM trip_stop s
+            WHERE s.trip_id = trips.id AND s.deleted_at IS NULL
+            ORDER BY s.sequence DESC
+            LIMIT 1
+        ), $9)
+    END
 WHERE
-    id = $6 AND user_id =$7
+    id = $6 AND status IN ('planned', 'active') AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trips.id AND a.user_id = $7 AND a.role IN ('owner', 'editor')
+    )
 RETURNING  id;
 
 
--- name: DeleteTrip :exec
-DELETE FROM trips
-WHERE   id = $1 AND user_id = $2;
+-- name: TrashTrip :execrows
+UPDATE trips
+SET deleted_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
 
 
 -- name: GetTrips :many
 SELECT   
   id,
+  trip_title,
   start_location_name,
   end_location_name,
   start_date,
@@ -50,18 +76,36 @@ SELECT
   distance_travelled,
   created_at,
   updated_at,
-  user_id,
+  trips.user_id,
+  start_country_code,
+  end_country_code,
+  start_timezone,
+  end_timezone,
+  status,
+  transport_mode,
   ST_Y(start_location::geometry) AS start_lat,
   ST_X(start_location::geometry) AS start_lng,
   ST_Y(end_location::geometry) AS end_lat,
-  ST_X(end_location::geometry) AS end_lng
-FROM trips 
-WHERE user_id = $1;
+  ST_X(end_location::geometry) AS end_lng,
+  cover_media_id,
+  (SELECT c.photo_url FROM trip_media c WHERE c.id = trips.cover_media_id AND c.deleted_at IS NULL) AS cover_photo_url,
+  a.role
+FROM trips
+JOIN trip_access a ON a.trip_id = trips.id AND a.user_id = sqlc.arg(user_id)
+WHERE (sqlc.narg(status)::trip_status IS NULL OR status = sqlc.narg(status)::trip_status)
+  AND (sqlc.narg(tags)::text[] IS NULL OR (
+    SELECT COUNT(*)
+    FROM trip_tags tt
+    JOIN tags g ON g.id = tt.tag_id
+    WHERE tt.trip_id = trips.id AND LOWER(g.name) = ANY(sqlc.narg(tags)::text[])
+  ) >= CASE WHEN sqlc.arg(match_all_tags)::bool THEN cardinality(sqlc.narg(tags)::text[]) ELSE 1 END)
+ORDER BY start_date DESC;
 
 
 -- name: GetTrip :one
 SELECT 
   id,
+  trip_title,
   start_location_name,
   end_location_name,
   start_date,
@@ -69,13 +113,23 @@ SELECT
   distance_travelled,
   created_at,
   updated_at,
-  user_id,
+  trips.user_id,
+  start_country_code,
+  end_country_code,
+  start_timezone,
+  end_timezone,
+  status,
+  transport_mode,
   ST_Y(start_location::geometry) AS start_lat,
   ST_X(start_location::geometry) AS start_lng,
   ST_Y(end_location::geometry) AS end_lat,
-  ST_X(end_location::geometry) AS end_lng
+  ST_X(end_location::geometry) AS end_lng,
+  cover_media_id,
+  (SELECT c.photo_url FROM trip_media c WHERE c.id = trips.cover_media_id AND c.deleted_at IS NULL) AS cover_photo_url,
+  a.role
 FROM trips
-WHERE user_id = $1 AND id = $2;
+JOIN trip_access a ON a.trip_id = trips.id AND a.user_id = $1
+WHERE id = $2;
 
 
 -- name: GetTripDistance :one
@@ -88,7 +142,137 @@ SET
     end_location_name = $1,
     end_location =$2,
     end_date = $3,
-    updated_at = $4
+    updated_at = $4,
+    end_country_code = $7,
+    end_timezone = $8,
+    status = 'completed'
 WHERE
-    id = $5 AND user_id = $6
+    id = $5 AND status = 'active' AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trips.id AND a.user_id = $6 AND a.role IN ('owner', 'editor')
+    )
 RETURNING  id;
+
+-- name: UpdateTripStatus :one
+UPDATE trips
+SET
+    status = sqlc.arg(status),
+    updated_at = NOW()
+WHERE
+    id = sqlc.arg(id) AND status = sqlc.arg(current_status) AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trips.id AND a.user_id = sqlc.arg(user_id) AND a.role IN ('owner', 'editor')
+    )
+RETURNING id;
+
+-- name: GetTrashedTrips :many
+SELECT id, trip_title, start_location_name, start_date, status, deleted_at::TIMESTAMPTZ AS deleted_at
+FROM trips
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: RestoreTrip :one
+UPDATE trips
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+RETURNING id;
+
+-- name: DuplicateTrip :one
+WITH new_trip AS (
+    INSERT INTO trips (
+        trip_title,
+        start_location_name,
+        start_location,
+        start_date,
+        start_country_code,
+        start_timezone,
+        timezones_resolved,
+        transport_mode,
+        status,
+        user_id
+    )
+    SELECT
+        COALESCE(sqlc.narg(trip_title)::VARCHAR, t.trip_title),
+        t.start_location_name,
+        t.start_location,
+        COALESCE(sqlc.narg(start_date)::TIMESTAMPTZ, t.start_date),
+        t.start_country_code,
+        t.start_timezone,
+        t.timezones_resolved,
+        t.transport_mode,
+        'planned',
+        sqlc.arg(user_id)
+    FROM trips t
+    WHERE t.id = sqlc.arg(id) AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = t.id AND a.user_id = sqlc.arg(user_id)
+    )
+    RETURNING id
+),
+copied_stops AS (
+    -- Stop times only carry over when the copy is shifted to a new start
+    -- date; without one they would all sit in the past.
+    INSERT INTO trip_stop (
+        location_name,
+        location_tag,
+        trip_id,
+        user_id,
+        country_code,
+        region,
+        timezone,
+        timezone_resolved,
+        arrived_at,
+        departed_at,
+        transport_mode,
+        sequence
+    )
+    SELECT
+        s.location_name,
+        s.location_tag,
+        new_trip.id,
+        sqlc.arg(user_id),
+        s.country_code,
+        s.region,
+        s.timezone,
+        s.timezone_resolved,
+        s.arrived_at + (sqlc.narg(start_date)::TIMESTAMPTZ - t.start_date),
+        s.departed_at + (sqlc.narg(start_date)::TIMESTAMPTZ - t.start_date),
+        s.transport_mode,
+        ROW_NUMBER() OVER (ORDER BY s.sequence, s.created_at) - 1
+    FROM trip_stop s
+    JOIN trips t ON t.id = s.trip_id, new_trip
+    WHERE s.trip_id = sqlc.arg(id) AND s.deleted_at IS NULL
+    RETURNING id
+)
+SELECT new_trip.id, (SELECT COUNT(*) FROM copied_stops) AS stop_count
+FROM new_trip;
+
+-- name: SetTripCover :execrows
+UPDATE trips
+SET cover_media_id = sqlc.narg(media_id), updated_at = NOW()
+WHERE id = sqlc.arg(trip_id) AND deleted_at IS NULL AND EXISTS (
+        SELECT 1 FROM trip_access a
+        WHERE a.trip_id = trips.id AND a.user_id = sqlc.arg(user_id) AND a.role IN ('owner', 'editor')
+    )
+    AND (sqlc.narg(media_id)::uuid IS NULL OR EXISTS (
+        SELECT 1 FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+        WHERE m.id = sqlc.narg(media_id)::uuid AND m.photo_url IS NOT NULL
+            AND COALESCE(m.trip_id, s.trip_id) = trips.id
+            AND m.deleted_at IS NULL AND s.deleted_at IS NULL
+    ));
+
+-- name: GetUnresolvedTripTimezones :many
+SELECT
+    id,
+    ST_Y(start_location::geometry)::FLOAT8 AS start_lat,
+    ST_X(start_location::geometry)::FLOAT8 AS start_lng,
+    ST_Y(end_location::geometry)::FLOAT8 AS end_lat,
+    ST_X(end_location::geometry)::FLOAT8 AS end_lng
+FROM trips
+WHERE NOT timezones_resolved;
+
+-- name: SetTripTimezones :exec
+UPDATE trips
+SET start_timezone = $1, end_timezone = $2, timezones_resolved = TRUE
+WHERE id = $3;
@@ -0,0 +1,29 @@
+-- name: CreateUpload :one
+INSERT INTO uploads (user_id, trip_id, trip_stop_id, media_type, file_name, upload_length, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, trip_id, trip_stop_id, media_type, file_name, upload_length, upload_offset, trip_media_id, expires_at, completed_at, created_at, updated_at;
+
+-- name: GetUpload :one
+SELECT id, user_id, trip_id, trip_stop_id, media_type, file_name, upload_length, upload_offset, trip_media_id, expires_at, completed_at, created_at, updated_at
+FROM uploads
+WHERE id = $1 AND user_id = $2 AND expires_at > NOW();
+
+-- name: UpdateUploadOffset :one
+UPDATE uploads
+SET upload_offset = sqlc.arg(upload_offset), updated_at = NOW()
+WHERE id = sqlc.arg(id) AND upload_offset = sqlc.arg(previous_offset) AND completed_at IS NULL
+RETURNING upload_offset;
+
+-- name: CompleteUpload :exec
+UPDATE uploads
+SET trip_media_id = $1, completed_at = NOW(), updated_at = NOW()
+WHERE id = $2;
+
+-- name: DeleteUpload :execrows
+DELETE FROM uploads
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteExpiredUploads :many
+DELETE FROM uploads
+WHERE expires_at <= NOW()
+RETURNING id, completed_at;
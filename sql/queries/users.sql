@@ -10,7 +10,7 @@ RETURNING *;
 
 -- name: GetUser :one
 
-SELECT id, username, email,password_hash, created_at
+SELECT id, username, email,password_hash, created_at, home_currency, keep_photo_location
 FROM USERS
 WHERE username = $1 OR id = $2 OR email = $3;
 
@@ -20,6 +20,14 @@ SET username = $1, email = $2, updated_at = $3
 WHERE id = $1 
 RETURNING *;
 
+-- name: UpdateSettings :one
+UPDATE users
+SET home_currency = sqlc.arg(home_currency),
+    keep_photo_location = COALESCE(sqlc.narg(keep_photo_location), keep_photo_location),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING home_currency, keep_photo_location;
+
 -- name: UpdatePassword :exec
 UPDATE users
 SET password_hash = $1
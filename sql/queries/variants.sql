@@ -0,0 +1,19 @@
+-- name: CreateMediaVariant :one
+INSERT INTO media_variants (trip_media_id, name, format, url, width, height)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, trip_media_id, name, format, url, width, height, created_at;
+
+-- name: GetMediaVariants :many
+SELECT id, trip_media_id, name, format, url, width, height, created_at
+FROM media_variants
+WHERE trip_media_id = ANY(sqlc.arg(media_ids)::uuid[])
+ORDER BY trip_media_id, format, width;
+
+-- name: CopyMediaVariants :many
+INSERT INTO media_variants (trip_media_id, name, format, url, width, height)
+SELECT DISTINCT ON (v.name, v.format) sqlc.arg(trip_media_id)::UUID, v.name, v.format, v.url, v.width, v.height
+FROM media_variants v
+JOIN trip_media m ON m.id = v.trip_media_id
+WHERE m.photo_url = sqlc.arg(photo_url) AND m.id <> sqlc.arg(trip_media_id)
+ORDER BY v.name, v.format
+RETURNING id, trip_media_id, name, format, url, width, height, created_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Tiles filter on the points as geometry, so the indexes are built on that
+-- cast rather than on the geography columns themselves.
+CREATE INDEX idx_trips_start_location ON trips USING GIST ((start_location::geometry));
+
+CREATE INDEX idx_trips_end_location ON trips USING GIST ((end_location::geometry));
+
+CREATE INDEX idx_trip_stop_location_tag ON trip_stop USING GIST ((location_tag::geometry));
+
+-- +goose Down
+DROP INDEX idx_trips_start_location;
+
+DROP INDEX idx_trips_end_location;
+
+DROP INDEX idx_trip_stop_location_tag;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE trips
+ADD start_country_code VARCHAR(2);
+
+ALTER TABLE trips
+ADD end_country_code VARCHAR(2);
+
+ALTER TABLE trip_stop
+ADD country_code VARCHAR(2);
+
+ALTER TABLE trip_stop
+ADD region VARCHAR;
+
+-- +goose Down
+ALTER TABLE trips
+DROP start_country_code;
+
+ALTER TABLE trips
+DROP end_country_code;
+
+ALTER TABLE trip_stop
+DROP country_code;
+
+ALTER TABLE trip_stop
+DROP region;
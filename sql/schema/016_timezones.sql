@@ -0,0 +1,141 @@
+-- +goose Up
+DROP VIEW trip_summaries;
+
+ALTER TABLE trips
+ALTER COLUMN start_date TYPE TIMESTAMPTZ USING start_date AT TIME ZONE 'UTC',
+ALTER COLUMN end_date TYPE TIMESTAMPTZ USING end_date AT TIME ZONE 'UTC',
+ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE trips
+ADD start_timezone VARCHAR NOT NULL DEFAULT 'Etc/UTC';
+
+ALTER TABLE trips
+ADD end_timezone VARCHAR;
+
+-- Existing trips and stops are given UTC here and resolved from their
+-- points by the API at startup, see resolveStoredTimezones.
+ALTER TABLE trips
+ADD timezones_resolved BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE trips
+ALTER COLUMN timezones_resolved SET DEFAULT TRUE;
+
+ALTER TABLE trip_stop
+ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE trip_stop
+ADD timezone VARCHAR NOT NULL DEFAULT 'Etc/UTC';
+
+ALTER TABLE trip_stop
+ADD timezone_resolved BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE trip_stop
+ALTER COLUMN timezone_resolved SET DEFAULT TRUE;
+
+ALTER TABLE trip_media
+ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
+
+CREATE VIEW trip_summaries AS
+SELECT
+    t.id AS trip_id,
+    t.user_id,
+    t.trip_title,
+    t.start_date,
+    t.end_date,
+    COALESCE((
+        SELECT ST_Length(ST_MakeLine(p.geom ORDER BY p.position, p.seen_at)::geography)
+        FROM (
+            SELECT 0 AS position, t.start_date AS seen_at, t.start_location::geometry AS geom
+            UNION ALL
+            SELECT 1, s.created_at, s.location_tag::geometry
+            FROM trip_stop s
+            WHERE s.trip_id = t.id
+            UNION ALL
+            SELECT 2, COALESCE(t.end_date, t.updated_at), t.end_location::geometry
+            WHERE t.end_location IS NOT NULL
+        ) p
+        HAVING COUNT(*) > 1
+    ), 0)::FLOAT8 AS distance,
+    (GREATEST(COALESCE(t.end_date, NOW()), t.start_date)::date - t.start_date::date + 1)::BIGINT AS days,
+    (
+        SELECT COUNT(*)
+        FROM trip_stop s
+        WHERE s.trip_id = t.id
+    ) AS stop_count,
+    (
+        SELECT COUNT(*)
+        FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+        WHERE COALESCE(m.trip_id, s.trip_id) = t.id AND m.photo_url IS NOT NULL
+    ) AS photo_count
+FROM trips t;
+
+-- +goose Down
+DROP VIEW trip_summaries;
+
+ALTER TABLE trip_media
+ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE trip_stop
+DROP timezone_resolved;
+
+ALTER TABLE trip_stop
+DROP timezone;
+
+ALTER TABLE trip_stop
+ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE trips
+DROP start_timezone;
+
+ALTER TABLE trips
+DROP end_timezone;
+
+ALTER TABLE trips
+DROP timezones_resolved;
+
+ALTER TABLE trips
+ALTER COLUMN start_date TYPE TIMESTAMP USING start_date AT TIME ZONE 'UTC',
+ALTER COLUMN end_date TYPE TIMESTAMP USING end_date AT TIME ZONE 'UTC',
+ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
+
+CREATE VIEW trip_summaries AS
+SELECT
+    t.id AS trip_id,
+    t.user_id,
+    t.trip_title,
+    t.start_date,
+    t.end_date,
+    COALESCE((
+        SELECT ST_Length(ST_MakeLine(p.geom ORDER BY p.position, p.seen_at)::geography)
+        FROM (
+            SELECT 0 AS position, t.start_date AS seen_at, t.start_location::geometry AS geom
+            UNION ALL
+            SELECT 1, s.created_at, s.location_tag::geometry
+            FROM trip_stop s
+            WHERE s.trip_id = t.id
+            UNION ALL
+            SELECT 2, COALESCE(t.end_date, t.updated_at), t.end_location::geometry
+            WHERE t.end_location IS NOT NULL
+        ) p
+        HAVING COUNT(*) > 1
+    ), 0)::FLOAT8 AS distance,
+    (GREATEST(COALESCE(t.end_date, NOW()), t.start_date)::date - t.start_date::date + 1)::BIGINT AS days,
+    (
+        SELECT COUNT(*)
+        FROM trip_stop s
+        WHERE s.trip_id = t.id
+    ) AS stop_count,
+    (
+        SELECT COUNT(*)
+        FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+        WHERE COALESCE(m.trip_id, s.trip_id) = t.id AND m.photo_url IS NOT NULL
+    ) AS photo_count
+FROM trips t;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TYPE trip_status AS ENUM ('planned', 'active', 'completed', 'archived');
+
+ALTER TABLE trips
+ADD status trip_status NOT NULL DEFAULT 'active';
+
+UPDATE trips
+SET status = 'completed'
+WHERE end_location IS NOT NULL;
+
+UPDATE trips
+SET status = 'planned'
+WHERE end_location IS NULL AND start_date > NOW();
+
+ALTER TABLE trips
+ADD CONSTRAINT trips_end_after_start CHECK (end_date IS NULL OR end_date >= start_date) NOT VALID;
+
+CREATE INDEX idx_trips_user_status ON trips (user_id, status);
+
+-- +goose Down
+DROP INDEX idx_trips_user_status;
+
+ALTER TABLE trips
+DROP CONSTRAINT trips_end_after_start;
+
+ALTER TABLE trips
+DROP status;
+
+DROP TYPE trip_status;
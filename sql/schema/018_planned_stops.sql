@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE planned_stops(
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        trip_id uuid NOT NULL,
+        FOREIGN KEY (trip_id) REFERENCES trips(id) ON DELETE CASCADE,
+        user_id uuid NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        location_name VARCHAR NOT NULL,
+        location_tag GEOGRAPHY(POINT, 4326) NOT NULL,
+        target_date TIMESTAMPTZ,
+        position INTEGER NOT NULL DEFAULT 0,
+        matched_stop_id uuid UNIQUE,
+        FOREIGN KEY (matched_stop_id) REFERENCES trip_stop(id) ON DELETE SET NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_planned_stops_trip ON planned_stops (trip_id, position);
+
+CREATE INDEX idx_planned_stops_location_tag ON planned_stops USING GIST (location_tag);
+
+-- +goose Down
+DROP TABLE planned_stops;
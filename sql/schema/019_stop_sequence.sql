@@ -0,0 +1,107 @@
+-- +goose Up
+ALTER TABLE trip_stop
+ADD sequence INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE trip_stop
+ADD arrived_at TIMESTAMPTZ;
+
+ALTER TABLE trip_stop
+ADD departed_at TIMESTAMPTZ;
+
+ALTER TABLE trip_stop
+ADD CONSTRAINT trip_stop_departed_after_arrived CHECK (departed_at IS NULL OR arrived_at IS NULL OR departed_at >= arrived_at);
+
+UPDATE trip_stop
+SET sequence = ordered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY trip_id ORDER BY created_at) - 1 AS position
+    FROM trip_stop
+) ordered
+WHERE trip_stop.id = ordered.id;
+
+CREATE INDEX idx_trip_stop_trip_sequence ON trip_stop (trip_id, sequence);
+
+CREATE OR REPLACE VIEW trip_summaries AS
+SELECT
+    t.id AS trip_id,
+    t.user_id,
+    t.trip_title,
+    t.start_date,
+    t.end_date,
+    COALESCE((
+        SELECT ST_Length(ST_MakeLine(p.geom ORDER BY p.position, p.sequence)::geography)
+        FROM (
+            SELECT 0 AS position, 0 AS sequence, t.start_location::geometry AS geom
+            UNION ALL
+            SELECT 1, s.sequence, s.location_tag::geometry
+            FROM trip_stop s
+            WHERE s.trip_id = t.id
+            UNION ALL
+            SELECT 2, 0, t.end_location::geometry
+            WHERE t.end_location IS NOT NULL
+        ) p
+        HAVING COUNT(*) > 1
+    ), 0)::FLOAT8 AS distance,
+    (GREATEST(COALESCE(t.end_date, NOW()), t.start_date)::date - t.start_date::date + 1)::BIGINT AS days,
+    (
+        SELECT COUNT(*)
+        FROM trip_stop s
+        WHERE s.trip_id = t.id
+    ) AS stop_count,
+    (
+        SELECT COUNT(*)
+        FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+        WHERE COALESCE(m.trip_id, s.trip_id) = t.id AND m.photo_url IS NOT NULL
+    ) AS photo_count
+FROM trips t;
+
+-- +goose Down
+CREATE OR REPLACE VIEW trip_summaries AS
+SELECT
+    t.id AS trip_id,
+    t.user_id,
+    t.trip_title,
+    t.start_date,
+    t.end_date,
+    COALESCE((
+        SELECT ST_Length(ST_MakeLine(p.geom ORDER BY p.position, p.seen_at)::geography)
+        FROM (
+            SELECT 0 AS position, t.start_date AS seen_at, t.start_location::geometry AS geom
+            UNION ALL
+            SELECT 1, s.created_at, s.location_tag::geometry
+            FROM trip_stop s
+            WHERE s.trip_id = t.id
+            UNION ALL
+            SELECT 2, COALESCE(t.end_date, t.updated_at), t.end_location::geometry
+            WHERE t.end_location IS NOT NULL
+        ) p
+        HAVING COUNT(*) > 1
+    ), 0)::FLOAT8 AS distance,
+    (GREATEST(COALESCE(t.end_date, NOW()), t.start_date)::date - t.start_date::date + 1)::BIGINT AS days,
+    (
+        SELECT COUNT(*)
+        FROM trip_stop s
+        WHERE s.trip_id = t.id
+    ) AS stop_count,
+    (
+        SELECT COUNT(*)
+        FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+        WHERE COALESCE(m.trip_id, s.trip_id) = t.id AND m.photo_url IS NOT NULL
+    ) AS photo_count
+FROM trips t;
+
+DROP INDEX idx_trip_stop_trip_sequence;
+
+ALTER TABLE trip_stop
+DROP CONSTRAINT trip_stop_departed_after_arrived;
+
+ALTER TABLE trip_stop
+DROP sequence;
+
+ALTER TABLE trip_stop
+DROP arrived_at;
+
+ALTER TABLE trip_stop
+DROP departed_at;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TYPE transport_mode AS ENUM ('walk', 'bike', 'car', 'bus', 'train', 'ferry', 'flight');
+
+-- The trip mode applies to every leg without its own mode, including the
+-- final leg to the end location.
+ALTER TABLE trips
+ADD transport_mode transport_mode;
+
+-- A stop's mode is the mode of the leg arriving at that stop.
+ALTER TABLE trip_stop
+ADD transport_mode transport_mode;
+
+-- +goose Down
+ALTER TABLE trip_stop
+DROP transport_mode;
+
+ALTER TABLE trips
+DROP transport_mode;
+
+DROP TYPE transport_mode;
@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE journal_entries(
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        trip_id uuid NOT NULL,
+        FOREIGN KEY (trip_id) REFERENCES trips(id) ON DELETE CASCADE,
+        trip_stop_id uuid,
+        FOREIGN KEY (trip_stop_id) REFERENCES trip_stop(id) ON DELETE SET NULL,
+        user_id uuid NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        title VARCHAR,
+        body TEXT NOT NULL,
+        body_html TEXT NOT NULL,
+        entry_date DATE NOT NULL,
+        location_name VARCHAR,
+        location_tag GEOGRAPHY(POINT, 4326),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_journal_entries_trip ON journal_entries (trip_id, entry_date);
+
+CREATE INDEX idx_journal_entries_trip_stop ON journal_entries (trip_stop_id);
+
+CREATE TABLE journal_entry_media(
+        journal_entry_id uuid NOT NULL,
+        FOREIGN KEY (journal_entry_id) REFERENCES journal_entries(id) ON DELETE CASCADE,
+        trip_media_id uuid NOT NULL,
+        FOREIGN KEY (trip_media_id) REFERENCES trip_media(id) ON DELETE CASCADE,
+        position INTEGER NOT NULL DEFAULT 0,
+        PRIMARY KEY (journal_entry_id, trip_media_id)
+);
+
+CREATE INDEX idx_journal_entry_media_trip_media ON journal_entry_media (trip_media_id);
+
+-- +goose Down
+DROP TABLE journal_entry_media;
+
+DROP TABLE journal_entries;
@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE tags(
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        user_id uuid NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        name VARCHAR(50) NOT NULL,
+        color VARCHAR(7),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_tags_user_name ON tags (user_id, LOWER(name));
+
+CREATE TABLE trip_tags(
+        trip_id uuid NOT NULL,
+        FOREIGN KEY (trip_id) REFERENCES trips(id) ON DELETE CASCADE,
+        tag_id uuid NOT NULL,
+        FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+        PRIMARY KEY (trip_id, tag_id)
+);
+
+CREATE INDEX idx_trip_tags_tag ON trip_tags (tag_id, trip_id);
+
+CREATE TABLE stop_tags(
+        trip_stop_id uuid NOT NULL,
+        FOREIGN KEY (trip_stop_id) REFERENCES trip_stop(id) ON DELETE CASCADE,
+        tag_id uuid NOT NULL,
+        FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+        PRIMARY KEY (trip_stop_id, tag_id)
+);
+
+CREATE INDEX idx_stop_tags_tag ON stop_tags (tag_id, trip_stop_id);
+
+-- +goose Down
+DROP TABLE stop_tags;
+
+DROP TABLE trip_tags;
+
+DROP TABLE tags;
@@ -0,0 +1,51 @@
+-- +goose Up
+ALTER TABLE users
+ADD home_currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE trips
+ADD budget_amount NUMERIC(14, 4);
+
+ALTER TABLE trips
+ADD budget_currency CHAR(3);
+
+ALTER TABLE trips
+ADD CONSTRAINT trips_budget_currency CHECK ((budget_amount IS NULL) = (budget_currency IS NULL));
+
+CREATE TYPE expense_category AS ENUM ('accommodation', 'transport', 'food', 'activities', 'shopping', 'fees', 'other');
+
+CREATE TABLE trip_expenses(
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        trip_id uuid NOT NULL,
+        FOREIGN KEY (trip_id) REFERENCES trips(id) ON DELETE CASCADE,
+        trip_stop_id uuid,
+        FOREIGN KEY (trip_stop_id) REFERENCES trip_stop(id) ON DELETE SET NULL,
+        user_id uuid NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        amount NUMERIC(14, 4) NOT NULL CHECK (amount > 0),
+        currency CHAR(3) NOT NULL,
+        category expense_category NOT NULL DEFAULT 'other',
+        spent_on DATE NOT NULL,
+        payer VARCHAR(100),
+        description VARCHAR(255),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_trip_expenses_trip ON trip_expenses (trip_id, spent_on);
+
+-- +goose Down
+DROP TABLE trip_expenses;
+
+DROP TYPE expense_category;
+
+ALTER TABLE trips
+DROP CONSTRAINT trips_budget_currency;
+
+ALTER TABLE trips
+DROP budget_currency;
+
+ALTER TABLE trips
+DROP budget_amount;
+
+ALTER TABLE users
+DROP home_currency;
@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TYPE trip_role AS ENUM ('viewer', 'editor');
+
+CREATE TABLE trip_members(
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        trip_id uuid NOT NULL,
+        FOREIGN KEY (trip_id) REFERENCES trips(id) ON DELETE CASCADE,
+        user_id uuid NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        invited_by uuid NOT NULL,
+        FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE,
+        role trip_role NOT NULL DEFAULT 'viewer',
+        accepted_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        UNIQUE (trip_id, user_id)
+);
+
+CREATE INDEX idx_trip_members_user ON trip_members (user_id, trip_id);
+
+-- trip_access lists everyone allowed on a trip: its owner plus the members
+-- who accepted their invite.
+CREATE VIEW trip_access AS
+SELECT id AS trip_id, user_id, 'owner'::text AS role
+FROM trips
+UNION ALL
+SELECT trip_id, user_id, role::text
+FROM trip_members
+WHERE accepted_at IS NOT NULL;
+
+-- +goose Down
+DROP VIEW trip_access;
+
+DROP TABLE trip_members;
+
+DROP TYPE trip_role;
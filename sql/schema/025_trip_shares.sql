@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE trip_shares(
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        trip_id uuid NOT NULL,
+        FOREIGN KEY (trip_id) REFERENCES trips(id) ON DELETE CASCADE,
+        user_id uuid NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        token VARCHAR(64) UNIQUE NOT NULL,
+        hide_coordinates BOOLEAN NOT NULL DEFAULT FALSE,
+        expires_at TIMESTAMPTZ,
+        revoked_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_trip_shares_trip ON trip_shares (trip_id, created_at);
+
+-- +goose Down
+DROP TABLE trip_shares;
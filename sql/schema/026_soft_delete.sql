@@ -0,0 +1,119 @@
+-- +goose Up
+ALTER TABLE trips
+ADD deleted_at TIMESTAMPTZ;
+
+ALTER TABLE trip_stop
+ADD deleted_at TIMESTAMPTZ;
+
+ALTER TABLE trip_media
+ADD deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_trips_deleted_at ON trips (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_trip_stop_deleted_at ON trip_stop (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_trip_media_deleted_at ON trip_media (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Trashed trips drop out of trip_access, which hides them and everything
+-- hanging off them from owners and members alike until they are restored.
+CREATE OR REPLACE VIEW trip_access AS
+SELECT id AS trip_id, user_id, 'owner'::text AS role
+FROM trips
+WHERE deleted_at IS NULL
+UNION ALL
+SELECT m.trip_id, m.user_id, m.role::text
+FROM trip_members m
+JOIN trips t ON t.id = m.trip_id
+WHERE m.accepted_at IS NOT NULL AND t.deleted_at IS NULL;
+
+CREATE OR REPLACE VIEW trip_summaries AS
+SELECT
+    t.id AS trip_id,
+    t.user_id,
+    t.trip_title,
+    t.start_date,
+    t.end_date,
+    COALESCE((
+        SELECT ST_Length(ST_MakeLine(p.geom ORDER BY p.position, p.sequence)::geography)
+        FROM (
+            SELECT 0 AS position, 0 AS sequence, t.start_location::geometry AS geom
+            UNION ALL
+            SELECT 1, s.sequence, s.location_tag::geometry
+            FROM trip_stop s
+            WHERE s.trip_id = t.id AND s.deleted_at IS NULL
+            UNION ALL
+            SELECT 2, 0, t.end_location::geometry
+            WHERE t.end_location IS NOT NULL
+        ) p
+        HAVING COUNT(*) > 1
+    ), 0)::FLOAT8 AS distance,
+    (GREATEST(COALESCE(t.end_date, NOW()), t.start_date)::date - t.start_date::date + 1)::BIGINT AS days,
+    (
+        SELECT COUNT(*)
+        FROM trip_stop s
+        WHERE s.trip_id = t.id AND s.deleted_at IS NULL
+    ) AS stop_count,
+    (
+        SELECT COUNT(*)
+        FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+        WHERE COALESCE(m.trip_id, s.trip_id) = t.id AND m.photo_url IS NOT NULL
+            AND m.deleted_at IS NULL AND s.deleted_at IS NULL
+    ) AS photo_count
+FROM trips t
+WHERE t.deleted_at IS NULL;
+
+-- +goose Down
+CREATE OR REPLACE VIEW trip_summaries AS
+SELECT
+    t.id AS trip_id,
+    t.user_id,
+    t.trip_title,
+    t.start_date,
+    t.end_date,
+    COALESCE((
+        SELECT ST_Length(ST_MakeLine(p.geom ORDER BY p.position, p.sequence)::geography)
+        FROM (
+            SELECT 0 AS position, 0 AS sequence, t.start_location::geometry AS geom
+            UNION ALL
+            SELECT 1, s.sequence, s.location_tag::geometry
+            FROM trip_stop s
+            WHERE s.trip_id = t.id
+            UNION ALL
+            SELECT 2, 0, t.end_location::geometry
+            WHERE t.end_location IS NOT NULL
+        ) p
+        HAVING COUNT(*) > 1
+    ), 0)::FLOAT8 AS distance,
+    (GREATEST(COALESCE(t.end_date, NOW()), t.start_date)::date - t.start_date::date + 1)::BIGINT AS days,
+    (
+        SELECT COUNT(*)
+        FROM trip_stop s
+        WHERE s.trip_id = t.id
+    ) AS stop_count,
+    (
+        SELECT COUNT(*)
+        FROM trip_media m
+        LEFT JOIN trip_stop s ON s.id = m.trip_stop_id
+        WHERE COALESCE(m.trip_id, s.trip_id) = t.id AND m.photo_url IS NOT NULL
+    ) AS photo_count
+FROM trips t;
+
+CREATE OR REPLACE VIEW trip_access AS
+SELECT id AS trip_id, user_id, 'owner'::text AS role
+FROM trips
+UNION ALL
+SELECT trip_id, user_id, role::text
+FROM trip_members
+WHERE accepted_at IS NOT NULL;
+
+DROP INDEX idx_trip_media_deleted_at;
+DROP INDEX idx_trip_stop_deleted_at;
+DROP INDEX idx_trips_deleted_at;
+
+ALTER TABLE trip_media
+DROP deleted_at;
+
+ALTER TABLE trip_stop
+DROP deleted_at;
+
+ALTER TABLE trips
+DROP deleted_at;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE trip_media
+ADD duration_seconds DOUBLE PRECISION;
+
+ALTER TABLE trip_media
+ADD width INTEGER;
+
+ALTER TABLE trip_media
+ADD height INTEGER;
+
+-- +goose Down
+ALTER TABLE trip_media
+DROP duration_seconds;
+
+ALTER TABLE trip_media
+DROP width;
+
+ALTER TABLE trip_media
+DROP height;
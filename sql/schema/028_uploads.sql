@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE uploads(
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        user_id uuid NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        trip_id uuid,
+        FOREIGN KEY (trip_id) REFERENCES trips(id) ON DELETE CASCADE,
+        trip_stop_id uuid,
+        FOREIGN KEY (trip_stop_id) REFERENCES trip_stop(id) ON DELETE CASCADE,
+        media_type VARCHAR NOT NULL,
+        file_name VARCHAR,
+        upload_length BIGINT NOT NULL CHECK (upload_length > 0),
+        upload_offset BIGINT NOT NULL DEFAULT 0 CHECK (upload_offset >= 0 AND upload_offset <= upload_length),
+        trip_media_id uuid,
+        FOREIGN KEY (trip_media_id) REFERENCES trip_media(id) ON DELETE SET NULL,
+        expires_at TIMESTAMPTZ NOT NULL,
+        completed_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        CHECK ((trip_id IS NULL) <> (trip_stop_id IS NULL))
+);
+
+CREATE INDEX idx_uploads_expires_at ON uploads (expires_at);
+
+-- +goose Down
+DROP TABLE uploads;
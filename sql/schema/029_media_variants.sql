@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE media_variants(
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        trip_media_id uuid NOT NULL,
+        FOREIGN KEY (trip_media_id) REFERENCES trip_media(id) ON DELETE CASCADE,
+        name VARCHAR NOT NULL,
+        format VARCHAR NOT NULL,
+        url TEXT NOT NULL,
+        width INTEGER NOT NULL CHECK (width > 0),
+        height INTEGER NOT NULL CHECK (height > 0),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        UNIQUE (trip_media_id, name, format)
+);
+
+-- +goose Down
+DROP TABLE media_variants;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE trip_media
+ADD taken_at TIMESTAMPTZ;
+
+ALTER TABLE trip_media
+ADD location GEOGRAPHY(POINT, 4326);
+
+ALTER TABLE trip_media
+ADD latitude DOUBLE PRECISION GENERATED ALWAYS AS (ST_Y(location::geometry)) STORED;
+
+ALTER TABLE trip_media
+ADD longitude DOUBLE PRECISION GENERATED ALWAYS AS (ST_X(location::geometry)) STORED;
+
+-- +goose Down
+ALTER TABLE trip_media
+DROP longitude;
+
+ALTER TABLE trip_media
+DROP latitude;
+
+ALTER TABLE trip_media
+DROP location;
+
+ALTER TABLE trip_media
+DROP taken_at;
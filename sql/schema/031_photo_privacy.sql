@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+ADD keep_photo_location BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- +goose Down
+ALTER TABLE users
+DROP keep_photo_location;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE media_blobs(
+        storage_key TEXT PRIMARY KEY,
+        user_id uuid NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        sha256 CHAR(64),
+        size BIGINT,
+        ref_count INTEGER NOT NULL DEFAULT 0 CHECK (ref_count >= 0),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        UNIQUE (user_id, sha256)
+);
+
+CREATE INDEX idx_media_blobs_unreferenced ON media_blobs (storage_key) WHERE ref_count = 0;
+
+-- Files stored before blobs were tracked were never hashed, so they are
+-- counted like the rest but never shared with new uploads.
+INSERT INTO media_blobs (storage_key, user_id, ref_count)
+SELECT split_part(url, '/assets/', 2), user_id, COUNT(*)
+FROM (
+    SELECT photo_url AS url, user_id FROM trip_media WHERE photo_url LIKE '%/assets/%'
+    UNION ALL
+    SELECT video_url, user_id FROM trip_media WHERE video_url LIKE '%/assets/%'
+) stored
+GROUP BY 1, 2
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TABLE media_blobs;
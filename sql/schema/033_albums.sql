@@ -0,0 +1,64 @@
+-- +goose Up
+ALTER TABLE trip_media
+ADD caption TEXT;
+
+ALTER TABLE trip_media
+ADD alt_text TEXT;
+
+ALTER TABLE trip_media
+ADD sort_order INTEGER;
+
+ALTER TABLE trip_media
+ADD favourite BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE trips
+ADD cover_media_id uuid REFERENCES trip_media(id) ON DELETE SET NULL;
+
+ALTER TABLE trip_stop
+ADD cover_media_id uuid REFERENCES trip_media(id) ON DELETE SET NULL;
+
+CREATE TABLE albums(
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        user_id uuid NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        title VARCHAR NOT NULL,
+        description TEXT,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_albums_user ON albums (user_id);
+
+CREATE TABLE album_media(
+        album_id uuid NOT NULL,
+        FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+        trip_media_id uuid NOT NULL,
+        FOREIGN KEY (trip_media_id) REFERENCES trip_media(id) ON DELETE CASCADE,
+        position INTEGER NOT NULL DEFAULT 0,
+        PRIMARY KEY (album_id, trip_media_id)
+);
+
+CREATE INDEX idx_album_media_trip_media ON album_media (trip_media_id);
+
+-- +goose Down
+DROP TABLE album_media;
+
+DROP TABLE albums;
+
+ALTER TABLE trip_stop
+DROP cover_media_id;
+
+ALTER TABLE trips
+DROP cover_media_id;
+
+ALTER TABLE trip_media
+DROP favourite;
+
+ALTER TABLE trip_media
+DROP sort_order;
+
+ALTER TABLE trip_media
+DROP alt_text;
+
+ALTER TABLE trip_media
+DROP caption;
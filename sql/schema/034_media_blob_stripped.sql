@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE media_blobs ADD COLUMN stripped_at TIMESTAMPTZ;
+
+-- Hashed blobs were stored after metadata stripping was added. Older files
+-- are stripped by the API in the background, see stripStoredPhotos.
+UPDATE media_blobs SET stripped_at = created_at WHERE sha256 IS NOT NULL;
+
+ALTER TABLE media_blobs ALTER COLUMN stripped_at SET DEFAULT NOW();
+
+-- +goose Down
+ALTER TABLE media_blobs DROP COLUMN stripped_at;
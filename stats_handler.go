@@ -0,0 +1,245 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/emissions"
+)
+
+type LongestTripResponse struct {
+	TripID    uuid.UUID    `json:"tripId"`
+	TripTitle string       `json:"tripTitle"`
+	StartDate time.Time    `json:"startDate"`
+	EndDate   sql.NullTime `json:"endDate"`
+	Distance  float64      `json:"distance"`
+	Days      int64        `json:"days"`
+}
+
+type PlaceStatsResponse struct {
+	LocationName string      `json:"locationName"`
+	Visits       int64       `json:"visits"`
+	Trips        int64       `json:"trips"`
+	Lat          interface{} `json:"lat"`
+	Lng          interface{} `json:"lng"`
+}
+
+type CountryStatsResponse struct {
+	CountryCode string `json:"countryCode"`
+	Trips       int64  `json:"trips"`
+}
+
+type PeriodStatsResponse struct {
+	Year     int32   `json:"year"`
+	Month    int32   `json:"month,omitempty"`
+	Trips    int64   `json:"trips"`
+	Distance float64 `json:"distance"`
+	Days     int64   `json:"days"`
+	Photos   int64   `json:"photos"`
+	// Footprint is only set on yearly periods.
+	Footprint *FootprintResponse `json:"footprint,omitempty"`
+}
+
+type StatsResponse struct {
+	TotalTrips        int64                  `json:"totalTrips"`
+	TotalDistance     float64                `json:"totalDistance"`
+	DaysOnTheRoad     int64                  `json:"daysOnTheRoad"`
+	TotalStops        int64                  `json:"totalStops"`
+	TotalPhotos       int64                  `json:"totalPhotos"`
+	LongestTrip       *LongestTripResponse   `json:"longestTrip"`
+	MostVisitedPlaces []PlaceStatsResponse   `json:"mostVisitedPlaces"`
+	CountriesVisited  int                    `json:"countriesVisited"`
+	Countries         []CountryStatsResponse `json:"countries"`
+	Footprint         FootprintResponse      `json:"footprint"`
+	Yearly            []PeriodStatsResponse  `json:"yearly"`
+	Monthly           []PeriodStatsResponse  `json:"monthly"`
+}
+
+func (cfg apiConfig) handlerGetStats(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	var year sql.NullInt32
+
+	if yearParam := r.URL.Query().Get("year"); yearParam != "" {
+		parsedYear, err := strconv.Atoi(yearParam)
+
+		if err != nil || parsedYear < 1 || parsedYear > 9999 {
+			respondWithError(w, http.StatusBadRequest, "Invalid year sent through query params", err, false)
+			return
+		}
+
+		year = sql.NullInt32{Int32: int32(parsedYear), Valid: true}
+	}
+
+	totals, err := cfg.db.GetTravelTotals(r.Context(), database.GetTravelTotalsParams{
+		UserID: user.ID,
+		Year:   year,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get travel totals", err, false)
+		return
+	}
+
+	stats := StatsResponse{
+		TotalTrips:        totals.TotalTrips,
+		TotalDistance:     math.Round(totals.TotalDistance),
+		DaysOnTheRoad:     totals.DaysOnTheRoad,
+		TotalStops:        totals.TotalStops,
+		TotalPhotos:       totals.TotalPhotos,
+		MostVisitedPlaces: make([]PlaceStatsResponse, 0),
+		Countries:         make([]CountryStatsResponse, 0),
+		Yearly:            make([]PeriodStatsResponse, 0),
+		Monthly:           make([]PeriodStatsResponse, 0),
+	}
+
+	longestTrip, err := cfg.db.GetLongestTrip(r.Context(), database.GetLongestTripParams{
+		UserID: user.ID,
+		Year:   year,
+	})
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get longest trip", err, false)
+		return
+	}
+
+	if err == nil {
+		stats.LongestTrip = &LongestTripResponse{
+			TripID:    longestTrip.TripID,
+			TripTitle: longestTrip.TripTitle,
+			StartDate: longestTrip.StartDate,
+			EndDate:   longestTrip.EndDate,
+			Distance:  math.Round(longestTrip.Distance),
+			Days:      longestTrip.Days,
+		}
+	}
+
+	places, err := cfg.db.GetMostVisitedPlaces(r.Context(), database.GetMostVisitedPlacesParams{
+		UserID: user.ID,
+		Year:   year,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get most visited places", err, false)
+		return
+	}
+
+	for _, place := range places {
+		stats.MostVisitedPlaces = append(stats.MostVisitedPlaces, PlaceStatsResponse{
+			LocationName: place.LocationName,
+			Visits:       place.Visits,
+			Trips:        place.Trips,
+			Lat:          place.Lat,
+			Lng:          place.Lng,
+		})
+	}
+
+	countries, err := cfg.db.GetVisitedCountries(r.Context(), database.GetVisitedCountriesParams{
+		UserID: user.ID,
+		Year:   year,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get visited countries", err, false)
+		return
+	}
+
+	for _, country := range countries {
+		stats.Countries = append(stats.Countries, CountryStatsResponse{
+			CountryCode: country.CountryCode,
+			Trips:       country.Trips,
+		})
+	}
+
+	stats.CountriesVisited = len(stats.Countries)
+
+	yearly, err := cfg.db.GetYearlyTravelStats(r.Context(), database.GetYearlyTravelStatsParams{
+		UserID: user.ID,
+		Year:   year,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get yearly stats", err, false)
+		return
+	}
+
+	footprints, err := getFootprints(cfg, r.Context(), database.GetTripLegsParams{
+		UserID:    user.ID,
+		OwnedOnly: true,
+		Year:      year,
+	}, footprintByYear)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to estimate travel footprint", err, false)
+		return
+	}
+
+	totalFootprint := emissions.Footprint{}
+
+	for _, footprint := range footprints {
+		totalFootprint.Merge(footprint)
+	}
+
+	stats.Footprint = cfg.convertToFootprintResponse(totalFootprint)
+
+	for _, period := range yearly {
+		footprint := cfg.convertToFootprintResponse(footprints[period.Year])
+
+		stats.Yearly = append(stats.Yearly, PeriodStatsResponse{
+			Year:      period.Year,
+			Trips:     period.Trips,
+			Distance:  math.Round(period.Distance),
+			Days:      period.Days,
+			Photos:    period.Photos,
+			Footprint: &footprint,
+		})
+	}
+
+	monthly, err := cfg.db.GetMonthlyTravelStats(r.Context(), database.GetMonthlyTravelStatsParams{
+		UserID: user.ID,
+		Year:   year,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get monthly stats", err, false)
+		return
+	}
+
+	for _, period := range monthly {
+		stats.Monthly = append(stats.Monthly, PeriodStatsResponse{
+			Year:     period.Year,
+			Month:    period.Month,
+			Trips:    period.Trips,
+			Distance: math.Round(period.Distance),
+			Days:     period.Days,
+			Photos:   period.Photos,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   stats,
+	})
+}
@@ -1,44 +1,93 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
-	"path"
-	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/mambo-dev/adventrak-backend/internal/database"
 	"github.com/mambo-dev/adventrak-backend/internal/utils"
 )
 
 type StopResponse struct {
-	ID           uuid.UUID   `json:"id"`
-	LocationName string      `json:"locationName"`
-	CreatedAt    time.Time   `json:"createdAt"`
-	EndLat       interface{} `json:"endLat"`
-	EndLng       interface{} `json:"endLng"`
+	ID              uuid.UUID      `json:"id"`
+	LocationName    string         `json:"locationName"`
+	CountryCode     sql.NullString `json:"countryCode"`
+	Region          sql.NullString `json:"region"`
+	Timezone        string         `json:"timezone"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	CreatedAtLocal  string         `json:"createdAtLocal"`
+	Sequence        int32          `json:"sequence"`
+	ArrivedAt       sql.NullTime   `json:"arrivedAt"`
+	DepartedAt      sql.NullTime   `json:"departedAt"`
+	ArrivedAtLocal  sql.NullString `json:"arrivedAtLocal"`
+	DepartedAtLocal sql.NullString `json:"departedAtLocal"`
+	TransportMode   sql.NullString `json:"transportMode"`
+	CoverMediaID    uuid.NullUUID  `json:"coverMediaID"`
+	CoverPhotoURL   sql.NullString `json:"coverPhotoURL"`
+	Tags            []TagResponse  `json:"tags"`
+	// StopDuration, LegDistance and LegTravelTime are in seconds and meters.
+	// A leg runs from the previous stop, or the trip start, to this stop.
+	StopDuration  sql.NullFloat64 `json:"stopDuration"`
+	LegDistance   sql.NullFloat64 `json:"legDistance"`
+	LegTravelTime sql.NullFloat64 `json:"legTravelTime"`
+	EndLat        interface{}     `json:"endLat"`
+	EndLng        interface{}     `json:"endLng"`
 }
 
-func convertToStopRow(rows *database.GetStopsRow, row *database.GetStopRow) StopResponse {
+func (cfg apiConfig) convertToStopRow(rows *database.GetStopsRow, row *database.GetStopRow) StopResponse {
 	if row != nil {
 		return StopResponse{
-			ID:           row.ID,
-			LocationName: row.LocationName,
-			CreatedAt:    row.CreatedAt,
-			EndLat:       row.EndLat,
-			EndLng:       row.EndLng,
+			ID:              row.ID,
+			LocationName:    row.LocationName,
+			CountryCode:     row.CountryCode,
+			Region:          row.Region,
+			Timezone:        row.Timezone,
+			CreatedAt:       row.CreatedAt.UTC(),
+			CreatedAtLocal:  formatLocalTime(row.CreatedAt, row.Timezone),
+			Sequence:        row.Sequence,
+			ArrivedAt:       row.ArrivedAt,
+			DepartedAt:      row.DepartedAt,
+			ArrivedAtLocal:  formatNullLocalTime(row.ArrivedAt, sql.NullString{String: row.Timezone, Valid: true}),
+			DepartedAtLocal: formatNullLocalTime(row.DepartedAt, sql.NullString{String: row.Timezone, Valid: true}),
+			TransportMode:   nullTransportModeString(row.TransportMode),
+			CoverMediaID:    row.CoverMediaID,
+			CoverPhotoURL:   cfg.signMediaNullURL(row.CoverPhotoUrl),
+			Tags:            make([]TagResponse, 0),
+			StopDuration:    row.StopDuration,
+			EndLat:          row.EndLat,
+			EndLng:          row.EndLng,
 		}
 	}
 
 	return StopResponse{
-		ID:           rows.ID,
-		LocationName: rows.LocationName,
-		CreatedAt:    rows.CreatedAt,
-		EndLat:       rows.EndLat,
-		EndLng:       rows.EndLng,
+		ID:              rows.ID,
+		LocationName:    rows.LocationName,
+		CountryCode:     rows.CountryCode,
+		Region:          rows.Region,
+		Timezone:        rows.Timezone,
+		CreatedAt:       rows.CreatedAt.UTC(),
+		CreatedAtLocal:  formatLocalTime(rows.CreatedAt, rows.Timezone),
+		Sequence:        rows.Sequence,
+		ArrivedAt:       rows.ArrivedAt,
+		DepartedAt:      rows.DepartedAt,
+		ArrivedAtLocal:  formatNullLocalTime(rows.ArrivedAt, sql.NullString{String: rows.Timezone, Valid: true}),
+		DepartedAtLocal: formatNullLocalTime(rows.DepartedAt, sql.NullString{String: rows.Timezone, Valid: true}),
+		TransportMode:   nullTransportModeString(rows.TransportMode),
+		CoverMediaID:    rows.CoverMediaID,
+		CoverPhotoURL:   cfg.signMediaNullURL(rows.CoverPhotoUrl),
+		Tags:            make([]TagResponse, 0),
+		StopDuration:    rows.StopDuration,
+		LegDistance:     rows.LegDistance,
+		LegTravelTime:   rows.LegTravelTime,
+		EndLat:          rows.EndLat,
+		EndLng:          rows.EndLng,
 	}
 }
 
@@ -76,9 +125,18 @@ func (cfg apiConfig) handlerGetStops(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tags, matchAllTags, err := parseTagFilter(r.URL.Query())
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid tags sent through query params", err, false)
+		return
+	}
+
 	stops, err := cfg.db.GetStops(r.Context(), database.GetStopsParams{
-		UserID: user.ID,
-		TripID: tripUUID,
+		UserID:       user.ID,
+		TripID:       tripUUID,
+		Tags:         tags,
+		MatchAllTags: matchAllTags,
 	})
 
 	if err != nil {
@@ -86,9 +144,27 @@ func (cfg apiConfig) handlerGetStops(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	stopIDs := make([]uuid.UUID, 0, len(stops))
+	for _, stop := range stops {
+		stopIDs = append(stopIDs, stop.ID)
+	}
+
+	stopTags, err := cfg.getStopTags(r.Context(), stopIDs)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return stop tags", err, false)
+		return
+	}
+
 	stopsResponse := make([]StopResponse, 0, len(stops))
 	for _, stop := range stops {
-		stopsResponse = append(stopsResponse, convertToStopRow(&stop, nil))
+		stopResponse := cfg.convertToStopRow(&stop, nil)
+
+		if tags, ok := stopTags[stop.ID]; ok {
+			stopResponse.Tags = tags
+		}
+
+		stopsResponse = append(stopsResponse, stopResponse)
 	}
 
 	respondWithJSON(w, http.StatusOK, ApiResponse{
@@ -136,14 +212,51 @@ func (cfg apiConfig) handlerGetStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	stopTags, err := cfg.getStopTags(r.Context(), []uuid.UUID{stop.ID})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return stop tags", err, false)
+		return
+	}
+
+	stopResponse := cfg.convertToStopRow(nil, &stop)
+
+	if tags, ok := stopTags[stop.ID]; ok {
+		stopResponse.Tags = tags
+	}
+
 	respondWithJSON(w, http.StatusOK, ApiResponse{
 		Status: "success",
-		Data:   convertToStopRow(nil, &stop),
+		Data:   stopResponse,
 	})
 }
 
+var errDepartedBeforeArrived = errors.New("departure is before arrival")
+
 type StopParams struct {
 	LocationTag utils.Location
+	ArrivedAt   *time.Time `json:"arrivedAt,omitempty"`
+	DepartedAt  *time.Time `json:"departedAt,omitempty"`
+	// TransportMode is the mode of the leg arriving at this stop.
+	TransportMode string `json:"transportMode,omitempty"`
+}
+
+func (params StopParams) visitTimes() (sql.NullTime, sql.NullTime, error) {
+	var arrivedAt, departedAt sql.NullTime
+
+	if params.ArrivedAt != nil {
+		arrivedAt = sql.NullTime{Time: params.ArrivedAt.UTC(), Valid: true}
+	}
+
+	if params.DepartedAt != nil {
+		departedAt = sql.NullTime{Time: params.DepartedAt.UTC(), Valid: true}
+	}
+
+	if arrivedAt.Valid && departedAt.Valid && departedAt.Time.Before(arrivedAt.Time) {
+		return arrivedAt, departedAt, errDepartedBeforeArrived
+	}
+
+	return arrivedAt, departedAt, nil
 }
 
 func (cfg apiConfig) handlerCreateStop(w http.ResponseWriter, r *http.Request) {
@@ -184,17 +297,49 @@ func (cfg apiConfig) handlerCreateStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
 	params := &StopParams{}
 	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Could not read trip details", err, false)
 		return
 	}
 
+	arrivedAt, departedAt, err := params.visitTimes()
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Departure must be after arrival", err, false)
+		return
+	}
+
+	transportMode, err := parseTransportMode(params.TransportMode)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid transport mode", err, false)
+		return
+	}
+
+	location, err := cfg.resolveLocation(params.LocationTag)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not resolve stop location name", err, false)
+		return
+	}
+
 	stopID, err := cfg.db.CreateStop(r.Context(), database.CreateStopParams{
-		LocationName: params.LocationTag.Name,
-		LocationTag:  utils.FormatPoint(params.LocationTag),
-		TripID:       trip.ID,
-		UserID:       trip.UserID,
+		LocationName:  location.Name,
+		LocationTag:   utils.FormatPoint(location.Location),
+		TripID:        trip.ID,
+		UserID:        trip.UserID,
+		CountryCode:   location.CountryCode,
+		Region:        location.Region,
+		Timezone:      location.Timezone,
+		ArrivedAt:     arrivedAt,
+		DepartedAt:    departedAt,
+		TransportMode: transportMode,
 	})
 
 	if err != nil {
@@ -202,6 +347,8 @@ func (cfg apiConfig) handlerCreateStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	cfg.matchPlannedStop(r, stopID)
+
 	respondWithJSON(w, http.StatusCreated, ApiResponse{
 		Status: "success",
 		Data: struct {
@@ -250,17 +397,49 @@ func (cfg apiConfig) handlerUpdateStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !canEditTrip(stop.Role) {
+		respondReadOnly(w)
+		return
+	}
+
 	params := &StopParams{}
 	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Could not read trip details", err, false)
 		return
 	}
 
+	arrivedAt, departedAt, err := params.visitTimes()
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Departure must be after arrival", err, false)
+		return
+	}
+
+	transportMode, err := parseTransportMode(params.TransportMode)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid transport mode", err, false)
+		return
+	}
+
+	location, err := cfg.resolveLocation(params.LocationTag)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not resolve stop location name", err, false)
+		return
+	}
+
 	updatedStopID, err := cfg.db.UpdateStop(r.Context(), database.UpdateStopParams{
-		LocationName: params.LocationTag.Name,
-		LocationTag:  utils.FormatPoint(params.LocationTag),
-		UserID:       user.ID,
-		ID:           stop.ID,
+		LocationName:  location.Name,
+		LocationTag:   utils.FormatPoint(location.Location),
+		UserID:        user.ID,
+		ID:            stop.ID,
+		CountryCode:   location.CountryCode,
+		Region:        location.Region,
+		Timezone:      location.Timezone,
+		ArrivedAt:     arrivedAt,
+		DepartedAt:    departedAt,
+		TransportMode: transportMode,
 	})
 
 	if err != nil {
@@ -268,6 +447,8 @@ func (cfg apiConfig) handlerUpdateStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	cfg.rematchPlannedStop(r, updatedStopID)
+
 	respondWithJSON(w, http.StatusCreated, ApiResponse{
 		Status: "success",
 		Data: struct {
@@ -316,37 +497,124 @@ func (cfg apiConfig) handlerDeleteStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	media, err := cfg.db.GetTripMediaByTripOrStopID(r.Context(), database.GetTripMediaByTripOrStopIDParams{
-		TripStopID: uuid.NullUUID{
-			UUID:  stop.ID,
-			Valid: true,
-		},
+	if !canEditTrip(stop.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	trashed, err := cfg.db.TrashStop(r.Context(), database.TrashStopParams{
+		ID:     stop.ID,
+		UserID: user.ID,
 	})
 
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Failed to get stop assets", err, false)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete trip's stop", err, false)
 		return
 	}
 
-	for _, medium := range media {
-		imageFileName := strings.Split(medium.PhotoUrl.String, "assets/")[1]
-		imageFilePath := path.Join(cfg.assetsRoot, imageFileName)
+	if trashed == 0 {
+		respondWithError(w, http.StatusNotFound, "Unable to find stop possibly deleted", errNothingTrashed, false)
+		return
+	}
 
-		if err = utils.DeleteMedia(imageFilePath); err != nil {
-			log.Printf("Failed to delete media at %v", imageFilePath)
-			respondWithError(w, http.StatusInternalServerError, "Something went wrong.", err, false)
-			return
-		}
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
+
+func (cfg apiConfig) handlerReorderStops(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
 
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
 	}
 
-	err = cfg.db.DeleteStop(r.Context(), database.DeleteStopParams{
-		ID:     stop.ID,
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	type Params struct {
+		StopIDs []uuid.UUID `json:"stopIDs" validate:"required,min=1,unique"`
+	}
+
+	params := &Params{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read stop order", err, false)
+		return
+	}
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	stops, err := cfg.db.GetStops(r.Context(), database.GetStopsParams{
 		UserID: user.ID,
+		TripID: tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trip stops", err, false)
+		return
+	}
+
+	tripStops := make(map[uuid.UUID]bool, len(stops))
+	for _, stop := range stops {
+		tripStops[stop.ID] = true
+	}
+
+	if len(params.StopIDs) != len(tripStops) {
+		respondWithError(w, http.StatusBadRequest, "Stop order must list every stop of the trip exactly once", errors.New("stop order does not match trip stops"), false)
+		return
+	}
+
+	for _, stopID := range params.StopIDs {
+		if !tripStops[stopID] {
+			respondWithError(w, http.StatusBadRequest, "Stop order must list every stop of the trip exactly once", fmt.Errorf("stop %v is not part of trip %v", stopID, tripUUID), false)
+			return
+		}
+	}
+
+	_, err = cfg.db.ReorderStops(r.Context(), database.ReorderStopsParams{
+		StopIds: params.StopIDs,
+		TripID:  tripUUID,
+		UserID:  user.ID,
 	})
 
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Failed to delete trip's stop", err, false)
+		respondWithError(w, http.StatusInternalServerError, "Failed to reorder trip stops", err, false)
 		return
 	}
 
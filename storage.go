@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/exif"
+	"github.com/mambo-dev/adventrak-backend/internal/imaging"
+	"github.com/mambo-dev/adventrak-backend/internal/storage"
+)
+
+const (
+	storageLocal = "local"
+	storageS3    = "s3"
+)
+
+var errUnknownStorage = errors.New("STORAGE_BACKEND must be local or s3")
+
+// loadStorage opens the media store named by backend. Local storage keeps
+// files under assetsRoot; S3 storage reads its bucket and credentials from
+// the S3_* environment variables.
+func loadStorage(backend, assetsRoot, assetsURL string) (storage.Storage, error) {
+	switch backend {
+	case "", storageLocal:
+		if assetsRoot == "" {
+			return nil, errors.New("ASSETS_ROOT environment variable is not set")
+		}
+
+		return storage.NewLocal(assetsRoot, assetsURL)
+	case storageS3:
+		pathStyle := false
+
+		if value := os.Getenv("S3_FORCE_PATH_STYLE"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+
+			if err != nil {
+				return nil, fmt.Errorf("could not parse S3_FORCE_PATH_STYLE: %w", err)
+			}
+
+			pathStyle = parsed
+		}
+
+		region := os.Getenv("S3_REGION")
+
+		if region == "" {
+			region = "us-east-1"
+		}
+
+		return storage.NewS3(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          region,
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       pathStyle,
+			Client:          &http.Client{Timeout: 5 * time.Minute},
+		})
+	}
+
+	return nil, errUnknownStorage
+}
+
+// hashFile returns the hex SHA-256 digest and size of a file.
+func hashFile(filePath string) (string, int64, error) {
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// mediaURL is the address a stored file is served from. It always points at
+// the API, whichever backend holds the file.
+func (cfg apiConfig) mediaURL(key string) string {
+	return fmt.Sprintf("%v/assets/%v", cfg.baseApiUrl, key)
+}
+
+// mediaKey maps a stored media URL back to its storage key.
+func (cfg apiConfig) mediaKey(mediaURL string) (string, error) {
+	_, key, found := strings.Cut(mediaURL, "/assets/")
+
+	if !found {
+		return "", errAssetOutsideDir
+	}
+
+	key, err := storage.CleanKey(key)
+
+	if err != nil {
+		return "", errAssetOutsideDir
+	}
+
+	return key, nil
+}
+
+// putFile copies a local file into storage under key.
+func (cfg apiConfig) putFile(ctx context.Context, key, filePath, contentType string) error {
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	return cfg.storage.Put(ctx, key, file, info.Size(), contentType)
+}
+
+// storeMedia files a local copy under its content hash. A file the user has
+// stored before is shared rather than uploaded again, so the same photo on a
+// trip and one of its stops is kept once. Either way the blob gains a
+// reference, which the caller hands back with releaseStoredMedia if the
+// media row is never created. New files that would take the user over their
+// storage quota fail with errStorageQuota before anything is written.
+func (cfg apiConfig) storeMedia(ctx context.Context, userID uuid.UUID, filePath, extension, contentType string) (string, error) {
+	digest, size, err := hashFile(filePath)
+
+	if err != nil {
+		return "", err
+	}
+
+	blob, err := cfg.db.AcquireMediaBlob(ctx, database.AcquireMediaBlobParams{
+		StorageKey: fmt.Sprintf("%v/%v%v", userID, digest, extension),
+		UserID:     userID,
+		Sha256:     sql.NullString{String: digest, Valid: true},
+		Size:       sql.NullInt64{Int64: size, Valid: true},
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if blob.RefCount == 1 {
+		remaining, err := cfg.storageRemaining(ctx, userID)
+
+		if err == nil && remaining < 0 {
+			err = errStorageQuota
+		}
+
+		if err != nil {
+			cfg.releaseMediaBlob(ctx, blob.StorageKey)
+			return "", err
+		}
+	}
+
+	// A shared blob is normally stored already, but the upload that created
+	// it may still be running or may have failed.
+	if blob.RefCount > 1 {
+		_, err := cfg.storage.Stat(ctx, blob.StorageKey)
+
+		if err == nil {
+			return blob.StorageKey, nil
+		}
+
+		if !errors.Is(err, storage.ErrNotFound) {
+			cfg.releaseMediaBlob(ctx, blob.StorageKey)
+			return "", err
+		}
+	}
+
+	if err := cfg.putFile(ctx, blob.StorageKey, filePath, contentType); err != nil {
+		cfg.releaseMediaBlob(ctx, blob.StorageKey)
+		return "", err
+	}
+
+	return blob.StorageKey, nil
+}
+
+// releaseStoredMedia drops the reference a media URL holds on its blob,
+// logging rather than failing so a half-finished upload never hides the
+// original error.
+func (cfg apiConfig) releaseStoredMedia(ctx context.Context, mediaURL string) {
+	key, err := cfg.mediaKey(mediaURL)
+
+	if err != nil {
+		log.Printf("Failed to release stored media %v: %v", mediaURL, err)
+		return
+	}
+
+	cfg.releaseMediaBlob(ctx, key)
+}
+
+// releaseMediaBlob drops a reference to a blob and removes it once nothing
+// refers to it. Blobs whose files cannot be removed now are retried by the
+// trash purge.
+func (cfg apiConfig) releaseMediaBlob(ctx context.Context, key string) {
+	refCount, err := cfg.db.ReleaseMediaBlob(ctx, key)
+
+	if err != nil {
+		log.Printf("Failed to release stored media %v: %v", key, err)
+		return
+	}
+
+	if refCount > 0 {
+		return
+	}
+
+	if err := cfg.removeMediaBlob(ctx, key); err != nil {
+		log.Printf("Failed to delete stored media %v: %v", key, err)
+	}
+}
+
+// removeMediaBlob deletes an unreferenced blob's files and then its row, so
+// a failure leaves the row behind to retry rather than orphaned files. The
+// row stays locked until both are gone, so an upload of the same file waits
+// and then stores it afresh instead of sharing a file being deleted. A blob
+// that gained a reference in the meantime is left alone.
+func (cfg apiConfig) removeMediaBlob(ctx context.Context, key string) error {
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		_, err := q.LockUnreferencedMediaBlob(ctx, key)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.deleteBlobFiles(ctx, key); err != nil {
+			return err
+		}
+
+		return q.DeleteMediaBlob(ctx, key)
+	})
+}
+
+// deleteBlobFiles deletes a stored file along with any photo variants made
+// from it, which are shared by every medium using the file.
+func (cfg apiConfig) deleteBlobFiles(ctx context.Context, key string) error {
+	for _, fileKey := range append([]string{key}, photoVariantKeys(key)...) {
+		if err := cfg.storage.Delete(ctx, fileKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stagePhoto writes the photo with its metadata stripped to a scratch file
+// under the uploads root, so the stored copy carries neither GPS nor device
+// details. Variants are made from the scratch file before it is removed.
+// Photos over imaging.MaxPixels are refused before anything decodes them.
+func (cfg apiConfig) stagePhoto(photo io.Reader) (string, error) {
+	photoFile, err := os.CreateTemp(cfg.uploadsRoot, "photo-*.part")
+
+	if err != nil {
+		return "", err
+	}
+
+	err = exif.Strip(photoFile, photo)
+
+	if err == nil {
+		_, err = photoFile.Seek(0, io.SeekStart)
+	}
+
+	if err == nil {
+		err = imaging.CheckPixels(photoFile)
+	}
+
+	if closeErr := photoFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(photoFile.Name())
+		return "", err
+	}
+
+	return photoFile.Name(), nil
+}
+
+// strippableExtensions are the stored photo formats exif.Strip understands.
+var strippableExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+}
+
+// stripStoredPhotos removes metadata from photos stored before uploads were
+// stripped. It runs once at startup; each file is marked when done, so an
+// interrupted run picks up where it stopped. Files keep their key, so links
+// already handed out still work.
+func (cfg apiConfig) stripStoredPhotos(ctx context.Context) {
+	keys, err := cfg.db.GetUnstrippedMediaBlobs(ctx)
+
+	if err != nil {
+		log.Printf("Failed to look up unstripped stored media: %v", err)
+		return
+	}
+
+	for _, key := range keys {
+		size := sql.NullInt64{}
+
+		if strippableExtensions[strings.ToLower(path.Ext(key))] {
+			stripped, err := cfg.stripStoredPhoto(ctx, key)
+
+			if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, exif.ErrUnsupported) {
+				log.Printf("Failed to strip metadata from stored photo %v: %v", key, err)
+				continue
+			}
+
+			size = sql.NullInt64{Int64: stripped, Valid: err == nil}
+		}
+
+		err = cfg.db.SetMediaBlobStripped(ctx, database.SetMediaBlobStrippedParams{
+			Size:       size,
+			StorageKey: key,
+		})
+
+		if err != nil {
+			log.Printf("Failed to mark stored photo %v as stripped: %v", key, err)
+		}
+	}
+}
+
+// stripStoredPhoto rewrites one stored photo without its metadata and
+// returns its new size.
+func (cfg apiConfig) stripStoredPhoto(ctx context.Context, key string) (int64, error) {
+	stored, object, err := cfg.storage.Get(ctx, key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	photoFile, err := os.CreateTemp(cfg.uploadsRoot, "strip-*.part")
+
+	if err != nil {
+		stored.Close()
+		return 0, err
+	}
+
+	defer os.Remove(photoFile.Name())
+
+	err = exif.Strip(photoFile, stored)
+	stored.Close()
+
+	if closeErr := photoFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := cfg.putFile(ctx, key, photoFile.Name(), object.ContentType); err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(photoFile.Name())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
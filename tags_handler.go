@@ -0,0 +1,530 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+)
+
+const maxTagFilters = 20
+
+var (
+	errTagExists        = errors.New("a tag with this name already exists")
+	errTagTargetMissing = errors.New("tag, trip or stop not found")
+)
+
+type TagParams struct {
+	Name  string `json:"name" validate:"required,max=50"`
+	Color string `json:"color,omitempty" validate:"omitempty,hexcolor,max=7"`
+}
+
+type TagAssignmentParams struct {
+	TagIDs  []uuid.UUID `json:"tagIds" validate:"required,min=1,max=50,unique"`
+	TripIDs []uuid.UUID `json:"tripIds" validate:"max=500,unique"`
+	StopIDs []uuid.UUID `json:"stopIds" validate:"max=500,unique"`
+}
+
+type TagResponse struct {
+	ID    uuid.UUID      `json:"id"`
+	Name  string         `json:"name"`
+	Color sql.NullString `json:"color"`
+}
+
+type TagDetailsResponse struct {
+	TagResponse
+	TripCount int64     `json:"tripCount"`
+	StopCount int64     `json:"stopCount"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// parseTagFilter reads the tags and tagMatch query params used to filter
+// listings. Tags are matched by case-insensitive name and a listing item
+// must carry all of them unless tagMatch=any.
+func parseTagFilter(query url.Values) ([]string, bool, error) {
+	matchAll := true
+
+	switch query.Get("tagMatch") {
+	case "", "all":
+	case "any":
+		matchAll = false
+	default:
+		return nil, false, fmt.Errorf("unknown tag match %q", query.Get("tagMatch"))
+	}
+
+	if query.Get("tags") == "" {
+		return nil, matchAll, nil
+	}
+
+	seen := make(map[string]bool)
+	tags := make([]string, 0)
+
+	for _, tag := range strings.Split(query.Get("tags"), ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	if len(tags) > maxTagFilters {
+		return nil, false, fmt.Errorf("at most %v tags can be filtered on", maxTagFilters)
+	}
+
+	if len(tags) == 0 {
+		return nil, matchAll, nil
+	}
+
+	return tags, matchAll, nil
+}
+
+func (params TagParams) color() sql.NullString {
+	return sql.NullString{String: strings.ToLower(params.Color), Valid: params.Color != ""}
+}
+
+func (cfg apiConfig) getTripTags(ctx context.Context, tripIDs []uuid.UUID) (map[uuid.UUID][]TagResponse, error) {
+	rows, err := cfg.db.GetTripTags(ctx, tripIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[uuid.UUID][]TagResponse, len(tripIDs))
+
+	for _, row := range rows {
+		tags[row.TripID] = append(tags[row.TripID], TagResponse{
+			ID:    row.ID,
+			Name:  row.Name,
+			Color: row.Color,
+		})
+	}
+
+	return tags, nil
+}
+
+func (cfg apiConfig) getStopTags(ctx context.Context, stopIDs []uuid.UUID) (map[uuid.UUID][]TagResponse, error) {
+	rows, err := cfg.db.GetStopTags(ctx, stopIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[uuid.UUID][]TagResponse, len(stopIDs))
+
+	for _, row := range rows {
+		tags[row.TripStopID] = append(tags[row.TripStopID], TagResponse{
+			ID:    row.ID,
+			Name:  row.Name,
+			Color: row.Color,
+		})
+	}
+
+	return tags, nil
+}
+
+func (cfg apiConfig) handlerGetTags(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tags, err := cfg.db.GetTags(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return users tags", err, false)
+		return
+	}
+
+	tagsResponse := make([]TagDetailsResponse, 0, len(tags))
+
+	for _, tag := range tags {
+		tagsResponse = append(tagsResponse, TagDetailsResponse{
+			TagResponse: TagResponse{
+				ID:    tag.ID,
+				Name:  tag.Name,
+				Color: tag.Color,
+			},
+			TripCount: tag.TripCount,
+			StopCount: tag.StopCount,
+			CreatedAt: tag.CreatedAt,
+			UpdatedAt: tag.UpdatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   tagsResponse,
+	})
+}
+
+func (cfg apiConfig) handlerCreateTag(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	params := &TagParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read tag details", err, false)
+		return
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	tagID, err := cfg.db.CreateTag(r.Context(), database.CreateTagParams{
+		UserID: user.ID,
+		Name:   params.Name,
+		Color:  params.color(),
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "A tag with this name already exists", errTagExists, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create tag", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data: struct {
+			TagID uuid.UUID `json:"tagID"`
+		}{
+			TagID: tagID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerUpdateTag(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tagUUID, err := uuid.Parse(chi.URLParam(r, "tagID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	tag, err := cfg.db.GetTag(r.Context(), database.GetTagParams{
+		ID:     tagUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find tag possibly deleted", err, false)
+		return
+	}
+
+	params := &TagParams{}
+
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not read tag details", err, false)
+		return
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+
+	if err := validator.New().Struct(params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+		return
+	}
+
+	tagID, err := cfg.db.UpdateTag(r.Context(), database.UpdateTagParams{
+		Name:   params.Name,
+		Color:  params.color(),
+		ID:     tag.ID,
+		UserID: user.ID,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "A tag with this name already exists", errTagExists, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update tag", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: struct {
+			TagID uuid.UUID `json:"tagID"`
+		}{
+			TagID: tagID,
+		},
+	})
+}
+
+func (cfg apiConfig) handlerDeleteTag(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tagUUID, err := uuid.Parse(chi.URLParam(r, "tagID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	tag, err := cfg.db.GetTag(r.Context(), database.GetTagParams{
+		ID:     tagUUID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find tag possibly deleted", err, false)
+		return
+	}
+
+	err = cfg.db.DeleteTag(r.Context(), database.DeleteTagParams{
+		ID:     tag.ID,
+		UserID: user.ID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete tag", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   nil,
+	})
+}
+
+// checkTagAssignment makes sure every tag, trip and stop in the assignment
+// belongs to the user.
+func (cfg apiConfig) checkTagAssignment(ctx context.Context, params *TagAssignmentParams, userID uuid.UUID) error {
+	tags, err := cfg.db.CountTags(ctx, database.CountTagsParams{
+		TagIds: params.TagIDs,
+		UserID: userID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if tags != int64(len(params.TagIDs)) {
+		return errTagTargetMissing
+	}
+
+	if len(params.TripIDs) > 0 {
+		trips, err := cfg.db.CountTrips(ctx, database.CountTripsParams{
+			TripIds: params.TripIDs,
+			UserID:  userID,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if trips != int64(len(params.TripIDs)) {
+			return errTagTargetMissing
+		}
+	}
+
+	if len(params.StopIDs) > 0 {
+		stops, err := cfg.db.CountStops(ctx, database.CountStopsParams{
+			StopIds: params.StopIDs,
+			UserID:  userID,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if stops != int64(len(params.StopIDs)) {
+			return errTagTargetMissing
+		}
+	}
+
+	return nil
+}
+
+// handlerBulkTag adds or removes every listed tag on every listed trip and
+// stop in one request.
+func (cfg apiConfig) handlerBulkTag(assign bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := rateLimit(w, r, "general")
+
+		if err != nil {
+			respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+			return
+		}
+
+		userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+		user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+			ID: userID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+			return
+		}
+
+		params := &TagAssignmentParams{}
+
+		if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Could not read tag assignment", err, false)
+			return
+		}
+
+		if err := validator.New().Struct(params); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Failed to validate user input", err, true)
+			return
+		}
+
+		if len(params.TripIDs) == 0 && len(params.StopIDs) == 0 {
+			respondWithError(w, http.StatusBadRequest, "Send at least one trip or stop to tag", errTagTargetMissing, false)
+			return
+		}
+
+		err = cfg.checkTagAssignment(r.Context(), params, user.ID)
+
+		if errors.Is(err, errTagTargetMissing) {
+			respondWithError(w, http.StatusNotFound, "Unable to find some tags, trips or stops possibly deleted", err, false)
+			return
+		}
+
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to check tag assignment", err, false)
+			return
+		}
+
+		var tripRows, stopRows int64
+
+		if len(params.TripIDs) > 0 {
+			if assign {
+				tripRows, err = cfg.db.AssignTripTags(r.Context(), database.AssignTripTagsParams{
+					TripIds: params.TripIDs,
+					UserID:  user.ID,
+					TagIds:  params.TagIDs,
+				})
+			} else {
+				tripRows, err = cfg.db.UnassignTripTags(r.Context(), database.UnassignTripTagsParams{
+					UserID:  user.ID,
+					TripIds: params.TripIDs,
+					TagIds:  params.TagIDs,
+				})
+			}
+
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Failed to update trip tags", err, false)
+				return
+			}
+		}
+
+		if len(params.StopIDs) > 0 {
+			if assign {
+				stopRows, err = cfg.db.AssignStopTags(r.Context(), database.AssignStopTagsParams{
+					StopIds: params.StopIDs,
+					UserID:  user.ID,
+					TagIds:  params.TagIDs,
+				})
+			} else {
+				stopRows, err = cfg.db.UnassignStopTags(r.Context(), database.UnassignStopTagsParams{
+					UserID:  user.ID,
+					StopIds: params.StopIDs,
+					TagIds:  params.TagIDs,
+				})
+			}
+
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Failed to update stop tags", err, false)
+				return
+			}
+		}
+
+		respondWithJSON(w, http.StatusOK, ApiResponse{
+			Status: "success",
+			Data: struct {
+				TripTagsChanged int64 `json:"tripTagsChanged"`
+				StopTagsChanged int64 `json:"stopTagsChanged"`
+			}{
+				TripTagsChanged: tripRows,
+				StopTagsChanged: stopRows,
+			},
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/mvt"
+)
+
+const (
+	maxTileZoom         = 22
+	clusterTileZoom     = 14
+	webMercatorWorldLen = 40075016.685578488
+)
+
+// tileClusterSize returns the grid size in web mercator meters used to
+// cluster stops at the given zoom. Past clusterTileZoom stops are sent as-is.
+func tileClusterSize(z int) float64 {
+	if z >= clusterTileZoom {
+		return 0
+	}
+
+	tileWidth := webMercatorWorldLen / math.Exp2(float64(z))
+
+	return tileWidth / 16
+}
+
+func parseTileCoordinates(r *http.Request) (int, int, int, error) {
+	z, err := strconv.Atoi(chi.URLParam(r, "z"))
+
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	x, err := strconv.Atoi(chi.URLParam(r, "x"))
+
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	y, err := strconv.Atoi(chi.URLParam(r, "y"))
+
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	if z < 0 || z > maxTileZoom {
+		return 0, 0, 0, fmt.Errorf("zoom %v out of range", z)
+	}
+
+	tiles := 1 << z
+
+	if x < 0 || x >= tiles || y < 0 || y >= tiles {
+		return 0, 0, 0, errors.New("tile coordinates out of range")
+	}
+
+	return z, x, y, nil
+}
+
+func (cfg apiConfig) handlerGetTile(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	z, x, y, err := parseTileCoordinates(r)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid tile coordinates", err, false)
+		return
+	}
+
+	tile, err := cfg.db.GetUserTile(r.Context(), database.GetUserTileParams{
+		Z:           int32(z),
+		X:           int32(x),
+		Y:           int32(y),
+		UserID:      user.ID,
+		ClusterSize: tileClusterSize(z),
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to render map tile", err, false)
+		return
+	}
+
+	tile, err = mvt.RewriteStrings(tile, "media", cfg.signMediaURL)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to sign map tile photos", err, false)
+		return
+	}
+
+	hash := sha256.Sum256(tile)
+	etag := fmt.Sprintf(`"%v"`, hex.EncodeToString(hash[:16]))
+
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", "Authorization")
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if len(tile) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+	w.Header().Set("Content-Length", strconv.Itoa(len(tile)))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(tile); err != nil {
+		log.Printf("Failed to write tile: %v", err)
+	}
+}
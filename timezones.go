@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/timezone"
+)
+
+// formatLocalTime renders t as RFC3339 in the named IANA timezone, falling
+// back to UTC when the zone is unknown to the embedded tz database.
+func formatLocalTime(t time.Time, tz string) string {
+	location, err := time.LoadLocation(tz)
+
+	if err != nil {
+		log.Printf("Unknown timezone %v: %v", tz, err)
+		location = time.UTC
+	}
+
+	return t.In(location).Format(time.RFC3339)
+}
+
+func formatNullLocalTime(t sql.NullTime, tz sql.NullString) sql.NullString {
+	if !t.Valid {
+		return sql.NullString{}
+	}
+
+	zone := "Etc/UTC"
+
+	if tz.Valid {
+		zone = tz.String
+	}
+
+	return sql.NullString{String: formatLocalTime(t.Time, zone), Valid: true}
+}
+
+func loadTimezones(path string) (*timezone.Finder, error) {
+	if path == "" {
+		return timezone.New(), nil
+	}
+
+	return timezone.Load(path)
+}
+
+// resolveStoredTimezones looks up the timezones of trips and stops made
+// before timezones were stored, which were given UTC. It runs once at
+// startup and marks each row as it goes, so an interrupted run picks up
+// where it stopped.
+func (cfg apiConfig) resolveStoredTimezones(ctx context.Context) {
+	trips, err := cfg.db.GetUnresolvedTripTimezones(ctx)
+
+	if err != nil {
+		log.Printf("Failed to look up trips without timezones: %v", err)
+		return
+	}
+
+	for _, trip := range trips {
+		endTimezone := sql.NullString{}
+
+		if trip.EndLat.Valid && trip.EndLng.Valid {
+			endTimezone = sql.NullString{String: cfg.timezones.Lookup(trip.EndLat.Float64, trip.EndLng.Float64), Valid: true}
+		}
+
+		err := cfg.db.SetTripTimezones(ctx, database.SetTripTimezonesParams{
+			StartTimezone: cfg.timezones.Lookup(trip.StartLat, trip.StartLng),
+			EndTimezone:   endTimezone,
+			ID:            trip.ID,
+		})
+
+		if err != nil {
+			log.Printf("Failed to store the timezones of trip %v: %v", trip.ID, err)
+		}
+	}
+
+	stops, err := cfg.db.GetUnresolvedStopTimezones(ctx)
+
+	if err != nil {
+		log.Printf("Failed to look up stops without timezones: %v", err)
+		return
+	}
+
+	for _, stop := range stops {
+		err := cfg.db.SetStopTimezone(ctx, database.SetStopTimezoneParams{
+			Timezone: cfg.timezones.Lookup(stop.Lat, stop.Lng),
+			ID:       stop.ID,
+		})
+
+		if err != nil {
+			log.Printf("Failed to store the timezone of stop %v: %v", stop.ID, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+)
+
+// withTx runs fn with queries bound to one transaction. The transaction is
+// committed when fn returns nil and rolled back otherwise, so callers can
+// return the first error they hit.
+func (cfg apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := fn(cfg.db.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
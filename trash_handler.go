@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+var (
+	errNothingTrashed  = errors.New("nothing was moved to the trash")
+	errNotInTrash      = errors.New("item is not in the trash")
+	errAssetOutsideDir = errors.New("media url does not point at stored assets")
+	errBadRetention    = errors.New("retention must be a whole number of days of at least 1")
+)
+
+type TrashedTripResponse struct {
+	ID                uuid.UUID `json:"id"`
+	TripTitle         string    `json:"tripTitle"`
+	StartLocationName string    `json:"startLocationName"`
+	StartDate         time.Time `json:"startDate"`
+	Status            string    `json:"status"`
+	DeletedAt         time.Time `json:"deletedAt"`
+	PurgeAt           time.Time `json:"purgeAt"`
+}
+
+type TrashedStopResponse struct {
+	ID           uuid.UUID `json:"id"`
+	TripID       uuid.UUID `json:"tripID"`
+	TripTitle    string    `json:"tripTitle"`
+	LocationName string    `json:"locationName"`
+	DeletedAt    time.Time `json:"deletedAt"`
+	PurgeAt      time.Time `json:"purgeAt"`
+}
+
+type TrashedMediaResponse struct {
+	ID         uuid.UUID      `json:"id"`
+	TripID     uuid.NullUUID  `json:"tripID"`
+	TripStopID uuid.NullUUID  `json:"tripStopID"`
+	PhotoURL   sql.NullString `json:"photoUrl"`
+	VideoURL   sql.NullString `json:"videoUrl"`
+	DeletedAt  time.Time      `json:"deletedAt"`
+	PurgeAt    time.Time      `json:"purgeAt"`
+}
+
+type TrashResponse struct {
+	RetentionDays int                    `json:"retentionDays"`
+	Trips         []TrashedTripResponse  `json:"trips"`
+	Stops         []TrashedStopResponse  `json:"stops"`
+	Media         []TrashedMediaResponse `json:"media"`
+}
+
+// parseTrashRetention reads the retention period in days, falling back to
+// defaultTrashRetention when it is not configured.
+func parseTrashRetention(days string) (time.Duration, error) {
+	if days == "" {
+		return defaultTrashRetention, nil
+	}
+
+	n, err := strconv.Atoi(days)
+
+	if err != nil || n < 1 {
+		return 0, errBadRetention
+	}
+
+	return time.Duration(n) * 24 * time.Hour, nil
+}
+
+func (cfg apiConfig) handlerGetTrash(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	trips, err := cfg.db.GetTrashedTrips(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trashed trips", err, false)
+		return
+	}
+
+	stops, err := cfg.db.GetTrashedStops(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trashed stops", err, false)
+		return
+	}
+
+	media, err := cfg.db.GetTrashedMedia(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trashed media", err, false)
+		return
+	}
+
+	trash := TrashResponse{
+		RetentionDays: int(cfg.trashRetention / (24 * time.Hour)),
+		Trips:         make([]TrashedTripResponse, 0, len(trips)),
+		Stops:         make([]TrashedStopResponse, 0, len(stops)),
+		Media:         make([]TrashedMediaResponse, 0, len(media)),
+	}
+
+	for _, trip := range trips {
+		trash.Trips = append(trash.Trips, TrashedTripResponse{
+			ID:                trip.ID,
+			TripTitle:         trip.TripTitle,
+			StartLocationName: trip.StartLocationName,
+			StartDate:         trip.StartDate.UTC(),
+			Status:            string(trip.Status),
+			DeletedAt:         trip.DeletedAt.UTC(),
+			PurgeAt:           trip.DeletedAt.Add(cfg.trashRetention).UTC(),
+		})
+	}
+
+	for _, stop := range stops {
+		trash.Stops = append(trash.Stops, TrashedStopResponse{
+			ID:           stop.ID,
+			TripID:       stop.TripID,
+			TripTitle:    stop.TripTitle,
+			LocationName: stop.LocationName,
+			DeletedAt:    stop.DeletedAt.UTC(),
+			PurgeAt:      stop.DeletedAt.Add(cfg.trashRetention).UTC(),
+		})
+	}
+
+	for _, medium := range media {
+		trash.Media = append(trash.Media, TrashedMediaResponse{
+			ID:         medium.ID,
+			TripID:     medium.TripID,
+			TripStopID: medium.TripStopID,
+			PhotoURL:   cfg.signMediaNullURL(medium.PhotoUrl),
+			VideoURL:   cfg.signMediaNullURL(medium.VideoUrl),
+			DeletedAt:  medium.DeletedAt.UTC(),
+			PurgeAt:    medium.DeletedAt.Add(cfg.trashRetention).UTC(),
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   trash,
+	})
+}
+
+func (cfg apiConfig) handlerRestoreTrip(w http.ResponseWriter, r *http.Request) {
+	cfg.restoreFromTrash(w, r, "tripID", func(ctx context.Context, id, userID uuid.UUID) (uuid.UUID, error) {
+		return cfg.db.RestoreTrip(ctx, database.RestoreTripParams{ID: id, UserID: userID})
+	})
+}
+
+func (cfg apiConfig) handlerRestoreStop(w http.ResponseWriter, r *http.Request) {
+	cfg.restoreFromTrash(w, r, "stopID", func(ctx context.Context, id, userID uuid.UUID) (uuid.UUID, error) {
+		return cfg.db.RestoreStop(ctx, database.RestoreStopParams{ID: id, UserID: userID})
+	})
+}
+
+func (cfg apiConfig) handlerRestoreMedia(w http.ResponseWriter, r *http.Request) {
+	cfg.restoreFromTrash(w, r, "mediaID", func(ctx context.Context, id, userID uuid.UUID) (uuid.UUID, error) {
+		return cfg.db.RestoreTripMedia(ctx, database.RestoreTripMediaParams{ID: id, UserID: userID})
+	})
+}
+
+// restoreFromTrash runs the shared part of the restore endpoints. Stops and
+// media inside a trashed trip are only reachable again once the trip itself
+// is restored.
+func (cfg apiConfig) restoreFromTrash(w http.ResponseWriter, r *http.Request, routeParam string, restore func(context.Context, uuid.UUID, uuid.UUID) (uuid.UUID, error)) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	itemUUID, err := uuid.Parse(chi.URLParam(r, routeParam))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	restoredID, err := restore(r.Context(), itemUUID, user.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Unable to find that item in your trash", errNotInTrash, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore from the trash", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data: struct {
+			ID uuid.UUID `json:"id"`
+		}{
+			ID: restoredID,
+		},
+	})
+}
+
+// purgeTrash permanently removes everything trashed before the retention
+// window. Deleting a media row drops its references to stored blobs, and
+// blobs nothing refers to any more have their files deleted before their
+// row, so a failed run leaves the row in place to retry rather than
+// orphaned files. Stops and trips wait until their media is purged.
+func (cfg apiConfig) purgeTrash(ctx context.Context) error {
+	cutoff := time.Now().Add(-cfg.trashRetention)
+
+	media, err := cfg.db.GetPurgeableMedia(ctx, cutoff)
+
+	if err != nil {
+		return err
+	}
+
+	for _, mediumID := range media {
+		if err := cfg.db.DeletePurgedMedia(ctx, mediumID); err != nil {
+			return err
+		}
+	}
+
+	blobs, err := cfg.db.GetUnreferencedMediaBlobs(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, key := range blobs {
+		if err := cfg.removeMediaBlob(ctx, key); err != nil {
+			log.Printf("Failed to purge stored media %v: %v", key, err)
+		}
+	}
+
+	purgedStops, err := cfg.db.PurgeTrashedStops(ctx, cutoff)
+
+	if err != nil {
+		return err
+	}
+
+	purgedTrips, err := cfg.db.PurgeTrashedTrips(ctx, cutoff)
+
+	if err != nil {
+		return err
+	}
+
+	if len(media) > 0 || purgedStops > 0 || purgedTrips > 0 {
+		log.Printf("Purged trash: %d trips, %d stops, %d media", purgedTrips, purgedStops, len(media))
+	}
+
+	return nil
+}
+
+func (cfg apiConfig) purgeTrashPeriodically(interval time.Duration) {
+	for {
+		if err := cfg.purgeTrash(context.Background()); err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		}
+
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+)
+
+// tripTransitions lists the statuses a trip may move to from its current
+// status. Completing a trip goes through MarkTripEnd since it needs an end
+// location, every other move goes through UpdateTripStatus.
+var tripTransitions = map[database.TripStatus][]database.TripStatus{
+	database.TripStatusPlanned:   {database.TripStatusActive},
+	database.TripStatusActive:    {database.TripStatusCompleted},
+	database.TripStatusCompleted: {database.TripStatusArchived, database.TripStatusActive},
+	database.TripStatusArchived:  {database.TripStatusActive},
+}
+
+func canTransitionTrip(from, to database.TripStatus) bool {
+	for _, next := range tripTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tripAction is a status change made through its own route. Start and reopen
+// both make a trip active but from different statuses, so each route only
+// accepts the statuses it is meant for.
+type tripAction struct {
+	name string
+	from []database.TripStatus
+	to   database.TripStatus
+}
+
+var (
+	startTrip = tripAction{
+		name: "started",
+		from: []database.TripStatus{database.TripStatusPlanned},
+		to:   database.TripStatusActive,
+	}
+	archiveTrip = tripAction{
+		name: "archived",
+		from: []database.TripStatus{database.TripStatusCompleted},
+		to:   database.TripStatusArchived,
+	}
+	reopenTrip = tripAction{
+		name: "reopened",
+		from: []database.TripStatus{database.TripStatusCompleted, database.TripStatusArchived},
+		to:   database.TripStatusActive,
+	}
+)
+
+func (action tripAction) allows(from database.TripStatus) bool {
+	return slices.Contains(action.from, from) && canTransitionTrip(from, action.to)
+}
+
+func isTripEditable(status database.TripStatus) bool {
+	return status == database.TripStatusPlanned || status == database.TripStatusActive
+}
+
+func parseTripStatus(status string) (database.TripStatus, error) {
+	tripStatus := database.TripStatus(status)
+
+	if _, ok := tripTransitions[tripStatus]; !ok {
+		return "", fmt.Errorf("unknown trip status %q", status)
+	}
+
+	return tripStatus, nil
+}
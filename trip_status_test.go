@@ -0,0 +1,80 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+)
+
+func TestCanTransitionTrip(t *testing.T) {
+	tests := map[string]struct {
+		from database.TripStatus
+		to   database.TripStatus
+		want bool
+	}{
+		"Planned to active":    {from: database.TripStatusPlanned, to: database.TripStatusActive, want: true},
+		"Planned to completed": {from: database.TripStatusPlanned, to: database.TripStatusCompleted},
+		"Planned to archived":  {from: database.TripStatusPlanned, to: database.TripStatusArchived},
+		"Active to completed":  {from: database.TripStatusActive, to: database.TripStatusCompleted, want: true},
+		"Active to planned":    {from: database.TripStatusActive, to: database.TripStatusPlanned},
+		"Active to archived":   {from: database.TripStatusActive, to: database.TripStatusArchived},
+		"Completed to archived": {
+			from: database.TripStatusCompleted, to: database.TripStatusArchived, want: true,
+		},
+		"Completed to active":  {from: database.TripStatusCompleted, to: database.TripStatusActive, want: true},
+		"Completed to planned": {from: database.TripStatusCompleted, to: database.TripStatusPlanned},
+		"Archived to active":   {from: database.TripStatusArchived, to: database.TripStatusActive, want: true},
+		"Archived to completed": {
+			from: database.TripStatusArchived, to: database.TripStatusCompleted,
+		},
+		"Unknown status": {from: database.TripStatus("cancelled"), to: database.TripStatusActive},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := canTransitionTrip(tc.from, tc.to); got != tc.want {
+				t.Errorf("canTransitionTrip(%v, %v) = %v, want %v", tc.from, tc.to, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTripActionAllows(t *testing.T) {
+	statuses := []database.TripStatus{
+		database.TripStatusPlanned,
+		database.TripStatusActive,
+		database.TripStatusCompleted,
+		database.TripStatusArchived,
+	}
+
+	tests := map[string]struct {
+		action  tripAction
+		allowed []database.TripStatus
+	}{
+		"Start": {
+			action:  startTrip,
+			allowed: []database.TripStatus{database.TripStatusPlanned},
+		},
+		"Archive": {
+			action:  archiveTrip,
+			allowed: []database.TripStatus{database.TripStatusCompleted},
+		},
+		"Reopen": {
+			action:  reopenTrip,
+			allowed: []database.TripStatus{database.TripStatusCompleted, database.TripStatusArchived},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, status := range statuses {
+				want := slices.Contains(tc.allowed, status)
+
+				if got := tc.action.allows(status); got != want {
+					t.Errorf("%v.allows(%v) = %v, want %v", tc.action.name, status, got, want)
+				}
+			}
+		})
+	}
+}
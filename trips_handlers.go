@@ -3,10 +3,12 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"math"
 	"net/http"
-	"path"
 	"strings"
 	"time"
 
@@ -18,35 +20,63 @@ import (
 )
 
 type TripResponse struct {
-	ID                uuid.UUID       `json:"id"`
-	StartDate         time.Time       `json:"startDate"`
-	StartLocationName string          `json:"startLocationName"`
-	EndLocationName   sql.NullString  `json:"endLocationName"`
-	StartLat          interface{}     `json:"startLat"`
-	StartLng          interface{}     `json:"startLng"`
-	EndLat            interface{}     `json:"endLat"`
-	EndLng            interface{}     `json:"endLng"`
-	EndDate           sql.NullTime    `json:"endDate"`
-	DistanceTravelled sql.NullFloat64 `json:"distanceTravelled"`
-	CreatedAt         time.Time       `json:"createdAt"`
-	UpdatedAt         time.Time       `json:"updatedAt"`
-	UserID            uuid.UUID       `json:"userId"`
+	ID                uuid.UUID               `json:"id"`
+	TripTitle         string                  `json:"tripTitle"`
+	StartDate         time.Time               `json:"startDate"`
+	StartDateLocal    string                  `json:"startDateLocal"`
+	StartTimezone     string                  `json:"startTimezone"`
+	StartLocationName string                  `json:"startLocationName"`
+	EndLocationName   sql.NullString          `json:"endLocationName"`
+	StartLat          interface{}             `json:"startLat"`
+	StartLng          interface{}             `json:"startLng"`
+	EndLat            interface{}             `json:"endLat"`
+	EndLng            interface{}             `json:"endLng"`
+	EndDate           sql.NullTime            `json:"endDate"`
+	EndDateLocal      sql.NullString          `json:"endDateLocal"`
+	EndTimezone       sql.NullString          `json:"endTimezone"`
+	Status            string                  `json:"status"`
+	Role              string                  `json:"role"`
+	TransportMode     sql.NullString          `json:"transportMode"`
+	Footprint         FootprintResponse       `json:"footprint"`
+	Tags              []TagResponse           `json:"tags"`
+	Expenses          *ExpenseSummaryResponse `json:"expenses,omitempty"`
+	DistanceTravelled sql.NullFloat64         `json:"distanceTravelled"`
+	StartCountryCode  sql.NullString          `json:"startCountryCode"`
+	EndCountryCode    sql.NullString          `json:"endCountryCode"`
+	CoverMediaID      uuid.NullUUID           `json:"coverMediaID"`
+	CoverPhotoURL     sql.NullString          `json:"coverPhotoURL"`
+	CreatedAt         time.Time               `json:"createdAt"`
+	UpdatedAt         time.Time               `json:"updatedAt"`
+	UserID            uuid.UUID               `json:"userId"`
 }
 
-func convertToTripResponse(dbTrip *database.GetTripRow, dbTrips *database.GetTripsRow) TripResponse {
+func (cfg apiConfig) convertToTripResponse(dbTrip *database.GetTripRow, dbTrips *database.GetTripsRow) TripResponse {
 
 	if dbTrip != nil {
 		return TripResponse{
 			ID:                dbTrip.ID,
+			TripTitle:         dbTrip.TripTitle,
 			StartLocationName: dbTrip.StartLocationName,
 			EndLocationName:   dbTrip.EndLocationName,
-			StartDate:         dbTrip.StartDate,
+			StartDate:         dbTrip.StartDate.UTC(),
+			StartDateLocal:    formatLocalTime(dbTrip.StartDate, dbTrip.StartTimezone),
+			StartTimezone:     dbTrip.StartTimezone,
 			StartLat:          dbTrip.StartLat,
 			StartLng:          dbTrip.StartLng,
 			EndLat:            dbTrip.EndLat,
 			EndLng:            dbTrip.StartLng,
 			EndDate:           dbTrip.EndDate,
+			EndDateLocal:      formatNullLocalTime(dbTrip.EndDate, dbTrip.EndTimezone),
+			EndTimezone:       dbTrip.EndTimezone,
+			Status:            string(dbTrip.Status),
+			Role:              dbTrip.Role,
+			TransportMode:     nullTransportModeString(dbTrip.TransportMode),
+			Tags:              make([]TagResponse, 0),
 			DistanceTravelled: dbTrip.DistanceTravelled,
+			StartCountryCode:  dbTrip.StartCountryCode,
+			EndCountryCode:    dbTrip.EndCountryCode,
+			CoverMediaID:      dbTrip.CoverMediaID,
+			CoverPhotoURL:     cfg.signMediaNullURL(dbTrip.CoverPhotoUrl),
 			CreatedAt:         dbTrip.CreatedAt,
 			UpdatedAt:         dbTrip.UpdatedAt,
 			UserID:            dbTrip.UserID,
@@ -55,15 +85,28 @@ func convertToTripResponse(dbTrip *database.GetTripRow, dbTrips *database.GetTri
 
 	return TripResponse{
 		ID:                dbTrips.ID,
+		TripTitle:         dbTrips.TripTitle,
 		StartLocationName: dbTrips.StartLocationName,
 		EndLocationName:   dbTrips.EndLocationName,
-		StartDate:         dbTrips.StartDate,
+		StartDate:         dbTrips.StartDate.UTC(),
+		StartDateLocal:    formatLocalTime(dbTrips.StartDate, dbTrips.StartTimezone),
+		StartTimezone:     dbTrips.StartTimezone,
 		StartLat:          dbTrips.StartLat,
 		StartLng:          dbTrips.StartLng,
 		EndLat:            dbTrips.EndLat,
 		EndLng:            dbTrips.StartLng,
 		EndDate:           dbTrips.EndDate,
+		EndDateLocal:      formatNullLocalTime(dbTrips.EndDate, dbTrips.EndTimezone),
+		EndTimezone:       dbTrips.EndTimezone,
+		Status:            string(dbTrips.Status),
+		Role:              dbTrips.Role,
+		TransportMode:     nullTransportModeString(dbTrips.TransportMode),
+		Tags:              make([]TagResponse, 0),
 		DistanceTravelled: dbTrips.DistanceTravelled,
+		StartCountryCode:  dbTrips.StartCountryCode,
+		EndCountryCode:    dbTrips.EndCountryCode,
+		CoverMediaID:      dbTrips.CoverMediaID,
+		CoverPhotoURL:     cfg.signMediaNullURL(dbTrips.CoverPhotoUrl),
 		CreatedAt:         dbTrips.CreatedAt,
 		UpdatedAt:         dbTrips.UpdatedAt,
 		UserID:            dbTrips.UserID,
@@ -89,18 +132,71 @@ func (cfg apiConfig) handlerGetTrips(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	trips, err := cfg.db.GetTrips(r.Context(), user.ID)
+	var status database.NullTripStatus
+
+	if statusParam := r.URL.Query().Get("status"); statusParam != "" {
+		tripStatus, err := parseTripStatus(statusParam)
+
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid status sent through query params", err, false)
+			return
+		}
+
+		status = database.NullTripStatus{TripStatus: tripStatus, Valid: true}
+	}
+
+	tags, matchAllTags, err := parseTagFilter(r.URL.Query())
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid tags sent through query params", err, false)
+		return
+	}
+
+	trips, err := cfg.db.GetTrips(r.Context(), database.GetTripsParams{
+		UserID:       user.ID,
+		Status:       status,
+		Tags:         tags,
+		MatchAllTags: matchAllTags,
+	})
 
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Failed to return users trips", err, false)
 		return
 	}
 
+	footprints, err := getFootprints(cfg, r.Context(), database.GetTripLegsParams{
+		UserID: user.ID,
+	}, footprintByTrip)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to estimate trip footprints", err, false)
+		return
+	}
+
+	tripIDs := make([]uuid.UUID, 0, len(trips))
+	for _, trip := range trips {
+		tripIDs = append(tripIDs, trip.ID)
+	}
+
+	tripTags, err := cfg.getTripTags(r.Context(), tripIDs)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trip tags", err, false)
+		return
+	}
+
 	jsonTrips := make([]TripResponse, 0, len(trips))
 
 	for _, trip := range trips {
 
-		jsonTrips = append(jsonTrips, convertToTripResponse(nil, &trip))
+		jsonTrip := cfg.convertToTripResponse(nil, &trip)
+		jsonTrip.Footprint = cfg.convertToFootprintResponse(footprints[trip.ID])
+
+		if tags, ok := tripTags[trip.ID]; ok {
+			jsonTrip.Tags = tags
+		}
+
+		jsonTrips = append(jsonTrips, jsonTrip)
 
 	}
 
@@ -148,17 +244,63 @@ func (cfg apiConfig) handlerGetTrip(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	footprints, err := getFootprints(cfg, r.Context(), database.GetTripLegsParams{
+		UserID: user.ID,
+		TripID: uuid.NullUUID{UUID: trip.ID, Valid: true},
+	}, footprintByTrip)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to estimate trip footprint", err, false)
+		return
+	}
+
+	tripTags, err := cfg.getTripTags(r.Context(), []uuid.UUID{trip.ID})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to return trip tags", err, false)
+		return
+	}
+
+	expenses, err := cfg.summariseExpenses(r.Context(), trip.ID, user.ID, user.HomeCurrency)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to summarise trip expenses", err, false)
+		return
+	}
+
+	jsonTrip := cfg.convertToTripResponse(&trip, nil)
+	jsonTrip.Footprint = cfg.convertToFootprintResponse(footprints[trip.ID])
+
+	if tags, ok := tripTags[trip.ID]; ok {
+		jsonTrip.Tags = tags
+	}
+
+	jsonTrip.Expenses = &expenses
+
 	respondWithJSON(w, http.StatusOK, ApiResponse{
 		Status: "success",
-		Data:   convertToTripResponse(&trip, nil),
+		Data:   jsonTrip,
 	})
 }
 
+var (
+	errEndBeforeStart        = errors.New("end date is before start date")
+	errTripNotEditable       = errors.New("trip is not editable in its current status")
+	errInvalidTripTransition = errors.New("invalid trip status transition")
+	errEmptyTripTitle        = errors.New("trip title is empty")
+)
+
 type TripDetails struct {
 	StartDate     time.Time      `json:"startDate" validate:"required"`
 	StartLocation utils.Location `json:"startLocation" validate:"required"`
 	EndDate       *time.Time     `json:"endDate,omitempty"`
 	TripTitle     string         `json:"tripTitle" validate:"required"`
+	TransportMode string         `json:"transportMode,omitempty"`
+}
+
+type DuplicateTripParams struct {
+	TripTitle *string    `json:"tripTitle,omitempty"`
+	StartDate *time.Time `json:"startDate,omitempty"`
 }
 
 type EndTrip struct {
@@ -197,17 +339,46 @@ func (cfg apiConfig) handlerCreateTrip(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	transportMode, err := parseTransportMode(params.TransportMode)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid transport mode", err, false)
+		return
+	}
+
+	if params.EndDate != nil && params.EndDate.Before(params.StartDate) {
+		respondWithError(w, http.StatusBadRequest, "End date must be after the start date", errEndBeforeStart, false)
+		return
+	}
+
+	startLocation, err := cfg.resolveLocation(params.StartLocation)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not resolve start location name", err, false)
+		return
+	}
+
 	var endDate sql.NullTime
 	if params.EndDate != nil {
-		endDate = sql.NullTime{Time: *params.EndDate, Valid: true}
+		endDate = sql.NullTime{Time: params.EndDate.UTC(), Valid: true}
+	}
+
+	status := database.TripStatusActive
+	if params.StartDate.After(time.Now()) {
+		status = database.TripStatusPlanned
 	}
 
 	tripID, err := cfg.db.CreateTrip(r.Context(), database.CreateTripParams{
-		StartDate:         params.StartDate,
+		TripTitle:         params.TripTitle,
+		StartDate:         params.StartDate.UTC(),
 		EndDate:           endDate,
-		StartLocation:     utils.FormatPoint(params.StartLocation),
+		StartLocation:     utils.FormatPoint(startLocation.Location),
+		StartTimezone:     startLocation.Timezone,
 		UserID:            user.ID,
-		StartLocationName: params.StartLocation.Name,
+		StartLocationName: startLocation.Name,
+		StartCountryCode:  startLocation.CountryCode,
+		Status:            status,
+		TransportMode:     transportMode,
 	})
 
 	if err != nil {
@@ -225,6 +396,88 @@ func (cfg apiConfig) handlerCreateTrip(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handlerDuplicateTrip copies a trip's title, start and stops into a new
+// planned trip owned by the caller. Media, expenses and completion details
+// stay behind.
+func (cfg apiConfig) handlerDuplicateTrip(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	tripUUID, err := uuid.Parse(chi.URLParam(r, "tripID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+		return
+	}
+
+	params := &DuplicateTripParams{}
+
+	if err = json.NewDecoder(r.Body).Decode(params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Could not read trip details", err, false)
+		return
+	}
+
+	var tripTitle sql.NullString
+	if params.TripTitle != nil {
+		title := strings.TrimSpace(*params.TripTitle)
+
+		if title == "" {
+			respondWithError(w, http.StatusBadRequest, "Trip title cannot be empty", errEmptyTripTitle, false)
+			return
+		}
+
+		tripTitle = sql.NullString{String: title, Valid: true}
+	}
+
+	var startDate sql.NullTime
+	if params.StartDate != nil {
+		startDate = sql.NullTime{Time: params.StartDate.UTC(), Valid: true}
+	}
+
+	duplicate, err := cfg.db.DuplicateTrip(r.Context(), database.DuplicateTripParams{
+		TripTitle: tripTitle,
+		StartDate: startDate,
+		UserID:    user.ID,
+		ID:        tripUUID,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to duplicate trip", err, false)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data: struct {
+			TripID    uuid.UUID `json:"tripID"`
+			StopCount int64     `json:"stopCount"`
+		}{
+			TripID:    duplicate.ID,
+			StopCount: duplicate.StopCount,
+		},
+	})
+}
+
 func (cfg apiConfig) handlerUpdateTripDetails(w http.ResponseWriter, r *http.Request) {
 	err := rateLimit(w, r, "general")
 
@@ -265,18 +518,61 @@ func (cfg apiConfig) handlerUpdateTripDetails(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	transportMode, err := parseTransportMode(params.TransportMode)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid transport mode", err, false)
+		return
+	}
+
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	if !isTripEditable(trip.Status) {
+		respondWithError(w, http.StatusConflict, "Only planned or active trips can be updated. Reopen the trip first.", errTripNotEditable, false)
+		return
+	}
+
+	if params.EndDate != nil && params.EndDate.Before(trip.StartDate) {
+		respondWithError(w, http.StatusBadRequest, "End date must be after the start date", errEndBeforeStart, false)
+		return
+	}
+
+	startLocation, err := cfg.resolveLocation(params.StartLocation)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not resolve start location name", err, false)
+		return
+	}
+
 	var endDate sql.NullTime
 	if params.EndDate != nil {
-		endDate = sql.NullTime{Time: *params.EndDate, Valid: true}
+		endDate = sql.NullTime{Time: params.EndDate.UTC(), Valid: true}
 	}
 
 	tripID, err := cfg.db.UpdateTrip(r.Context(), database.UpdateTripParams{
+		TripTitle:         params.TripTitle,
 		EndDate:           endDate,
-		StartLocation:     utils.FormatPoint(params.StartLocation),
+		StartLocation:     utils.FormatPoint(startLocation.Location),
 		UserID:            user.ID,
 		ID:                tripUUID,
-		StartLocationName: params.StartLocation.Name,
+		StartLocationName: startLocation.Name,
+		StartCountryCode:  startLocation.CountryCode,
+		StartTimezone:     startLocation.Timezone,
 		UpdatedAt:         time.Now(),
+		TransportMode:     transportMode,
 	})
 
 	if err != nil {
@@ -334,16 +630,53 @@ func (cfg apiConfig) handlerMarkTripComplete(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	var endDate sql.NullTime
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+		return
+	}
+
+	if !canEditTrip(trip.Role) {
+		respondReadOnly(w)
+		return
+	}
+
+	if !canTransitionTrip(trip.Status, database.TripStatusCompleted) {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("A %v trip cannot be completed", trip.Status), errInvalidTripTransition, false)
+		return
+	}
+
+	if params.EndDate != nil && params.EndDate.Before(trip.StartDate) {
+		respondWithError(w, http.StatusBadRequest, "End date must be after the start date", errEndBeforeStart, false)
+		return
+	}
+
+	endLocation, err := cfg.resolveLocation(params.EndLocation)
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not resolve end location name", err, false)
+		return
+	}
+
+	endDate := sql.NullTime{Time: time.Now().UTC(), Valid: true}
 	if params.EndDate != nil {
-		endDate = sql.NullTime{Time: *params.EndDate, Valid: true}
+		endDate = sql.NullTime{Time: params.EndDate.UTC(), Valid: true}
 	}
 
 	tripID, err := cfg.db.MarkTripEnd(r.Context(), database.MarkTripEndParams{
 		EndDate:     endDate,
-		EndLocation: utils.FormatPoint(params.EndLocation),
+		EndLocation: utils.FormatPoint(endLocation.Location),
 		EndLocationName: sql.NullString{
-			String: params.EndLocation.Name,
+			String: endLocation.Name,
+			Valid:  true,
+		},
+		EndCountryCode: endLocation.CountryCode,
+		EndTimezone: sql.NullString{
+			String: endLocation.Timezone,
 			Valid:  true,
 		},
 		UserID:    user.ID,
@@ -377,6 +710,80 @@ func (cfg apiConfig) handlerMarkTripComplete(w http.ResponseWriter, r *http.Requ
 	})
 }
 
+func (cfg apiConfig) handlerTransitionTrip(action tripAction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := rateLimit(w, r, "general")
+
+		if err != nil {
+			respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+			return
+		}
+
+		userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+		user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+			ID: userID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+			return
+		}
+
+		paramID := chi.URLParam(r, "tripID")
+
+		tripUUID, err := uuid.Parse(paramID)
+
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Invalid route path", err, false)
+			return
+		}
+
+		trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+			UserID: user.ID,
+			ID:     tripUUID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
+			return
+		}
+
+		if !canEditTrip(trip.Role) {
+			respondReadOnly(w)
+			return
+		}
+
+		if !action.allows(trip.Status) {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("A %v trip cannot be %v", trip.Status, action.name), errInvalidTripTransition, false)
+			return
+		}
+
+		tripID, err := cfg.db.UpdateTripStatus(r.Context(), database.UpdateTripStatusParams{
+			Status:        action.to,
+			ID:            trip.ID,
+			UserID:        user.ID,
+			CurrentStatus: trip.Status,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusConflict, "Trip status changed while updating. Please try again.", err, false)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, ApiResponse{
+			Status: "success",
+			Data: struct {
+				TripID uuid.UUID `json:"tripID"`
+				Status string    `json:"status"`
+			}{
+				TripID: tripID,
+				Status: string(action.to),
+			},
+		})
+	}
+}
+
 func (cfg apiConfig) handlerDeleteTrip(w http.ResponseWriter, r *http.Request) {
 	err := rateLimit(w, r, "general")
 
@@ -405,37 +812,33 @@ func (cfg apiConfig) handlerDeleteTrip(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	media, err := cfg.db.GetTripMediaByTripOrStopID(r.Context(), database.GetTripMediaByTripOrStopIDParams{
-		TripID: uuid.NullUUID{
-			UUID:  tripUUID,
-			Valid: true,
-		},
+	trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+		UserID: user.ID,
+		ID:     tripUUID,
 	})
 
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Failed to get trip assets", err, false)
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", err, false)
 		return
 	}
 
-	for _, medium := range media {
-		imageFileName := strings.Split(medium.PhotoUrl.String, "assets/")[1]
-		imageFilePath := path.Join(cfg.assetsRoot, imageFileName)
-
-		if err = utils.DeleteMedia(imageFilePath); err != nil {
-			log.Printf("Failed to delete media at %v", imageFilePath)
-			respondWithError(w, http.StatusInternalServerError, "Something went wrong.", err, false)
-			return
-		}
-
+	if trip.Role != tripRoleOwner {
+		respondWithError(w, http.StatusForbidden, "Only the trip owner can delete it", errNotTripOwner, false)
+		return
 	}
 
-	err = cfg.db.DeleteTrip(r.Context(), database.DeleteTripParams{
+	trashed, err := cfg.db.TrashTrip(r.Context(), database.TrashTripParams{
 		UserID: user.ID,
 		ID:     tripUUID,
 	})
 
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Failed to delete user's trip", err, false)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete user's trip", err, false)
+		return
+	}
+
+	if trashed == 0 {
+		respondWithError(w, http.StatusNotFound, "Unable to find trip possibly deleted", errNothingTrashed, false)
 		return
 	}
 
@@ -0,0 +1,759 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/exif"
+	"github.com/mambo-dev/adventrak-backend/internal/imaging"
+	"github.com/mambo-dev/adventrak-backend/internal/video"
+)
+
+const (
+	tusVersion       = "1.0.0"
+	tusExtensions    = "creation,expiration,termination"
+	tusContentType   = "application/offset+octet-stream"
+	uploadExpiry     = 24 * time.Hour
+	maxPhotoSize     = 25 << 20
+	defaultUploadDir = "uploads"
+)
+
+// imageExtensions lists the photo types accepted over tus by the stored
+// extension.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// uploadLocks holds a *sync.Mutex per upload id so two PATCH requests can
+// never append to the same partial file at once.
+var uploadLocks sync.Map
+
+var (
+	errTusVersion       = errors.New("unsupported Tus-Resumable version")
+	errUploadLength     = errors.New("Upload-Length must be a positive whole number of bytes")
+	errDeferLength      = errors.New("deferred upload lengths are not supported")
+	errUploadMetadata   = errors.New("Upload-Metadata is malformed")
+	errUploadType       = errors.New("filetype must be a supported image or video type")
+	errUploadTarget     = errors.New("metadata must name exactly one of tripID or stopID")
+	errUploadTooLarge   = errors.New("upload exceeds the maximum size for its type")
+	errUploadOffset     = errors.New("Upload-Offset does not match the stored offset")
+	errUploadBusy       = errors.New("another request is already writing to this upload")
+	errUploadOverflow   = errors.New("request body extends past Upload-Length")
+	errUploadIncomplete = errors.New("request body ended before it was fully received")
+	errUploadContent    = errors.New("uploaded file does not match its declared filetype")
+)
+
+type CreateUploadResponse struct {
+	UploadID  uuid.UUID `json:"uploadID"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header, a comma
+// separated list of keys each followed by an optional base64 value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+
+		if key == "" {
+			return nil, errUploadMetadata
+		}
+
+		if _, seen := metadata[key]; seen {
+			return nil, errUploadMetadata
+		}
+
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+
+		if err != nil {
+			return nil, errUploadMetadata
+		}
+
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
+
+func (cfg apiConfig) uploadPartPath(id uuid.UUID) string {
+	return filepath.Join(cfg.uploadsRoot, id.String()+".part")
+}
+
+func (cfg apiConfig) maxUploadSize(mediaType string) int64 {
+	if videoMediaTypes[mediaType] {
+		return cfg.maxVideoSize
+	}
+
+	return maxPhotoSize
+}
+
+func lockUpload(id uuid.UUID) *sync.Mutex {
+	lock, _ := uploadLocks.LoadOrStore(id, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// checkTusResumable sets the protocol header every tus response carries and
+// rejects clients speaking a version we do not implement.
+func checkTusResumable(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		respondWithError(w, http.StatusPreconditionFailed, fmt.Sprintf("Only tus %v is supported.", tusVersion), errTusVersion, false)
+		return false
+	}
+
+	return true
+}
+
+func setUploadHeaders(w http.ResponseWriter, upload database.Upload) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.UploadOffset, 10))
+	w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+func (cfg apiConfig) handlerTusOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(max(cfg.maxVideoSize, maxPhotoSize), 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg apiConfig) handlerCreateUpload(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		respondWithError(w, http.StatusBadRequest, "Uploads must declare their length up front.", errDeferLength, false)
+		return
+	}
+
+	uploadLength, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+
+	if err != nil || uploadLength < 1 {
+		respondWithError(w, http.StatusBadRequest, "Invalid Upload-Length header", errUploadLength, false)
+		return
+	}
+
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Upload-Metadata header", err, false)
+		return
+	}
+
+	mediaType := metadata["filetype"]
+
+	if imageExtensions[mediaType] == "" && !videoMediaTypes[mediaType] {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only .jpeg and .png photos or .mp4, .webm and .mov videos allowed.", errUploadType, false)
+		return
+	}
+
+	if limit := cfg.maxUploadSize(mediaType); uploadLength > limit {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("This file type can be at most %d MB.", limit>>20), errUploadTooLarge, false)
+		return
+	}
+
+	remaining, err := cfg.storageRemaining(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	if uploadLength > remaining {
+		cfg.respondQuotaExceeded(w)
+		return
+	}
+
+	tripID, stopID := metadata["tripID"], metadata["stopID"]
+
+	if (len(tripID) > 0) == (len(stopID) > 0) {
+		respondWithError(w, http.StatusBadRequest, "Use either a trip or a stop id but not both", errUploadTarget, false)
+		return
+	}
+
+	uploadParams := database.CreateUploadParams{
+		UserID:       user.ID,
+		MediaType:    mediaType,
+		FileName:     sql.NullString{String: metadata["filename"], Valid: metadata["filename"] != ""},
+		UploadLength: uploadLength,
+		ExpiresAt:    time.Now().Add(uploadExpiry),
+	}
+
+	if len(tripID) > 0 {
+		tripUUID, err := uuid.Parse(tripID)
+
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid trip id", err, false)
+			return
+		}
+
+		trip, err := cfg.db.GetTrip(r.Context(), database.GetTripParams{
+			UserID: user.ID,
+			ID:     tripUUID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Failed to get this trip, it may have been deleted", err, false)
+			return
+		}
+
+		if !canEditTrip(trip.Role) {
+			respondReadOnly(w)
+			return
+		}
+
+		uploadParams.TripID = uuid.NullUUID{UUID: trip.ID, Valid: true}
+	} else {
+		stopUUID, err := uuid.Parse(stopID)
+
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid stop id", err, false)
+			return
+		}
+
+		stop, err := cfg.db.GetStop(r.Context(), database.GetStopParams{
+			UserID: user.ID,
+			ID:     stopUUID,
+		})
+
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Failed to get this stop, it may have been deleted", err, false)
+			return
+		}
+
+		if !canEditTrip(stop.Role) {
+			respondReadOnly(w)
+			return
+		}
+
+		uploadParams.TripStopID = uuid.NullUUID{UUID: stop.ID, Valid: true}
+	}
+
+	upload, err := cfg.db.CreateUpload(r.Context(), uploadParams)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create upload", err, false)
+		return
+	}
+
+	partFile, err := os.OpenFile(cfg.uploadPartPath(upload.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+
+	if err != nil {
+		cfg.db.DeleteUpload(r.Context(), database.DeleteUploadParams{ID: upload.ID, UserID: user.ID})
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	partFile.Close()
+
+	w.Header().Set("Location", fmt.Sprintf("%v/v1/uploads/%v", cfg.baseApiUrl, upload.ID))
+	setUploadHeaders(w, upload)
+
+	respondWithJSON(w, http.StatusCreated, ApiResponse{
+		Status: "success",
+		Data: CreateUploadResponse{
+			UploadID:  upload.ID,
+			ExpiresAt: upload.ExpiresAt.UTC(),
+		},
+	})
+}
+
+func (cfg apiConfig) handlerGetUploadOffset(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	uploadUUID, err := uuid.Parse(chi.URLParam(r, "uploadID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Invalid upload id", err, false)
+		return
+	}
+
+	upload, err := cfg.db.GetUpload(r.Context(), database.GetUploadParams{
+		ID:     uploadUUID,
+		UserID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find that upload, it may have expired", err, false)
+		return
+	}
+
+	setUploadHeaders(w, upload)
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.UploadLength, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (cfg apiConfig) handlerPatchUpload(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	if r.Header.Get("Content-Type") != tusContentType {
+		respondWithError(w, http.StatusUnsupportedMediaType, fmt.Sprintf("Content-Type must be %v", tusContentType), errors.New("invalid content type"), false)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+
+	if err != nil || offset < 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid Upload-Offset header", errUploadOffset, false)
+		return
+	}
+
+	uploadUUID, err := uuid.Parse(chi.URLParam(r, "uploadID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Invalid upload id", err, false)
+		return
+	}
+
+	lock := lockUpload(uploadUUID)
+
+	if !lock.TryLock() {
+		respondWithError(w, http.StatusConflict, "This upload is already receiving data", errUploadBusy, false)
+		return
+	}
+
+	defer lock.Unlock()
+
+	upload, err := cfg.db.GetUpload(r.Context(), database.GetUploadParams{
+		ID:     uploadUUID,
+		UserID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find that upload, it may have expired", err, false)
+		return
+	}
+
+	if offset != upload.UploadOffset {
+		setUploadHeaders(w, upload)
+		respondWithError(w, http.StatusConflict, "Upload-Offset does not match, resume from the stored offset", errUploadOffset, false)
+		return
+	}
+
+	if upload.CompletedAt.Valid {
+		setUploadHeaders(w, upload)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	partFile, err := os.OpenFile(cfg.uploadPartPath(upload.ID), os.O_WRONLY, 0640)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	defer partFile.Close()
+
+	// Drop anything a crashed request wrote past the last recorded offset.
+	if err := partFile.Truncate(upload.UploadOffset); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	if _, err := partFile.Seek(upload.UploadOffset, io.SeekStart); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+		return
+	}
+
+	remaining := upload.UploadLength - upload.UploadOffset
+
+	written, copyErr := io.Copy(partFile, io.LimitReader(r.Body, remaining))
+
+	if copyErr == nil && written == remaining {
+		if n, _ := r.Body.Read(make([]byte, 1)); n > 0 {
+			partFile.Truncate(upload.UploadOffset)
+			respondWithError(w, http.StatusRequestEntityTooLarge, "The request body is longer than the upload", errUploadOverflow, false)
+			return
+		}
+	}
+
+	// Whatever arrived before a dropped connection is kept, so the client
+	// can HEAD the upload and resume from there.
+	newOffset, err := cfg.db.UpdateUploadOffset(r.Context(), database.UpdateUploadOffsetParams{
+		UploadOffset:   upload.UploadOffset + written,
+		ID:             upload.ID,
+		PreviousOffset: upload.UploadOffset,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "This upload changed while receiving data", errUploadOffset, false)
+		return
+	}
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to save upload progress", err, false)
+		return
+	}
+
+	upload.UploadOffset = newOffset
+	setUploadHeaders(w, upload)
+
+	if copyErr != nil {
+		respondWithError(w, http.StatusBadRequest, "The upload was interrupted, resume from Upload-Offset", errors.Join(errUploadIncomplete, copyErr), false)
+		return
+	}
+
+	if upload.UploadOffset == upload.UploadLength {
+		if err := partFile.Close(); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Something went wrong", err, false)
+			return
+		}
+
+		status, err := cfg.finishUpload(r.Context(), upload)
+
+		if errors.Is(err, errStorageQuota) {
+			cfg.respondQuotaExceeded(w)
+			return
+		}
+
+		if err != nil {
+			respondWithError(w, status, "Failed to save the finished upload", err, false)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// finishUpload checks the assembled file is the media it claimed to be and
+// turns it into a trip_media row exactly like the multipart upload handlers.
+// Files that fail the check, or that no longer fit in the storage quota, are
+// discarded along with the upload.
+func (cfg apiConfig) finishUpload(ctx context.Context, upload database.Upload) (int, error) {
+	mediaParams := database.CreateTripMediaParams{
+		UserID: upload.UserID,
+	}
+
+	if upload.TripID.Valid {
+		trip, err := cfg.db.GetTrip(ctx, database.GetTripParams{UserID: upload.UserID, ID: upload.TripID.UUID})
+
+		if err != nil {
+			return http.StatusNotFound, err
+		}
+
+		if !canEditTrip(trip.Role) {
+			return http.StatusForbidden, errTripReadOnly
+		}
+
+		mediaParams.TripID = upload.TripID
+	} else {
+		stop, err := cfg.db.GetStop(ctx, database.GetStopParams{UserID: upload.UserID, ID: upload.TripStopID.UUID})
+
+		if err != nil {
+			return http.StatusNotFound, err
+		}
+
+		if !canEditTrip(stop.Role) {
+			return http.StatusForbidden, errTripReadOnly
+		}
+
+		mediaParams.TripStopID = upload.TripStopID
+	}
+
+	partPath := cfg.uploadPartPath(upload.ID)
+
+	extension, info, err := detectUploadedMedia(partPath, upload.MediaType)
+
+	if err != nil {
+		cfg.db.DeleteUpload(ctx, database.DeleteUploadParams{ID: upload.ID, UserID: upload.UserID})
+		os.Remove(partPath)
+		return http.StatusUnprocessableEntity, err
+	}
+
+	localPath := partPath
+	var metadata exif.Metadata
+
+	if !videoMediaTypes[upload.MediaType] {
+		metadata, localPath, err = cfg.stageUploadedPhoto(partPath)
+
+		if errors.Is(err, exif.ErrMalformed) || errors.Is(err, imaging.ErrTooManyPixels) {
+			cfg.db.DeleteUpload(ctx, database.DeleteUploadParams{ID: upload.ID, UserID: upload.UserID})
+			os.Remove(partPath)
+			return http.StatusUnprocessableEntity, err
+		}
+
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		defer os.Remove(localPath)
+	}
+
+	key, err := cfg.storeMedia(ctx, upload.UserID, localPath, extension, upload.MediaType)
+
+	if errors.Is(err, errStorageQuota) {
+		cfg.db.DeleteUpload(ctx, database.DeleteUploadParams{ID: upload.ID, UserID: upload.UserID})
+		os.Remove(partPath)
+		return http.StatusRequestEntityTooLarge, err
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	mediaURL := sql.NullString{
+		String: cfg.mediaURL(key),
+		Valid:  true,
+	}
+
+	if videoMediaTypes[upload.MediaType] {
+		mediaParams.VideoUrl = mediaURL
+		mediaParams.DurationSeconds = sql.NullFloat64{Float64: info.Duration.Seconds(), Valid: info.Duration > 0}
+		mediaParams.Width = sql.NullInt32{Int32: int32(info.Width), Valid: info.Width > 0}
+		mediaParams.Height = sql.NullInt32{Int32: int32(info.Height), Valid: info.Height > 0}
+	} else {
+		user, err := cfg.db.GetUser(ctx, database.GetUserParams{ID: upload.UserID})
+
+		if err != nil {
+			cfg.releaseStoredMedia(ctx, mediaURL.String)
+			return http.StatusNotFound, err
+		}
+
+		mediaParams.PhotoUrl = mediaURL
+		cfg.geotagPhoto(ctx, &mediaParams, metadata, user.KeepPhotoLocation)
+	}
+
+	media, err := cfg.db.CreateTripMedia(ctx, mediaParams)
+
+	if err != nil {
+		cfg.releaseStoredMedia(ctx, mediaURL.String)
+		return http.StatusInternalServerError, err
+	}
+
+	if mediaParams.PhotoUrl.Valid {
+		if _, err := cfg.variantsForPhoto(ctx, media, key, localPath); err != nil {
+			log.Printf("Failed to create variants for media %v: %v", media.ID, err)
+		}
+	}
+
+	err = cfg.db.CompleteUpload(ctx, database.CompleteUploadParams{
+		TripMediaID: uuid.NullUUID{UUID: media.ID, Valid: true},
+		ID:          upload.ID,
+	})
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if err := os.Remove(partPath); err != nil {
+		log.Printf("Failed to remove finished upload %v: %v", upload.ID, err)
+	}
+
+	return http.StatusNoContent, nil
+}
+
+// detectUploadedMedia sniffs the finished file and returns the extension it
+// should be stored under, plus container details for videos.
+func detectUploadedMedia(partPath, mediaType string) (string, video.Info, error) {
+	partFile, err := os.Open(partPath)
+
+	if err != nil {
+		return "", video.Info{}, err
+	}
+
+	defer partFile.Close()
+
+	if videoMediaTypes[mediaType] {
+		info, err := video.Probe(partFile)
+
+		if err != nil {
+			return "", video.Info{}, errors.Join(errUploadContent, err)
+		}
+
+		return videoExtensions[info.Format], info, nil
+	}
+
+	header := make([]byte, 512)
+
+	n, err := io.ReadFull(partFile, header)
+
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", video.Info{}, err
+	}
+
+	if http.DetectContentType(header[:n]) != mediaType {
+		return "", video.Info{}, errUploadContent
+	}
+
+	return imageExtensions[mediaType], video.Info{}, nil
+}
+
+// stageUploadedPhoto reads the EXIF of a finished photo upload and stages
+// a copy without it, returning the metadata and the staged file's path.
+func (cfg apiConfig) stageUploadedPhoto(partPath string) (exif.Metadata, string, error) {
+	partFile, err := os.Open(partPath)
+
+	if err != nil {
+		return exif.Metadata{}, "", err
+	}
+
+	defer partFile.Close()
+
+	metadata, err := readPhotoMetadata(partFile)
+
+	if err != nil {
+		return exif.Metadata{}, "", err
+	}
+
+	photoPath, err := cfg.stagePhoto(partFile)
+
+	return metadata, photoPath, err
+}
+
+func (cfg apiConfig) handlerDeleteUpload(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	uploadUUID, err := uuid.Parse(chi.URLParam(r, "uploadID"))
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Invalid upload id", err, false)
+		return
+	}
+
+	lock := lockUpload(uploadUUID)
+
+	if !lock.TryLock() {
+		respondWithError(w, http.StatusConflict, "This upload is still receiving data", errUploadBusy, false)
+		return
+	}
+
+	defer lock.Unlock()
+
+	deleted, err := cfg.db.DeleteUpload(r.Context(), database.DeleteUploadParams{
+		ID:     uploadUUID,
+		UserID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete upload", err, false)
+		return
+	}
+
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Unable to find that upload, it may have expired", sql.ErrNoRows, false)
+		return
+	}
+
+	if err := os.Remove(cfg.uploadPartPath(uploadUUID)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove partial upload %v: %v", uploadUUID, err)
+	}
+
+	uploadLocks.Delete(uploadUUID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// expireUploads drops uploads past their expiry along with the partial
+// files of those that never finished.
+func (cfg apiConfig) expireUploads(ctx context.Context) error {
+	expired, err := cfg.db.DeleteExpiredUploads(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range expired {
+		uploadLocks.Delete(upload.ID)
+
+		if upload.CompletedAt.Valid {
+			continue
+		}
+
+		if err := os.Remove(cfg.uploadPartPath(upload.ID)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove partial upload %v: %v", upload.ID, err)
+		}
+	}
+
+	if len(expired) > 0 {
+		log.Printf("Expired %d uploads", len(expired))
+	}
+
+	return nil
+}
+
+func (cfg apiConfig) expireUploadsPeriodically(interval time.Duration) {
+	for {
+		if err := cfg.expireUploads(context.Background()); err != nil {
+			log.Printf("Failed to expire uploads: %v", err)
+		}
+
+		time.Sleep(interval)
+	}
+}
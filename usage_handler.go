@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/storage"
+)
+
+const defaultStorageQuota = 5 << 30
+
+var errStorageQuota = errors.New("storage quota exceeded")
+
+type TripUsageResponse struct {
+	TripID    uuid.UUID `json:"tripID"`
+	TripTitle string    `json:"tripTitle"`
+	Files     int32     `json:"files"`
+	Bytes     int64     `json:"bytes"`
+}
+
+type UsageResponse struct {
+	UsedBytes      int64               `json:"usedBytes"`
+	QuotaBytes     int64               `json:"quotaBytes"`
+	RemainingBytes int64               `json:"remainingBytes"`
+	Trips          []TripUsageResponse `json:"trips"`
+}
+
+// storageRemaining is how many more bytes the user may store. It goes
+// negative when a user is already over a lowered quota.
+func (cfg apiConfig) storageRemaining(ctx context.Context, userID uuid.UUID) (int64, error) {
+	used, err := cfg.db.GetStorageUsed(ctx, userID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return cfg.storageQuota - used, nil
+}
+
+func (cfg apiConfig) respondQuotaExceeded(w http.ResponseWriter) {
+	respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("This upload would take you over your %d MB storage limit. Delete some media and empty the trash to make room.", cfg.storageQuota>>20), errStorageQuota, false)
+}
+
+// recordBlobSizes fills in the size of files stored before sizes were
+// tracked, so they count towards their owner's quota.
+func (cfg apiConfig) recordBlobSizes(ctx context.Context) {
+	keys, err := cfg.db.GetUnsizedMediaBlobs(ctx)
+
+	if err != nil {
+		log.Printf("Failed to look up stored media sizes: %v", err)
+		return
+	}
+
+	for _, key := range keys {
+		size := int64(0)
+		object, err := cfg.storage.Stat(ctx, key)
+
+		if err == nil {
+			size = object.Size
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Failed to look up the size of stored media %v: %v", key, err)
+			continue
+		}
+
+		err = cfg.db.SetMediaBlobSize(ctx, database.SetMediaBlobSizeParams{
+			Size:       sql.NullInt64{Int64: size, Valid: true},
+			StorageKey: key,
+		})
+
+		if err != nil {
+			log.Printf("Failed to record the size of stored media %v: %v", key, err)
+		}
+	}
+}
+
+func (cfg apiConfig) handlerGetUsage(w http.ResponseWriter, r *http.Request) {
+	err := rateLimit(w, r, "general")
+
+	if err != nil {
+		respondWithError(w, http.StatusForbidden, "Too many requests. Please slow down.", err, false)
+		return
+	}
+
+	userID := r.Context().Value(UserIDKey).(uuid.UUID)
+
+	user, err := cfg.db.GetUser(r.Context(), database.GetUserParams{
+		ID: userID,
+	})
+
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to find user possibly deleted", err, false)
+		return
+	}
+
+	used, err := cfg.db.GetStorageUsed(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get your storage usage", err, false)
+		return
+	}
+
+	trips, err := cfg.db.GetStorageUsageByTrip(r.Context(), user.ID)
+
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get your storage usage", err, false)
+		return
+	}
+
+	usage := UsageResponse{
+		UsedBytes:      used,
+		QuotaBytes:     cfg.storageQuota,
+		RemainingBytes: max(cfg.storageQuota-used, 0),
+		Trips:          make([]TripUsageResponse, 0, len(trips)),
+	}
+
+	for _, trip := range trips {
+		usage.Trips = append(usage.Trips, TripUsageResponse{
+			TripID:    trip.TripID,
+			TripTitle: trip.TripTitle,
+			Files:     trip.Files,
+			Bytes:     trip.Bytes,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, ApiResponse{
+		Status: "success",
+		Data:   usage,
+	})
+}
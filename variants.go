@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mambo-dev/adventrak-backend/internal/database"
+	"github.com/mambo-dev/adventrak-backend/internal/imaging"
+)
+
+const (
+	variantJPEGQuality = 82
+	variantThumbnail   = "thumbnail"
+)
+
+// photoVariants lists the resized copies made of every photo, largest first
+// so each one can be scaled from the previous rather than the original.
+var photoVariants = []struct {
+	name    string
+	maxEdge int
+}{
+	{name: "large", maxEdge: 1600},
+	{name: "medium", maxEdge: 800},
+	{name: variantThumbnail, maxEdge: 320},
+}
+
+// createPhotoVariants stores resized copies of a photo next to it in the
+// photo's own format and records them in media_variants. They are made
+// from a local copy at photoPath and stored under keys built from photoKey.
+// Sizes the photo is too small to need are skipped rather than stored
+// twice. No WebP copies are made: the standard library cannot encode WebP,
+// and a pure Go lossless encoder loses to JPEG on photos and to PNG on
+// graphics.
+func (cfg apiConfig) createPhotoVariants(ctx context.Context, mediaID uuid.UUID, photoKey, photoPath string) ([]database.MediaVariant, error) {
+	photoFile, err := os.Open(photoPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer photoFile.Close()
+
+	metadata, err := readPhotoMetadata(photoFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	img, format, err := imaging.Decode(photoFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var encoded []encodedVariant
+
+	// Variants are re-encoded without EXIF, so they are turned upright here
+	// rather than relying on the orientation tag kept on the original.
+	source := image.Image(imaging.Orient(img, metadata.Orientation))
+	previous := image.Point{}
+
+	for _, variant := range photoVariants {
+		resized, err := imaging.Resize(source, variant.maxEdge)
+
+		if err != nil {
+			return nil, err
+		}
+
+		size := resized.Bounds().Size()
+		source = resized
+
+		if size == previous {
+			continue
+		}
+
+		previous = size
+
+		data, err := encodeVariant(variant.name, format, resized)
+
+		if err != nil {
+			return nil, err
+		}
+
+		encoded = append(encoded, data)
+	}
+
+	var written []string
+	var params []database.CreateMediaVariantParams
+
+	cleanUp := func() {
+		for _, key := range written {
+			if err := cfg.storage.Delete(ctx, key); err != nil {
+				log.Printf("Failed to delete variant %v: %v", key, err)
+			}
+		}
+	}
+
+	for _, variant := range encoded {
+		variantKey := photoVariantKey(photoKey, variant.name, variant.format)
+		data := bytes.NewReader(variant.data)
+
+		if err := cfg.storage.Put(ctx, variantKey, data, data.Size(), "image/"+variant.format); err != nil {
+			cleanUp()
+			return nil, err
+		}
+
+		written = append(written, variantKey)
+		params = append(params, database.CreateMediaVariantParams{
+			TripMediaID: mediaID,
+			Name:        variant.name,
+			Format:      variant.format,
+			Url:         cfg.mediaURL(variantKey),
+			Width:       int32(variant.size.X),
+			Height:      int32(variant.size.Y),
+		})
+	}
+
+	variants := make([]database.MediaVariant, 0, len(params))
+
+	for _, param := range params {
+		variant, err := cfg.db.CreateMediaVariant(ctx, param)
+
+		if err != nil {
+			cleanUp()
+			return nil, err
+		}
+
+		variants = append(variants, variant)
+	}
+
+	return variants, nil
+}
+
+// variantsForPhoto reuses the variants of an identical photo stored before,
+// which share its files, and only makes new ones when there are none.
+func (cfg apiConfig) variantsForPhoto(ctx context.Context, media database.TripMedium, photoKey, photoPath string) ([]database.MediaVariant, error) {
+	variants, err := cfg.db.CopyMediaVariants(ctx, database.CopyMediaVariantsParams{
+		TripMediaID: media.ID,
+		PhotoUrl:    media.PhotoUrl,
+	})
+
+	if err != nil || len(variants) > 0 {
+		return variants, err
+	}
+
+	return cfg.createPhotoVariants(ctx, media.ID, photoKey, photoPath)
+}
+
+func photoVariantKey(photoKey, name, format string) string {
+	return fmt.Sprintf("%v-%v.%v", strings.TrimSuffix(photoKey, path.Ext(photoKey)), name, format)
+}
+
+// photoVariantKeys lists every key a variant of the photo could be stored
+// under, for deleting them without looking up which were made.
+func photoVariantKeys(photoKey string) []string {
+	if !strings.HasPrefix(mime.TypeByExtension(path.Ext(photoKey)), "image/") {
+		return nil
+	}
+
+	var keys []string
+
+	for _, variant := range photoVariants {
+		for _, format := range []string{"jpeg", "png"} {
+			keys = append(keys, photoVariantKey(photoKey, variant.name, format))
+		}
+	}
+
+	return keys
+}
+
+// encodedVariant is a resized copy of a photo encoded in one format.
+type encodedVariant struct {
+	name   string
+	format string
+	size   image.Point
+	data   []byte
+}
+
+func encodeVariant(name, format string, img image.Image) (encodedVariant, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: variantJPEGQuality})
+	case "png":
+		err = png.Encode(&buf, img)
+	default:
+		err = fmt.Errorf("unsupported variant format %q", format)
+	}
+
+	return encodedVariant{name: name, format: format, size: img.Bounds().Size(), data: buf.Bytes()}, err
+}
+
+// attachVariants fills in the thumbnail and the srcset strings, one per
+// format, that clients can hand straight to <img srcset> or <source>.
+func (cfg apiConfig) attachVariants(response *MediaResponse, variants []database.MediaVariant) {
+	for _, variant := range variants {
+		if response.Srcset == nil {
+			response.Srcset = map[string]string{}
+		}
+
+		candidate := fmt.Sprintf("%v %vw", cfg.signMediaURL(variant.Url), variant.Width)
+
+		if existing := response.Srcset[variant.Format]; existing != "" {
+			candidate = existing + ", " + candidate
+		}
+
+		response.Srcset[variant.Format] = candidate
+
+		if variant.Name == variantThumbnail {
+			response.ThumbnailURL = cfg.signMediaURL(variant.Url)
+		}
+	}
+}
+
+// transformMediaList converts media rows to responses with their variants,
+// fetched in a single query.
+func (cfg apiConfig) transformMediaList(ctx context.Context, media []database.TripMedium) ([]MediaResponse, error) {
+	ids := make([]uuid.UUID, 0, len(media))
+
+	for _, medium := range media {
+		ids = append(ids, medium.ID)
+	}
+
+	variants, err := cfg.db.GetMediaVariants(ctx, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	byMedia := map[uuid.UUID][]database.MediaVariant{}
+
+	for _, variant := range variants {
+		byMedia[variant.TripMediaID] = append(byMedia[variant.TripMediaID], variant)
+	}
+
+	responses := make([]MediaResponse, 0, len(media))
+
+	for _, medium := range media {
+		response := cfg.transformMedia(medium)
+		cfg.attachVariants(&response, byMedia[medium.ID])
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// photoResponse builds the response for a freshly uploaded photo. The
+// original is already saved, so a photo whose variants cannot be made is
+// still returned, just without a srcset.
+func (cfg apiConfig) photoResponse(ctx context.Context, media database.TripMedium, photoPath string) MediaResponse {
+	response := cfg.transformMedia(media)
+
+	photoKey, err := cfg.mediaKey(media.PhotoUrl.String)
+
+	if err != nil {
+		log.Printf("Failed to create variants for media %v: %v", media.ID, err)
+		return response
+	}
+
+	variants, err := cfg.variantsForPhoto(ctx, media, photoKey, photoPath)
+
+	if err != nil {
+		log.Printf("Failed to create variants for media %v: %v", media.ID, err)
+		return response
+	}
+
+	cfg.attachVariants(&response, variants)
+
+	return response
+}